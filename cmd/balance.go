@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"quai-transfer/config"
+	"quai-transfer/utils"
+	"quai-transfer/wallet"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	balanceKeyFile string
+	balanceAddress string
+)
+
+var balanceCmd = &cobra.Command{
+	Use:     BalanceCmdName + " [-p|--pk_file /path/to/private_key.json | --address <address>]",
+	Short:   BalanceCmdShortDesc,
+	RunE:    runBalance,
+	Version: Version,
+}
+
+func init() {
+	flags := balanceCmd.Flags()
+	flags.StringVarP(&balanceKeyFile, "pk_file", "p", "", "Private key file of the wallet (defaults to key_file)")
+	flags.StringVar(&balanceAddress, "address", "", "Address to query instead of a key's, no private key needed")
+	flags.SortFlags = false
+	balanceCmd.MarkFlagsMutuallyExclusive("pk_file", "address")
+}
+
+func runBalance(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to initialize config: %w", err)
+	}
+
+	var w *wallet.Wallet
+	if balanceAddress != "" {
+		address, parseErr := parseAddress(balanceAddress)
+		if parseErr != nil {
+			return parseErr
+		}
+		applyChainIDOverride(cfg)
+		w, err = wallet.NewWatchWallet(address, cfg)
+	} else {
+		w, err = loadWallet(cfg, balanceKeyFile)
+	}
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	balance, err := w.GetBalance(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to get balance: %w", err)
+	}
+
+	fmt.Printf("Address: %s\n", w.GetAddress().Hex())
+	fmt.Printf("Balance: %s Quai (%s wei)\n", utils.ToQuai(balance.String()), balance)
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"quai-transfer/config"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	broadcastRaw     string
+	broadcastKeyFile string
+)
+
+var broadcastCmd = &cobra.Command{
+	Use:     BroadcastCmdName + " --raw <raw_transaction> [-p|--pk_file /path/to/private_key.json]",
+	Short:   BroadcastCmdShortDesc,
+	RunE:    runBroadcast,
+	Version: Version,
+}
+
+func init() {
+	flags := broadcastCmd.Flags()
+	flags.StringVar(&broadcastRaw, "raw", "", "Signed raw transaction, as printed by preview or get-raw")
+	flags.StringVarP(&broadcastKeyFile, "pk_file", "p", "", "Private key file of the sender (defaults to key_file)")
+	flags.SortFlags = false
+
+	_ = broadcastCmd.MarkFlagRequired("raw")
+}
+
+func runBroadcast(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to initialize config: %w", err)
+	}
+
+	w, err := loadWallet(cfg, broadcastKeyFile)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	tx, err := w.BroadcastRawTransaction(context.Background(), broadcastRaw)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Broadcast transaction %s with nonce %d\n", tx.Hash().Hex(), tx.Nonce())
+	fmt.Println("Raw broadcasts are not recorded: a batch run will not know this entry was paid")
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"quai-transfer/config"
+	"quai-transfer/keystore"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	checkPasswordAddress string
+	checkPasswordFile    string
+)
+
+var checkPasswordCmd = &cobra.Command{
+	Use:     CheckPasswordCmdName + " -a|--address <address> [--password-file /path/to/password]",
+	Short:   CheckPasswordCmdShortDesc,
+	RunE:    runCheckPassword,
+	Version: Version,
+}
+
+func init() {
+	flags := checkPasswordCmd.Flags()
+	flags.StringVarP(&checkPasswordAddress, "address", "a", "", "Address of the key to check the password of (required)")
+	flags.StringVar(&checkPasswordFile, "password-file", "", "File holding the password, prompted for when omitted")
+	flags.SortFlags = false
+	checkPasswordCmd.MarkFlagRequired("address")
+}
+
+func runCheckPassword(cmd *cobra.Command, args []string) error {
+	address, err := parseAddress(checkPasswordAddress)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to initialize config: %w", err)
+	}
+	ks, err := newKeyManager(cfg)
+	if err != nil {
+		return err
+	}
+
+	var password string
+	if checkPasswordFile != "" {
+		password, err = readPasswordFile(checkPasswordFile)
+	} else {
+		password, err = keystore.ReadPassword("Enter the password: ")
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := ks.VerifyPassword(address, password); err != nil {
+		switch {
+		case errors.Is(err, keystore.ErrDecrypt):
+			return fmt.Errorf("wrong password for %s", address.Hex())
+		case errors.Is(err, keystore.ErrNoMatch):
+			return fmt.Errorf("no key for %s in the keystore", address.Hex())
+		}
+		return fmt.Errorf("failed to check password of %s: %w", address.Hex(), err)
+	}
+	fmt.Printf("🔑 PASSWORD OK | %s\n", address.Hex())
+	return nil
+}
+
+// readPasswordFile reads a password from a file, without the trailing newline
+func readPasswordFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read password file: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"quai-transfer/config"
+	"quai-transfer/dal"
+	"quai-transfer/dal/models"
+	"quai-transfer/utils"
+	"quai-transfer/wallet"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	requeue         bool
+	requeueEntryIDs []int32
+)
+
+var deadLetterCmd = &cobra.Command{
+	Use:     DeadLetterCmdName + " [--requeue [--id <entry_id>]...]",
+	Short:   DeadLetterCmdShortDesc,
+	RunE:    runDeadLetter,
+	Version: Version,
+}
+
+func init() {
+	flags := deadLetterCmd.Flags()
+	flags.BoolVar(&requeue, "requeue", false, "Move dead-lettered entries back to pending so the next run broadcasts them again")
+	flags.Int32SliceVar(&requeueEntryIDs, "id", nil, "Entry IDs to requeue, all dead letters when omitted")
+	flags.SortFlags = false
+}
+
+func runDeadLetter(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to initialize config: %w", err)
+	}
+
+	dal.DBInit(cfg)
+	txDAL := dal.NewTransactionDAL(dal.InterDB)
+	ctx := context.Background()
+
+	if requeue {
+		n, err := txDAL.RequeueDeadLetters(ctx, wallet.SpentNonceFailureCodes, requeueEntryIDs...)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Requeued %d dead-lettered entries\n", n)
+		return nil
+	}
+	if len(requeueEntryIDs) > 0 {
+		return fmt.Errorf("--id requires --requeue")
+	}
+
+	txs, err := txDAL.ListDeadLetters(ctx)
+	if err != nil {
+		return err
+	}
+	if len(txs) == 0 {
+		fmt.Println("No dead-lettered entries")
+		return nil
+	}
+
+	byCode := make(map[string]int)
+	for _, tx := range txs {
+		code := tx.FailureCode
+		if code == "" {
+			code = "unknown"
+		}
+		byCode[code]++
+		fmt.Printf("Entry ID: %d | To: %s | Amount: %s Quai | Nonce: %d | Retries: %d | Tx Hash: %s\n  Failure: %s | %s\n",
+			tx.ID, tx.ToAddress, utils.ToQuai(tx.Value.String()), tx.Nonce, tx.RetryCount, tx.TxHash, code, failureReason(tx))
+	}
+
+	codes := make([]string, 0, len(byCode))
+	for code := range byCode {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		fmt.Printf("%s: %d\n", code, byCode[code])
+	}
+	fmt.Printf("%d dead-lettered entries, requeue them with --requeue once the cause is fixed\n", len(txs))
+	return nil
+}
+
+// failureReason returns why a transaction was dead-lettered. Records dead-lettered before the
+// reason was stored only have their last broadcast error.
+func failureReason(tx *models.Transaction) string {
+	if tx.FailureReason != "" {
+		return tx.FailureReason
+	}
+	return tx.LastError
+}
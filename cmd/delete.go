@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"quai-transfer/config"
+	"quai-transfer/keystore"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	deleteAddress string
+	deleteYes     bool
+)
+
+var deleteCmd = &cobra.Command{
+	Use:     DeleteCmdName + " -a|--address <address> [-y|--yes]",
+	Short:   DeleteCmdShortDesc,
+	RunE:    runDelete,
+	Version: Version,
+}
+
+func init() {
+	flags := deleteCmd.Flags()
+	flags.StringVarP(&deleteAddress, "address", "a", "", "Address of the key to delete (required)")
+	flags.BoolVarP(&deleteYes, "yes", "y", false, "Skip the confirmation prompt")
+	flags.SortFlags = false
+	deleteCmd.MarkFlagRequired("address")
+}
+
+func runDelete(cmd *cobra.Command, args []string) error {
+	address, err := parseAddress(deleteAddress)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to initialize config: %w", err)
+	}
+	ks, err := newKeyManager(cfg)
+	if err != nil {
+		return err
+	}
+
+	if !deleteYes {
+		fmt.Printf("Delete the key of %s? Funds it holds can't be sent without a backup. [y/N]: ", address.Hex())
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			fmt.Println("Aborted, nothing was deleted")
+			return nil
+		}
+	}
+
+	password, err := keystore.ReadPassword("Enter the password of the key: ")
+	if err != nil {
+		return err
+	}
+	if err := ks.DeleteAccount(address, password); err != nil {
+		return fmt.Errorf("failed to delete key of %s: %w", address.Hex(), err)
+	}
+	fmt.Printf("🗑️ KEY DELETED | %s\n", address.Hex())
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"quai-transfer/config"
+	"quai-transfer/wallet"
+
+	"github.com/spf13/cobra"
+)
+
+var doctorKeyFile string
+
+var doctorCmd = &cobra.Command{
+	Use:     DoctorCmdName + " [-p|--pk_file /path/to/private_key.json]",
+	Short:   DoctorCmdShortDesc,
+	RunE:    runDoctor,
+	Version: Version,
+}
+
+func init() {
+	flags := doctorCmd.Flags()
+	flags.StringVarP(&doctorKeyFile, "pk_file", "p", "", "Private key file of the wallet to check (defaults to key_file)")
+	flags.SortFlags = false
+}
+
+// doctorCheck is a read-only diagnostic. It returns a one-line detail and whether the check passed.
+type doctorCheck struct {
+	name string
+	run  func(ctx context.Context, w *wallet.Wallet) (string, bool, error)
+}
+
+var doctorChecks = []doctorCheck{
+	{name: "signing", run: checkSigning},
+	{name: "clock skew", run: checkClockSkew},
+	{name: "node sync", run: checkNodeSync},
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to initialize config: %w", err)
+	}
+
+	// Loading the wallet already checks the key, the RPC connection and the chain ID
+	w, err := loadWallet(cfg, doctorKeyFile)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	fmt.Printf("✅ wallet: %s on network %s\n", w.GetAddress().Hex(), cfg.Network)
+
+	ctx := context.Background()
+	failed := 0
+	for _, check := range doctorChecks {
+		detail, ok, err := check.run(ctx, w)
+		switch {
+		case err != nil:
+			failed++
+			fmt.Printf("❌ %s: %v\n", check.name, err)
+		case !ok:
+			failed++
+			fmt.Printf("⚠️ %s: %s\n", check.name, detail)
+		default:
+			fmt.Printf("✅ %s: %s\n", check.name, detail)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(doctorChecks))
+	}
+	return nil
+}
+
+func checkClockSkew(ctx context.Context, w *wallet.Wallet) (string, bool, error) {
+	skew, ok, err := w.CheckClockSkew(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	return fmt.Sprintf("local clock is %s from the latest block", skew.Round(time.Second)), ok, nil
+}
+
+func checkSigning(ctx context.Context, w *wallet.Wallet) (string, bool, error) {
+	if err := w.CheckSigning(ctx); err != nil {
+		return "", false, err
+	}
+	return "a transaction signed with the key recovers its sender for this chain ID only", true, nil
+}
+
+func checkNodeSync(ctx context.Context, w *wallet.Wallet) (string, bool, error) {
+	age, err := w.CheckNodeSync(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	return fmt.Sprintf("node is not syncing, its latest block is %s old", age.Round(time.Second)), true, nil
+}
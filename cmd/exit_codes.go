@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	wtypes "quai-transfer/types"
+	"quai-transfer/wallet"
+)
+
+// Process exit codes. When a batch has several outcomes, the lowest non-zero code wins.
+const (
+	ExitOK                  = 0 // every entry confirmed or was already processed
+	ExitError               = 1 // the command failed before the batch ran (config, key, connection...)
+	ExitInsufficientBalance = 2 // the balance can't cover the batch, nothing was sent
+	ExitFailed              = 3 // some entries failed, reverted or were dead-lettered
+	ExitUnsent              = 4 // broadcasting stopped early, some entries were never sent
+	ExitUnprocessed         = 5 // some transactions were sent but not confirmed before monitoring stopped
+	ExitInvalid             = 6 // some entries were invalid and skipped, or strict validation aborted the batch
+)
+
+// exitError is a command error with the exit code the process should end with
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+func withExitCode(code int, err error) error {
+	return &exitError{code: code, err: err}
+}
+
+// exitCode returns the exit code of a command error, ExitError unless the command set one
+func exitCode(err error) int {
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	return ExitError
+}
+
+// batchOutcome turns the result of a batch into the command error, so the exit code tells a
+// scheduler whether to alert, retry or move on
+func batchOutcome(result *wallet.BatchResult, err error) error {
+	code := batchExitCode(result, err)
+	if code == ExitOK {
+		return nil
+	}
+	if err == nil {
+		err = fmt.Errorf("batch incomplete: %d failed, %d dead-lettered, %d unsent, %d unconfirmed, %d invalid",
+			result.Failed, result.DeadLettered, result.Unsent, result.Unprocessed, result.Invalid)
+	} else {
+		err = fmt.Errorf("batch transfer aborted: %w", err)
+	}
+	return withExitCode(code, err)
+}
+
+func batchExitCode(result *wallet.BatchResult, err error) int {
+	switch {
+	case errors.Is(err, wtypes.ErrInsufficientBalance):
+		return ExitInsufficientBalance
+	case result == nil:
+		if err != nil {
+			return ExitError
+		}
+		return ExitOK
+	case result.Failed > 0 || result.Reverted > 0 || result.DeadLettered > 0:
+		return ExitFailed
+	case result.Unsent > 0:
+		return ExitUnsent
+	case result.Unprocessed > 0:
+		return ExitUnprocessed
+	case result.Invalid > 0 || errors.Is(err, wtypes.ErrInvalidEntries):
+		return ExitInvalid
+	case err != nil:
+		return ExitError
+	}
+	return ExitOK
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"quai-transfer/config"
+	"quai-transfer/keystore"
+	"quai-transfer/qrcode"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportQRAddress string
+	exportQROutput  string
+	exportQRScale   int
+	exportQRInvert  bool
+)
+
+var exportQRCmd = &cobra.Command{
+	Use:     ExportQRCmdName + " -a|--address <address> [-o|--output key.png]",
+	Short:   ExportQRCmdShortDesc,
+	RunE:    runExportQR,
+	Version: Version,
+}
+
+func init() {
+	flags := exportQRCmd.Flags()
+	flags.StringVarP(&exportQRAddress, "address", "a", "", "Address of the key to export (required)")
+	flags.StringVarP(&exportQROutput, "output", "o", "", "PNG file to write the QR code to, printed to the terminal when omitted")
+	flags.IntVar(&exportQRScale, "scale", 8, "Pixels per module of the PNG image")
+	flags.BoolVar(&exportQRInvert, "invert", false, "Print dark modules as blocks, for terminals with a light background")
+	flags.SortFlags = false
+	exportQRCmd.MarkFlagRequired("address")
+}
+
+func runExportQR(cmd *cobra.Command, args []string) error {
+	address, err := parseAddress(exportQRAddress)
+	if err != nil {
+		return err
+	}
+	if exportQRScale < 1 {
+		return fmt.Errorf("invalid --scale %d, must be at least 1", exportQRScale)
+	}
+
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to initialize config: %w", err)
+	}
+	ks, err := newKeyManager(cfg)
+	if err != nil {
+		return err
+	}
+
+	pass, err := keystore.ReadPassword("Enter the current password: ")
+	if err != nil {
+		return err
+	}
+	backupPass, err := keystore.PromptAndConfirmPassword("Enter the password of the backup: ")
+	if err != nil {
+		return err
+	}
+	payload, err := ks.ExportBackup(address, pass, backupPass)
+	if err != nil {
+		return fmt.Errorf("failed to export key of %s: %w", address.Hex(), err)
+	}
+
+	code, err := qrcode.Encode([]byte(payload))
+	if err != nil {
+		return err
+	}
+	if exportQROutput == "" {
+		fmt.Print(code.Terminal(exportQRInvert))
+	} else {
+		f, err := os.OpenFile(exportQROutput, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", exportQROutput, err)
+		}
+		if err := code.WritePNG(f, exportQRScale); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to write %s: %w", exportQROutput, err)
+		}
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("failed to write %s: %w", exportQROutput, err)
+		}
+	}
+	fmt.Printf("🔐 KEY EXPORTED | %s | %d bytes, encrypted with the password of the backup\n", address.Hex(), len(payload))
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"quai-transfer/config"
+	"quai-transfer/dal"
+	"quai-transfer/wallet"
+
+	"github.com/spf13/cobra"
+)
+
+var rawEntryID int32
+
+var getRawCmd = &cobra.Command{
+	Use:     GetRawCmdName + " --id <entry_id>",
+	Short:   GetRawCmdShortDesc,
+	RunE:    runGetRaw,
+	Version: Version,
+}
+
+func init() {
+	flags := getRawCmd.Flags()
+	flags.Int32Var(&rawEntryID, "id", 0, "Entry ID of the recorded transaction")
+	flags.SortFlags = false
+
+	_ = getRawCmd.MarkFlagRequired("id")
+}
+
+func runGetRaw(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to initialize config: %w", err)
+	}
+
+	dal.DBInit(cfg)
+	txDAL := dal.NewTransactionDAL(dal.InterDB)
+
+	txRecord, err := txDAL.GetTransactionByID(context.Background(), rawEntryID)
+	if err != nil {
+		return err
+	}
+	if txRecord == nil {
+		return fmt.Errorf("no transaction recorded for entry ID %d", rawEntryID)
+	}
+
+	tx, err := wallet.DecodeStoredTransaction(txRecord)
+	if err != nil {
+		return err
+	}
+
+	raw, err := wallet.EncodeRawTransaction(tx)
+	if err != nil {
+		return fmt.Errorf("failed to encode transaction: %w", err)
+	}
+
+	fmt.Printf("Entry ID: %d\nTx Hash: %s\nNonce: %d\nRaw Transaction: %s\n", rawEntryID, tx.Hash().Hex(), tx.Nonce(), raw)
+	return nil
+}
@@ -4,7 +4,7 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"quai-transfer/keystore"
+	"quai-transfer/config"
 )
 
 var importKeyCmd = &cobra.Command{
@@ -15,9 +15,14 @@ var importKeyCmd = &cobra.Command{
 
 func runImportKey(cmd *cobra.Command, args []string) error {
 	// Initialize keystore
-	ks, err := keystore.NewKeyManager(keyDir)
+	cfg, err := config.LoadConfig(configFile)
 	if err != nil {
-		return fmt.Errorf("failed to initialize keystore: %w", err)
+		return fmt.Errorf("failed to initialize config: %w", err)
+	}
+
+	ks, err := newKeyManager(cfg)
+	if err != nil {
+		return err
 	}
 
 	// Import the private key
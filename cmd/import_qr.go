@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"quai-transfer/config"
+	"quai-transfer/keystore"
+
+	"github.com/spf13/cobra"
+)
+
+var importQRFile string
+
+var importQRCmd = &cobra.Command{
+	Use:     ImportQRCmdName + " [-f|--file scanned.txt]",
+	Short:   ImportQRCmdShortDesc,
+	RunE:    runImportQR,
+	Version: Version,
+}
+
+func init() {
+	flags := importQRCmd.Flags()
+	flags.StringVarP(&importQRFile, "file", "f", "", "File holding the scanned text of the QR code, read from stdin when omitted")
+	flags.SortFlags = false
+}
+
+func runImportQR(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to initialize config: %w", err)
+	}
+	ks, err := newKeyManager(cfg)
+	if err != nil {
+		return err
+	}
+
+	var payload []byte
+	if importQRFile != "" {
+		payload, err = os.ReadFile(importQRFile)
+	} else {
+		// A scanner acting as a keyboard types the text and a newline
+		fmt.Print("Scan the QR code: ")
+		var line string
+		line, err = bufio.NewReader(os.Stdin).ReadString('\n')
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+		payload = []byte(line)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read the scanned QR code: %w", err)
+	}
+
+	address, err := ks.ImportBackup(string(payload))
+	if err != nil {
+		if errors.Is(err, keystore.ErrBackupChecksum) {
+			return fmt.Errorf("%w, scan the QR code again", err)
+		}
+		return fmt.Errorf("failed to import key: %w", err)
+	}
+	fmt.Printf("🔐 KEY IMPORTED | %s | Still encrypted with the password of the backup, change it with %s\n", address.Hex(), PasswdCmdName)
+	return nil
+}
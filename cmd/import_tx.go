@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"quai-transfer/config"
+	"quai-transfer/utils"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/common/hexutil"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importTxHash    string
+	importTxID      int32
+	importTxMiner   string
+	importTxKeyFile string
+)
+
+var importTxCmd = &cobra.Command{
+	Use:     ImportTxCmdName + " --hash <tx_hash> --id <entry_id> [--miner <miner_account>] [-p|--pk_file /path/to/private_key.json]",
+	Short:   ImportTxCmdShortDesc,
+	RunE:    runImportTx,
+	Version: Version,
+}
+
+func init() {
+	flags := importTxCmd.Flags()
+	flags.StringVar(&importTxHash, "hash", "", "Hash of the mined transaction")
+	flags.Int32Var(&importTxID, "id", 0, "Entry ID the transaction paid, as in the transfer CSV")
+	flags.StringVar(&importTxMiner, "miner", "", "Miner account of the entry, as in the transfer CSV")
+	flags.StringVarP(&importTxKeyFile, "pk_file", "p", "", "Private key file of the sender (defaults to key_file)")
+	flags.SortFlags = false
+
+	_ = importTxCmd.MarkFlagRequired("hash")
+	_ = importTxCmd.MarkFlagRequired("id")
+}
+
+func runImportTx(cmd *cobra.Command, args []string) error {
+	hash, err := hexutil.Decode(importTxHash)
+	if err != nil || len(hash) != common.HashLength {
+		return fmt.Errorf("invalid transaction hash %q, must be 0x and 64 hex digits", importTxHash)
+	}
+
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to initialize config: %w", err)
+	}
+
+	w, err := loadWallet(cfg, importTxKeyFile)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	txRecord, err := w.ImportTransaction(context.Background(), common.BytesToHash(hash), importTxID, importTxMiner)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Imported transaction %s as entry %d: %s Quai to %s, nonce %d, %s in block %d\n",
+		txRecord.TxHash, txRecord.ID, utils.ToQuai(txRecord.Value.String()), txRecord.ToAddress, txRecord.Nonce, txRecord.Status, txRecord.BlockNumber)
+	return nil
+}
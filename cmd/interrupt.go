@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// interruptContext returns a context canceled at the first SIGINT or SIGTERM, until the
+// returned stop is called. Canceling stops the broadcast of new entries; what was already
+// broadcast is checked once more and reported as unconfirmed, as on a monitoring timeout.
+// Signal handling is given back at the first signal, so a second Ctrl+C quits at once.
+func interruptContext(parent context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case sig := <-signals:
+			signal.Stop(signals)
+			log.Printf("🛑 INTERRUPTED | %s received | No new transactions are broadcast, pending ones are checked once more | Press Ctrl+C again to quit at once", sig)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
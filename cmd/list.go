@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+
+	"quai-transfer/config"
+	"quai-transfer/wallet"
+
+	"github.com/spf13/cobra"
+)
+
+var listCmd = &cobra.Command{
+	Use:     ListCmdName,
+	Short:   ListCmdShortDesc,
+	RunE:    runList,
+	Version: Version,
+}
+
+func runList(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to initialize config: %w", err)
+	}
+
+	ks, err := newKeyManager(cfg)
+	if err != nil {
+		return err
+	}
+	accounts, err := ks.ListAccounts()
+	if err != nil {
+		return fmt.Errorf("failed to list keys: %w", err)
+	}
+	if len(accounts) == 0 {
+		fmt.Println("No keys in the keystore")
+		return nil
+	}
+
+	for _, account := range accounts {
+		location := account.Address.Location()
+		fmt.Printf("Address: %s | Location: %d-%d | Ledger: %s | File: %s\n", account.Address.Hex(),
+			location.Region(), location.Zone(), wallet.LedgerOf(account.Address.Hex()), account.URL.Path)
+	}
+	fmt.Printf("%d keys\n", len(accounts))
+	return nil
+}
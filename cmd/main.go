@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 
+	"quai-transfer/utils"
+
 	"github.com/spf13/cobra"
 )
 
@@ -17,13 +19,40 @@ var rootCmd = &cobra.Command{
 
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "", "Configuration file path")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colored output (also disabled by NO_COLOR or when stdout isn't a terminal)")
+	rootCmd.PersistentFlags().BoolVar(&trustNodeChainID, "trust-node-chain-id", false, "Sign with the chain ID the node reports when it differs from chain_id (overrides trust_node_chain_id)")
 	rootCmd.Flags().SortFlags = false
 	_ = rootCmd.MarkFlagRequired("config")
+	cobra.OnInitialize(func() {
+		if noColor {
+			utils.DisableColor()
+		}
+	})
 
 	// Add subcommands
 	rootCmd.AddCommand(createWalletCmd)
 	rootCmd.AddCommand(transferCmd)
 	rootCmd.AddCommand(importKeyCmd)
+	rootCmd.AddCommand(getRawCmd)
+	rootCmd.AddCommand(replayCmd)
+	rootCmd.AddCommand(deadLetterCmd)
+	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(pruneCmd)
+	rootCmd.AddCommand(previewCmd)
+	rootCmd.AddCommand(broadcastCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(whoamiCmd)
+	rootCmd.AddCommand(rekeyCmd)
+	rootCmd.AddCommand(monitorAddressesCmd)
+	rootCmd.AddCommand(balanceCmd)
+	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(passwdCmd)
+	rootCmd.AddCommand(deleteCmd)
+	rootCmd.AddCommand(shardStatsCmd)
+	rootCmd.AddCommand(checkPasswordCmd)
+	rootCmd.AddCommand(exportQRCmd)
+	rootCmd.AddCommand(importQRCmd)
+	rootCmd.AddCommand(importTxCmd)
 
 	// Require a subcommand
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
@@ -35,6 +64,6 @@ func main() {
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
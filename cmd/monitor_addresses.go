@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+	"time"
+
+	"quai-transfer/config"
+	"quai-transfer/utils"
+	"quai-transfer/wallet"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	monitorAddressFile string
+	monitorInterval    time.Duration
+	monitorJSON        bool
+)
+
+var monitorAddressesCmd = &cobra.Command{
+	Use:     MonitorAddressesCmdName + " -f|--file /path/to/addresses.txt [--interval <duration>] [--json]",
+	Short:   MonitorAddressesCmdShortDesc,
+	RunE:    runMonitorAddresses,
+	Version: Version,
+}
+
+func init() {
+	flags := monitorAddressesCmd.Flags()
+	flags.StringVarP(&monitorAddressFile, "file", "f", "", "File listing the addresses to monitor, one per line (required)")
+	flags.DurationVar(&monitorInterval, "interval", 30*time.Second, "How often balances and pending transactions are refreshed")
+	flags.BoolVar(&monitorJSON, "json", false, "Print a JSON object per snapshot and per incoming transaction instead of a table")
+	flags.SortFlags = false
+	monitorAddressesCmd.MarkFlagRequired("file")
+}
+
+// monitorRow is the JSON form of an address in a snapshot, values in wei
+type monitorRow struct {
+	Address  string `json:"address"`
+	Balance  string `json:"balance"`
+	Nonce    uint64 `json:"nonce"`
+	Pending  uint64 `json:"pending"`
+	Incoming int    `json:"incoming"`
+	Received string `json:"received"`
+	Error    string `json:"error,omitempty"`
+}
+
+// monitorEvent is a line of the JSON stream, either a snapshot or an incoming transaction
+type monitorEvent struct {
+	Type      string       `json:"type"`
+	Time      time.Time    `json:"time"`
+	Addresses []monitorRow `json:"addresses,omitempty"`
+	Balance   string       `json:"total_balance,omitempty"`
+	Pending   uint64       `json:"total_pending,omitempty"`
+	Hash      string       `json:"hash,omitempty"`
+	From      string       `json:"from,omitempty"`
+	To        string       `json:"to,omitempty"`
+	Value     string       `json:"value,omitempty"`
+	Block     uint64       `json:"block,omitempty"`
+}
+
+func runMonitorAddresses(cmd *cobra.Command, args []string) error {
+	if monitorInterval <= 0 {
+		return fmt.Errorf("invalid --interval %s, must be positive", monitorInterval)
+	}
+
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to initialize config: %w", err)
+	}
+	applyChainIDOverride(cfg)
+
+	addresses, err := utils.ReadAddressFile(monitorAddressFile)
+	if err != nil {
+		return err
+	}
+
+	monitor, err := wallet.NewAddressMonitor(addresses, cfg)
+	if err != nil {
+		return err
+	}
+	defer monitor.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	incoming, err := monitor.Watch(ctx)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	var recent []wallet.IncomingTx
+	ticker := time.NewTicker(monitorInterval)
+	defer ticker.Stop()
+
+	show := func() {
+		statuses := monitor.Snapshot(ctx)
+		if monitorJSON {
+			enc.Encode(snapshotEvent(statuses))
+			return
+		}
+		printMonitorTable(statuses, recent)
+	}
+
+	show()
+	for {
+		select {
+		case tx, ok := <-incoming:
+			if !ok {
+				return nil
+			}
+			if monitorJSON {
+				enc.Encode(monitorEvent{
+					Type:  "incoming",
+					Time:  time.Now().UTC(),
+					Hash:  tx.Hash.Hex(),
+					From:  tx.From.Hex(),
+					To:    tx.To.Hex(),
+					Value: tx.Value.String(),
+					Block: tx.BlockNumber,
+				})
+				continue
+			}
+			recent = append(recent, tx)
+			if len(recent) > 10 {
+				recent = recent[1:]
+			}
+			show()
+		case <-ticker.C:
+			show()
+		}
+	}
+}
+
+// snapshotEvent converts a snapshot to its JSON stream line
+func snapshotEvent(statuses []wallet.AddressStatus) monitorEvent {
+	balance, pending := monitorTotals(statuses)
+	event := monitorEvent{Type: "snapshot", Time: time.Now().UTC(), Balance: balance.String(), Pending: pending}
+	for _, s := range statuses {
+		row := monitorRow{
+			Address:  s.Address.Hex(),
+			Balance:  s.Balance.String(),
+			Nonce:    s.Nonce,
+			Pending:  s.Pending,
+			Incoming: s.Incoming,
+			Received: s.Received.String(),
+		}
+		if s.Err != nil {
+			row.Error = s.Err.Error()
+		}
+		event.Addresses = append(event.Addresses, row)
+	}
+	return event
+}
+
+// printMonitorTable clears the terminal and prints the snapshot with the latest deposits.
+// Without colors the snapshots are printed one after the other instead.
+func printMonitorTable(statuses []wallet.AddressStatus, recent []wallet.IncomingTx) {
+	if utils.ColorEnabled() {
+		fmt.Print("\033[H\033[2J")
+	} else {
+		fmt.Println()
+	}
+	fmt.Printf("Monitoring %d addresses | %s | refreshing every %s, press Ctrl+C to stop\n\n",
+		len(statuses), time.Now().Format(time.DateTime), monitorInterval)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ADDRESS\tBALANCE (QUAI)\tNONCE\tPENDING\tINCOMING\tRECEIVED (QUAI)")
+	for _, s := range statuses {
+		if s.Err != nil {
+			fmt.Fprintf(tw, "%s\t⚠️ %v\t\t\t\t\n", s.Address.Hex(), s.Err)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%s\n", s.Address.Hex(), utils.ToQuai(s.Balance.String()),
+			s.Nonce, s.Pending, s.Incoming, utils.ToQuai(s.Received.String()))
+	}
+	balance, pending := monitorTotals(statuses)
+	fmt.Fprintf(tw, "TOTAL\t%s\t\t%d\t\t\n", utils.ToQuai(balance.String()), pending)
+	tw.Flush()
+
+	if len(recent) > 0 {
+		fmt.Println("\nLatest deposits:")
+		for i := len(recent) - 1; i >= 0; i-- {
+			tx := recent[i]
+			fmt.Printf("📥 Block: %d | To: %s | From: %s | Amount: %s Quai | Tx Hash: %s\n",
+				tx.BlockNumber, tx.To.Hex(), tx.From.Hex(), utils.ToQuai(tx.Value.String()), tx.Hash.Hex())
+		}
+	}
+}
+
+// monitorTotals sums the balances and pending transactions of the addresses that could be read
+func monitorTotals(statuses []wallet.AddressStatus) (*big.Int, uint64) {
+	balance := new(big.Int)
+	var pending uint64
+	for _, s := range statuses {
+		balance.Add(balance, s.Balance)
+		pending += s.Pending
+	}
+	return balance, pending
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+
+	"quai-transfer/config"
+	"quai-transfer/keystore"
+
+	"github.com/spf13/cobra"
+)
+
+var passwdAddress string
+
+var passwdCmd = &cobra.Command{
+	Use:     PasswdCmdName + " -a|--address <address>",
+	Short:   PasswdCmdShortDesc,
+	RunE:    runPasswd,
+	Version: Version,
+}
+
+func init() {
+	flags := passwdCmd.Flags()
+	flags.StringVarP(&passwdAddress, "address", "a", "", "Address of the key to change the password of (required)")
+	flags.SortFlags = false
+	passwdCmd.MarkFlagRequired("address")
+}
+
+func runPasswd(cmd *cobra.Command, args []string) error {
+	address, err := parseAddress(passwdAddress)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to initialize config: %w", err)
+	}
+	ks, err := newKeyManager(cfg)
+	if err != nil {
+		return err
+	}
+
+	oldPass, err := keystore.ReadPassword("Enter the current password: ")
+	if err != nil {
+		return err
+	}
+	newPass, err := keystore.PromptAndConfirmPassword("Enter the new password: ")
+	if err != nil {
+		return err
+	}
+
+	if err := ks.ChangePassword(address, oldPass, newPass); err != nil {
+		return fmt.Errorf("failed to change password of %s: %w", address.Hex(), err)
+	}
+	fmt.Printf("🔑 PASSWORD CHANGED | %s\n", address.Hex())
+	return nil
+}
@@ -0,0 +1,37 @@
+//go:build !windows
+
+package main
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"quai-transfer/wallet"
+)
+
+// watchPauseSignal toggles pause on each SIGUSR1 until the returned stop is called
+func watchPauseSignal(pause *wallet.Pause) (stop func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-signals:
+				if pause.Toggle() {
+					log.Printf("⏸️ BATCH PAUSED | SIGUSR1 received | No new transactions are broadcast, pending ones are still monitored | Send SIGUSR1 again to resume")
+				} else {
+					log.Printf("▶️ BATCH RESUMED | SIGUSR1 received | Broadcasting again")
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
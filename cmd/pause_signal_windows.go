@@ -0,0 +1,8 @@
+package main
+
+import "quai-transfer/wallet"
+
+// watchPauseSignal does nothing on Windows, which has no SIGUSR1
+func watchPauseSignal(pause *wallet.Pause) (stop func()) {
+	return func() {}
+}
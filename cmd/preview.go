@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"quai-transfer/config"
+	wtypes "quai-transfer/types"
+	"quai-transfer/utils"
+	"quai-transfer/wallet"
+
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	previewCSVFile   string
+	previewInputUnit string
+	previewKeyFile   string
+	previewEntryID   int32
+)
+
+var previewCmd = &cobra.Command{
+	Use:     PreviewCmdName + " [-f|--csv /path/to/csv_file] --id <entry_id> [-p|--pk_file /path/to/private_key.json]",
+	Short:   PreviewCmdShortDesc,
+	RunE:    runPreview,
+	Version: Version,
+}
+
+func init() {
+	flags := previewCmd.Flags()
+	flags.StringVarP(&previewCSVFile, "csv", "f", "", "CSV file containing transfer details")
+	flags.StringVar(&previewInputUnit, "input-unit", "", "Unit of the CSV values without a unit column: wei, gwei or quai (overrides input_unit)")
+	flags.Int32Var(&previewEntryID, "id", 0, "Entry ID to preview")
+	flags.StringVarP(&previewKeyFile, "pk_file", "p", "", "Private key file path (defaults to key_file)")
+	flags.SortFlags = false
+
+	_ = previewCmd.MarkFlagRequired("csv")
+	_ = previewCmd.MarkFlagRequired("id")
+}
+
+func runPreview(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to initialize config: %w", err)
+	}
+
+	if err := applyInputUnit(cfg, previewInputUnit); err != nil {
+		return err
+	}
+	entries, err := utils.ParseTransferCSV(previewCSVFile, utils.CSVOptions{Unit: cfg.InputUnit, Strict: cfg.StrictValidation, AddressBook: cfg.AddressBook})
+	if err != nil {
+		return fmt.Errorf("failed to parse CSV file: %w", err)
+	}
+	var entry *wtypes.TransferEntry
+	for _, e := range entries {
+		if e.ID == previewEntryID {
+			entry = e
+			break
+		}
+	}
+	if entry == nil {
+		return fmt.Errorf("entry ID %d not found in %s", previewEntryID, previewCSVFile)
+	}
+
+	w, err := loadWallet(cfg, previewKeyFile)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	tx, err := w.PreviewTransaction(context.Background(), entry)
+	if err != nil {
+		return err
+	}
+	return printSignedTx(w, tx)
+}
+
+// printSignedTx prints the JSON of a signed transaction, its decoded fields and its raw form
+func printSignedTx(w *wallet.Wallet, tx *types.Transaction) error {
+	txJSON, err := json.MarshalIndent(tx, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize transaction: %w", err)
+	}
+	raw, err := wallet.EncodeRawTransaction(tx)
+	if err != nil {
+		return fmt.Errorf("failed to encode transaction: %w", err)
+	}
+
+	fmt.Printf("Signed Transaction:\n%s\n\n", txJSON)
+	fmt.Printf("From: %s\nTo: %s\nValue: %s Quai\nNonce: %d\nGas Price: %s wei\nGas Limit: %d\nTx Hash: %s\n\n",
+		w.GetAddress().Hex(), tx.To().Hex(), utils.ToQuai(tx.Value().String()), tx.Nonce(), tx.GasPrice(), tx.Gas(), tx.Hash().Hex())
+	fmt.Printf("Raw Transaction: %s\n", raw)
+	fmt.Printf("Nothing was recorded or broadcast. Send it with: %s --raw <raw transaction>\n", BroadcastCmdName)
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"quai-transfer/config"
+	"quai-transfer/dal"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	pruneDays   int
+	pruneDryRun bool
+)
+
+var pruneCmd = &cobra.Command{
+	Use:     PruneCmdName + " [--days <days>] [--dry-run]",
+	Short:   PruneCmdShortDesc,
+	RunE:    runPrune,
+	Version: Version,
+}
+
+func init() {
+	flags := pruneCmd.Flags()
+	flags.IntVar(&pruneDays, "days", 0, "Archive records confirmed more than this many days ago (overrides tx_retention_days)")
+	flags.BoolVar(&pruneDryRun, "dry-run", false, "Only report how many records would be archived")
+	flags.SortFlags = false
+}
+
+func runPrune(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to initialize config: %w", err)
+	}
+	if pruneDays > 0 {
+		cfg.TxRetentionDays = pruneDays
+	}
+	if cfg.TxRetentionDays <= 0 {
+		return fmt.Errorf("no retention window, set tx_retention_days or --days")
+	}
+
+	dal.DBInit(cfg)
+	return applyRetention(context.Background(), dal.NewTransactionDAL(dal.InterDB), cfg.TxRetentionDays, pruneDryRun)
+}
+
+// applyRetention archives the records confirmed more than days ago. A dry run only reports
+// how many records would be archived.
+func applyRetention(ctx context.Context, txDAL *dal.TransactionDAL, days int, dryRun bool) error {
+	before := time.Now().AddDate(0, 0, -days)
+
+	if dryRun {
+		count, err := txDAL.CountConfirmedBefore(ctx, before)
+		if err != nil {
+			return err
+		}
+		log.Printf("🧹 RETENTION DRY RUN | Would archive %d records confirmed before %s", count, before.Format(time.DateOnly))
+		return nil
+	}
+
+	archived, err := txDAL.ArchiveConfirmedBefore(ctx, before)
+	if err != nil {
+		return err
+	}
+	log.Printf("🧹 RETENTION | Archived %d records confirmed before %s", archived, before.Format(time.DateOnly))
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"quai-transfer/config"
+	"quai-transfer/keystore"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	rekeyScrypt       string
+	rekeyPasswordFile string
+)
+
+var rekeyCmd = &cobra.Command{
+	Use:     RekeyCmdName + " [--scrypt standard|light] [--password-file /path/to/password]",
+	Short:   RekeyCmdShortDesc,
+	RunE:    runRekey,
+	Version: Version,
+}
+
+func init() {
+	flags := rekeyCmd.Flags()
+	flags.StringVar(&rekeyScrypt, "scrypt", keystore.ScryptStandard, "Scrypt parameters to re-encrypt with (standard/light)")
+	flags.StringVar(&rekeyPasswordFile, "password-file", "", "File holding the password shared by the keys, prompted for when omitted")
+	flags.SortFlags = false
+}
+
+func runRekey(cmd *cobra.Command, args []string) error {
+	scryptN, scryptP, err := keystore.ScryptParams(strings.ToLower(rekeyScrypt))
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to initialize config: %w", err)
+	}
+	ks, err := newKeyManager(cfg)
+	if err != nil {
+		return err
+	}
+
+	var password string
+	if rekeyPasswordFile != "" {
+		password, err = readPasswordFile(rekeyPasswordFile)
+		if err != nil {
+			return err
+		}
+	} else {
+		password, err = keystore.ReadPassword("Enter the password of the keys: ")
+		if err != nil {
+			return err
+		}
+	}
+
+	result, err := ks.Rekey(password, scryptN, scryptP)
+	if err != nil {
+		return err
+	}
+	for _, name := range result.Rekeyed {
+		fmt.Printf("🔑 REKEYED | %s\n", name)
+	}
+	for _, name := range result.Skipped {
+		fmt.Printf("⏭️ SKIPPED | %s | Already at %s parameters\n", name, rekeyScrypt)
+	}
+	for name, err := range result.Failed {
+		fmt.Printf("❌ FAILED | %s | %v\n", name, err)
+	}
+	fmt.Printf("Rekeyed: %d, Skipped: %d, Failed: %d\n", len(result.Rekeyed), len(result.Skipped), len(result.Failed))
+
+	if len(result.Failed) > 0 {
+		return fmt.Errorf("%d keys could not be re-encrypted", len(result.Failed))
+	}
+	return nil
+}
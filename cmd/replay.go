@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+
+	"quai-transfer/config"
+	"quai-transfer/eventlog"
+
+	"github.com/spf13/cobra"
+)
+
+var replayFile string
+
+var replayCmd = &cobra.Command{
+	Use:     ReplayCmdName + " [--file /path/to/events.jsonl]",
+	Short:   ReplayCmdShortDesc,
+	RunE:    runReplay,
+	Version: Version,
+}
+
+func init() {
+	flags := replayCmd.Flags()
+	flags.StringVar(&replayFile, "file", "", "Event log path (defaults to event_log from config)")
+	flags.SortFlags = false
+}
+
+func runReplay(cmd *cobra.Command, args []string) error {
+	if replayFile == "" {
+		cfg, err := config.LoadConfig(configFile)
+		if err != nil {
+			return fmt.Errorf("failed to initialize config: %w", err)
+		}
+		if cfg.EventLog == "" {
+			return fmt.Errorf("no event log configured, pass --file")
+		}
+		replayFile = cfg.EventLog
+	}
+
+	events, err := eventlog.Read(replayFile)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range events {
+		if e.Type == eventlog.RunStarted {
+			fmt.Println()
+		}
+		fmt.Println(eventlog.Format(e))
+	}
+	fmt.Printf("\n%d events replayed from %s\n", len(events), replayFile)
+	return nil
+}
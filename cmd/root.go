@@ -2,7 +2,16 @@ package main
 
 import (
 	"fmt"
+	"log"
+	"os"
+	"strings"
 
+	"quai-transfer/config"
+	"quai-transfer/keystore"
+	wtypes "quai-transfer/types"
+	"quai-transfer/wallet"
+
+	"github.com/dominant-strategies/go-quai/common"
 	"github.com/spf13/cobra"
 )
 
@@ -10,3 +19,106 @@ func runRootCmd(cmd *cobra.Command, args []string) error {
 	// Since we want to require subcommands, this function shouldn't be called directly
 	return fmt.Errorf("a subcommand is required")
 }
+
+// PrivateKeyEnv holds a raw private key for ephemeral runs; when set it replaces the keystore
+const PrivateKeyEnv = "QUAI_PRIVATE_KEY"
+
+// loadWallet opens the wallet of a key file. Without one it uses the key in PrivateKeyEnv
+// if set, then the configured key_file.
+func loadWallet(cfg *config.Config, keyFile string) (*wallet.Wallet, error) {
+	applyChainIDOverride(cfg)
+	if keyFile == "" && os.Getenv(PrivateKeyEnv) != "" {
+		return walletFromEnv(cfg)
+	}
+
+	ks, err := newKeyManager(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if keyFile == "" {
+		keyFile = cfg.KeyFile
+	}
+	fmt.Printf("Loading key from file: %s\n", keyFile)
+	key, err := ks.LoadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load key from %s: %w", keyFile, err)
+	}
+
+	w, err := wallet.NewWalletFromKey(key, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create wallet: %w", err)
+	}
+	return w, nil
+}
+
+// applyChainIDOverride applies --trust-node-chain-id before a wallet connects
+func applyChainIDOverride(cfg *config.Config) {
+	if trustNodeChainID {
+		cfg.TrustNodeChainID = true
+	}
+}
+
+// applyInputUnit applies an --input-unit flag, when set, over input_unit
+func applyInputUnit(cfg *config.Config, unit string) error {
+	if unit == "" {
+		return nil
+	}
+	unit = strings.ToLower(unit)
+	if _, ok := wtypes.UnitExponent(unit); !ok {
+		return fmt.Errorf("invalid --input-unit %q, must be %s, %s or %s", unit, wtypes.UnitWei, wtypes.UnitGwei, wtypes.UnitQuai)
+	}
+	cfg.InputUnit = unit
+	return nil
+}
+
+// newKeyManager opens the keystore backend selected by keystore_backend
+func newKeyManager(cfg *config.Config) (*keystore.KeyManager, error) {
+	var ks *keystore.KeyManager
+	switch cfg.KeystoreBackend {
+	case config.KeystoreBackendSecretDir:
+		store, err := keystore.NewDirSecretStore(cfg.KeystoreSecretDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize keystore: %w", err)
+		}
+		ks = keystore.NewSecretKeyManager(store)
+	default:
+		var err error
+		ks, err = keystore.NewKeyManager(keyDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize keystore: %w", err)
+		}
+	}
+	ks.SetKeyGeneration(cfg.KeyMaxAttempts, cfg.Debug)
+	return ks, nil
+}
+
+// walletFromEnv creates a wallet from the private key in PrivateKeyEnv, skipping the keystore.
+// The key is never logged; the wallet must belong to the configured protocol's ledger.
+func walletFromEnv(cfg *config.Config) (*wallet.Wallet, error) {
+	log.Printf("⚠️ USING %s | The private key is unencrypted in the process environment, where other processes, "+
+		"crash dumps and orchestration tools may read it. Only use it for short-lived jobs.", PrivateKeyEnv)
+
+	w, err := wallet.NewWalletFromPrivateKeyString(os.Getenv(PrivateKeyEnv), cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create wallet from %s: %w", PrivateKeyEnv, err)
+	}
+
+	address := w.GetAddress().Hex()
+	if ledger := wallet.LedgerOf(address); ledger != cfg.Protocol {
+		w.Close()
+		return nil, fmt.Errorf("%w: key in %s belongs to the %s ledger, config protocol is %s",
+			wtypes.ErrLedgerMismatch, PrivateKeyEnv, ledger, cfg.Protocol)
+	}
+	loc := w.GetLocation()
+	fmt.Printf("Loaded key from %s with address: %s (location %d-%d)\n", PrivateKeyEnv, address, loc.Region(), loc.Zone())
+	return w, nil
+}
+
+// parseAddress parses a hex address given on the command line, in the location of its prefix
+func parseAddress(s string) (common.Address, error) {
+	if !common.IsHexAddress(s) {
+		return common.Address{}, fmt.Errorf("invalid address %s", s)
+	}
+	b := common.FromHex(s)
+	return common.BytesToAddress(b, common.LocationFromAddressBytes(b)), nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"quai-transfer/config"
+	"quai-transfer/utils"
+	"quai-transfer/wallet"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	shardStatsCSVFile   string
+	shardStatsInputUnit string
+	shardStatsJSON      bool
+)
+
+var shardStatsCmd = &cobra.Command{
+	Use:     ShardStatsCmdName + " -f|--csv /path/to/csv_file [--json]",
+	Short:   ShardStatsCmdShortDesc,
+	RunE:    runShardStats,
+	Version: Version,
+}
+
+func init() {
+	flags := shardStatsCmd.Flags()
+	flags.StringVarP(&shardStatsCSVFile, "csv", "f", "", "CSV file containing transfer details")
+	flags.StringVar(&shardStatsInputUnit, "input-unit", "", "Unit of the CSV values without a unit column: wei, gwei or quai (overrides input_unit)")
+	flags.BoolVar(&shardStatsJSON, "json", false, "Print the shards as a JSON array instead of a table")
+	flags.SortFlags = false
+
+	_ = shardStatsCmd.MarkFlagRequired("csv")
+}
+
+func runShardStats(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to initialize config: %w", err)
+	}
+
+	if err := applyInputUnit(cfg, shardStatsInputUnit); err != nil {
+		return err
+	}
+	entries, err := utils.ParseTransferCSV(shardStatsCSVFile, utils.CSVOptions{Unit: cfg.InputUnit, Strict: cfg.StrictValidation, AddressBook: cfg.AddressBook})
+	if err != nil {
+		return fmt.Errorf("failed to parse CSV file: %w", err)
+	}
+	stats := wallet.ShardStats(cfg, entries)
+
+	if shardStatsJSON {
+		out, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to serialize shard stats: %w", err)
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SHARD\tENTRIES\tTOTAL (QUAI)\tRPC")
+	for _, stat := range stats {
+		rpc := "yes"
+		if !stat.HasRPC {
+			rpc = "no"
+		}
+		if stat.Location == wallet.InvalidShard {
+			rpc = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", stat.Location, stat.Count, utils.ToQuai(stat.Value.BigInt()), rpc)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	shards := len(stats)
+	for _, stat := range stats {
+		if stat.Location == wallet.InvalidShard {
+			shards--
+			fmt.Printf("Invalid entry IDs: %v\n", stat.InvalidIDs)
+		}
+	}
+	fmt.Printf("%d entries across %d shard(s) on %s\n", len(entries), shards, cfg.Network)
+	return nil
+}
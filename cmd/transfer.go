@@ -2,23 +2,48 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"time"
 
 	"quai-transfer/config"
-	"quai-transfer/keystore"
+	"quai-transfer/dal"
+	"quai-transfer/dal/models"
+	"quai-transfer/eventlog"
+	"quai-transfer/metrics"
+	"quai-transfer/notify"
+	"quai-transfer/report"
+	wtypes "quai-transfer/types"
 	"quai-transfer/utils"
 	"quai-transfer/wallet"
 
+	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 )
 
 var (
-	csvFile string
-	pkFile  string
+	csvFile            string
+	pkFiles            []string
+	strictValidation   bool
+	failFast           bool
+	eventLogFile       string
+	maxRetries         int
+	maxBatchSize       int
+	resultsCSV         string
+	resultsJSONL       string
+	logResults         bool
+	streamCSV          bool
+	priority           string
+	resume             bool
+	dryRun             bool
+	inputUnit          string
+	reportFile         string
+	verifyConservation bool
 )
 
 var transferCmd = &cobra.Command{
-	Use:     TransferCmdName + " [-f|--csv /path/to/csv_file] [-p|--pk_file /path/to/private_key.json]",
+	Use:     TransferCmdName + " [-f|--csv /path/to/csv_file] [-p|--pk_file /path/to/private_key.json]...",
 	Short:   TransferCmdShortDesc,
 	RunE:    runTransfer,
 	Version: Version,
@@ -27,7 +52,22 @@ var transferCmd = &cobra.Command{
 func init() {
 	flags := transferCmd.Flags()
 	flags.StringVarP(&csvFile, "csv", "f", "", "CSV file containing transfer details")
-	flags.StringVarP(&pkFile, "pk_file", "p", "", "Private key file path")
+	flags.StringSliceVarP(&pkFiles, "pk_file", "p", nil, "Private key file path, repeat with keys of other locations to pay out across shards")
+	flags.StringVar(&eventLogFile, "event-log", "", "Append-only event log path (overrides event_log)")
+	flags.BoolVar(&streamCSV, "stream", false, "Read the CSV row by row instead of loading it, for very large files (single key only)")
+	flags.StringVar(&resultsCSV, "results-csv", "", "Append a row to this CSV as each entry confirms or fails")
+	flags.StringVar(&resultsJSONL, "results-jsonl", "", "Append a JSON line to this file as each entry confirms or fails")
+	flags.BoolVar(&logResults, "log-results", false, "Log a line as each entry confirms or fails")
+	flags.StringVar(&inputUnit, "input-unit", "", "Unit of the CSV values without a unit column: wei, gwei or quai (overrides input_unit)")
+	flags.BoolVar(&strictValidation, "strict", false, "Abort the whole batch if any entry is invalid (overrides strict_validation)")
+	flags.BoolVar(&failFast, "fail-fast", false, "Stop broadcasting at the first failed entry (overrides fail_fast)")
+	flags.IntVar(&maxBatchSize, "max-batch-size", -1, "Split the CSV into chunks of this many entries, each confirmed before the next (overrides max_batch_size)")
+	flags.IntVar(&maxRetries, "max-retries", -1, "Broadcast retries per entry before it is dead-lettered (overrides max_retries)")
+	flags.StringVar(&priority, "priority", "", "Process entries by value-desc, value-asc or id, leaving what the balance can't cover for a later run (overrides priority)")
+	flags.StringVar(&reportFile, "report", "", "Write the records of every transaction of the run to this CSV once it finishes")
+	flags.BoolVar(&dryRun, "dry-run", false, "Validate, check the balance, sign and record every transaction without broadcasting it (overrides dry_run)")
+	flags.BoolVar(&verifyConservation, "verify-conservation", false, "Check the balances the run ends with against what it sent, spent in fees and received, warning of any unexplained delta")
+	flags.BoolVar(&resume, "resume", false, "Rebroadcast and monitor the transactions a previous run left pending before processing the CSV")
 
 	flags.SortFlags = false
 
@@ -35,63 +75,374 @@ func init() {
 }
 
 func runTransfer(cmd *cobra.Command, args []string) error {
-	var (
-		err error
-		key *keystore.Key
-	)
-
 	cfg, err := config.LoadConfig(configFile)
 	if err != nil {
 		return fmt.Errorf("failed to initialize config: %w", err)
 	}
+	if strictValidation {
+		cfg.StrictValidation = true
+	}
+	if failFast {
+		cfg.FailFast = true
+	}
+	if dryRun {
+		cfg.DryRun = true
+	}
+	if err := applyInputUnit(cfg, inputUnit); err != nil {
+		return err
+	}
+	if eventLogFile != "" {
+		cfg.EventLog = eventLogFile
+	}
+	if maxBatchSize >= 0 {
+		cfg.MaxBatchSize = maxBatchSize
+	}
+	if maxRetries >= 0 {
+		cfg.MaxRetries = maxRetries
+	}
+	if priority != "" {
+		cfg.Priority = priority
+	}
+	if err := wallet.ValidatePriority(cfg.Priority); err != nil {
+		return err
+	}
 	utils.Json(cfg)
 
-	// Initialize keystore
-	ks, err := keystore.NewKeyManager(keyDir)
+	var events *eventlog.Log
+	if cfg.EventLog != "" {
+		events, err = eventlog.Open(cfg.EventLog)
+		if err != nil {
+			return err
+		}
+		defer events.Close()
+	}
+
+	if streamCSV && len(pkFiles) > 1 {
+		return fmt.Errorf("--stream supports a single key, split the CSV per location instead")
+	}
+	if streamCSV && cfg.Priority != "" {
+		return fmt.Errorf("--stream can't reorder entries it never holds, drop --priority")
+	}
+	if streamCSV && reportFile != "" {
+		return fmt.Errorf("--stream doesn't keep the entries it has sent, use --results-csv instead of --report")
+	}
+	protocol, err := utils.ValidateProtocol(cfg.Protocol)
 	if err != nil {
-		return fmt.Errorf("failed to initialize keystore: %w", err)
+		return err
+	}
+	// Qi payments spend outpoints one after the other, so they're paid in order by a single wallet
+	qiBatch := protocol == "qi"
+	if qiBatch && (len(pkFiles) > 1 || streamCSV || cfg.Priority != "" || verifyConservation) {
+		return fmt.Errorf("qi batches take a single key and don't support --stream, --priority or --verify-conservation")
 	}
 
-	if pkFile != "" {
-		fmt.Printf("Loading key from private key file: %s\n", pkFile)
-		key, err = ks.LoadFile(pkFile)
-		if err != nil {
-			return fmt.Errorf("failed to load key from private key file: %w", err)
+	// Closed last, once the wallets have stopped writing
+	defer dal.DBClose()
+
+	results, err := openResultSinks(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := results.Close(); err != nil {
+			log.Printf("failed to close result outputs: %v", err)
+		}
+	}()
+
+	batchMetrics, err := metrics.Open(cfg)
+	if err != nil {
+		return err
+	}
+	defer batchMetrics.Close()
+
+	// Identifies the progress records of this run in batch_runs
+	runID := uuid.NewString()
+	log.Printf("🆔 RUN | ID: %s", runID)
+	events.Append(eventlog.Event{Type: eventlog.RunStarted, Data: map[string]any{"config": cfg.Redacted(), "csv": csvFile, "run_id": runID}})
+
+	if cfg.TxRetentionDays > 0 {
+		dal.DBInit(cfg)
+		if err := applyRetention(context.Background(), dal.NewTransactionDAL(dal.InterDB), cfg.TxRetentionDays, false); err != nil {
+			return err
 		}
-	} else {
-		fmt.Printf("Loading key from config file: %s\n", cfg.KeyFile)
-		key, err = ks.LoadFile(cfg.KeyFile)
+	}
+
+	// Without --pk_file, loadWallet falls back to QUAI_PRIVATE_KEY, then key_file
+	keyFiles := pkFiles
+	if len(keyFiles) == 0 {
+		keyFiles = []string{""}
+	}
+
+	// One wallet per key; each keeps its own client and nonce state
+	ctx, stopInterrupt := interruptContext(context.Background())
+	defer stopInterrupt()
+	pause := wallet.NewPause()
+	defer watchPauseSignal(pause)()
+	wallets := make([]*wallet.Wallet, 0, len(keyFiles))
+	for _, keyFile := range keyFiles {
+		w, err := loadWallet(cfg, keyFile)
 		if err != nil {
-			return fmt.Errorf("failed to load key from config file: %w", err)
+			return err
 		}
+		defer w.Close()
+		fmt.Printf("Loaded wallet with address: %s\n", w.GetAddress().Hex())
+		w.SetEventLog(events)
+		w.SetResults(results)
+		w.SetMetrics(batchMetrics)
+		w.SetRunID(runID)
+		w.SetPause(pause)
+
+		// Only a warning, a skewed clock doesn't make the transfers themselves unsafe
+		if _, _, err := w.CheckClockSkew(ctx); err != nil {
+			log.Printf("failed to check clock skew: %v", err)
+		}
+		if _, err := w.CheckNodeSync(ctx); err != nil {
+			return fmt.Errorf("refusing to send from %s: %w", w.GetAddress().Hex(), err)
+		}
+
+		if qiBatch {
+			balance, err := w.QiBalance(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get wallet qi balance: %v", err)
+			}
+			fmt.Printf("Wallet balance: %s qits unlocked\n", balance)
+		} else {
+			balance, err := w.GetBalance(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get wallet balance: %v", err)
+			}
+			fmt.Printf("Wallet balance: %s Quai\n", utils.ToQuai(balance.String()))
+		}
+		wallets = append(wallets, w)
 	}
-	fmt.Printf("Loaded key with address: %s\n", key.Address.Hex())
 
-	// Create wallet instance
-	w, err := wallet.NewWalletFromKey(key, cfg)
-	if err != nil {
-		return fmt.Errorf("failed to create wallet: %w", err)
+	if (resume || cfg.ResumeOnStart) && qiBatch {
+		// Resuming is for Quai nonces; a Qi batch rebroadcasts the payments it left pending as it reaches their entries
+		log.Printf("⏭️ RESUME SKIPPED | Qi batch, pending payments are rebroadcast with their entries")
+	} else if (resume || cfg.ResumeOnStart) && cfg.DryRun {
+		// Resuming rebroadcasts, which a dry run must never do
+		log.Printf("⏭️ RESUME SKIPPED | Dry run, pending transactions are left alone")
+	} else if resume || cfg.ResumeOnStart {
+		for _, w := range wallets {
+			if err := resumeWallet(ctx, w); err != nil {
+				return err
+			}
+		}
 	}
-	defer w.Close()
 
-	ctx := context.Background()
-	balance, err := w.GetBalance(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to get wallet balance: %v", err)
+	// Taken once resumed transactions are settled, so only this run's transactions explain the end balances
+	var startBalances []*wallet.BalanceSnapshot
+	if verifyConservation {
+		if startBalances, err = wallet.SnapshotBalances(ctx, wallets); err != nil {
+			return fmt.Errorf("--verify-conservation needs the starting balances: %w", err)
+		}
+	}
+	conserve := func(result *wallet.BatchResult, err error) (*wallet.BatchResult, error) {
+		if startBalances != nil {
+			checkConservation(ctx, wallets, startBalances, result)
+		}
+		return result, err
+	}
+
+	csvOptions := utils.CSVOptions{Unit: cfg.InputUnit, Strict: cfg.StrictValidation, AddressBook: cfg.AddressBook}
+	if streamCSV && cfg.MaxBatchSize > 0 {
+		// Only a chunk is ever in memory, so the balance is checked chunk by chunk
+		w := wallets[0]
+		return batchOutcome(conserve(wallet.StreamInChunks(ctx, csvFile, csvOptions, cfg.MaxBatchSize, cfg.DryRun,
+			func(ctx context.Context, chunk []*wtypes.TransferEntry) (*wallet.BatchResult, error) {
+				if err := wallet.CheckBalance(ctx, w, chunk); err != nil {
+					return nil, err
+				}
+				return w.ProcessBatchEntry(ctx, chunk)
+			})))
+	}
+	if streamCSV {
+		// The entries are never all in memory, so the entry count and estimate are skipped
+		return batchOutcome(conserve(wallets[0].ProcessBatchStream(ctx, csvFile)))
 	}
-	fmt.Printf("Wallet balance: %s Quai\n", utils.ToQuai(balance.String()))
 
-	transferEntries, err := utils.ParseTransferCSV(csvFile)
+	transferEntries, err := utils.ParseTransferCSV(csvFile, csvOptions)
 	if err != nil {
 		return fmt.Errorf("failed to parse CSV file: %w", err)
 	}
+	events.Append(eventlog.Event{Type: eventlog.EntriesLoaded, Data: map[string]any{"count": len(transferEntries)}})
+
+	// Duplicate IDs and zero values would be paid wrongly, so they always abort. Invalid
+	// destinations only abort under strict validation, otherwise their entries are skipped.
+	var destinations utils.DestinationValidator = wallets[0]
+	if len(wallets) > 1 {
+		destinations = wallet.Destinations(wallets)
+	}
+	if err := utils.ValidateTransfers(transferEntries, destinations); err != nil {
+		if cfg.StrictValidation || errors.Is(err, wtypes.ErrDuplicateID) || errors.Is(err, wtypes.ErrInvalidValue) {
+			return withExitCode(ExitInvalid, err)
+		}
+		log.Printf("⚠️ INVALID ENTRIES | They will be skipped | %v", err)
+	}
 
-	// Check if address have enough balance for all entries
-	if err := wallet.CheckBalance(ctx, w, transferEntries); err != nil {
-		return fmt.Errorf("insufficient balance: %w", err)
+	if qiBatch {
+		w := wallets[0]
+		if err := wallet.CheckQiBalance(ctx, w, transferEntries); err != nil {
+			return withExitCode(ExitInsufficientBalance, err)
+		}
+		result, err := wallet.ProcessInChunks(ctx, transferEntries, cfg.MaxBatchSize, "", cfg.DryRun, w.ProcessQiBatch)
+		return finishTransfer(ctx, transferEntries, result, err)
+	}
+
+	printEstimate(wallets, transferEntries, cfg)
+
+	if len(wallets) > 1 {
+		result, err := wallet.ProcessInChunks(ctx, transferEntries, cfg.MaxBatchSize, cfg.Priority, cfg.DryRun,
+			func(ctx context.Context, chunk []*wtypes.TransferEntry) (*wallet.BatchResult, error) {
+				return wallet.ProcessMultiLocationBatch(ctx, wallets, chunk)
+			})
+		result, err = conserve(result, err)
+		return finishTransfer(ctx, transferEntries, result, err)
+	}
+	w := wallets[0]
+
+	// Check if address have enough balance for all entries, a prioritized batch cuts off the rest instead
+	if cfg.Priority == "" {
+		if err := wallet.CheckBalance(ctx, w, transferEntries); err != nil {
+			return withExitCode(ExitInsufficientBalance, err)
+		}
+	}
+
+	result, err := conserve(wallet.ProcessInChunks(ctx, transferEntries, cfg.MaxBatchSize, cfg.Priority, cfg.DryRun, w.ProcessBatchEntry))
+	return finishTransfer(ctx, transferEntries, result, err)
+}
+
+// checkConservation runs the --verify-conservation check once a run is done. An unexplained
+// delta or a check that can't be done is only a warning, the outcome of the batch stands.
+func checkConservation(ctx context.Context, wallets []*wallet.Wallet, start []*wallet.BalanceSnapshot, result *wallet.BatchResult) {
+	// Still checked after an interrupt, whatever was mined counts
+	ctx = context.WithoutCancel(ctx)
+	if _, err := wallet.VerifyConservation(ctx, wallets, start, result); err != nil {
+		log.Printf("⚠️ CONSERVATION UNVERIFIED | %v", err)
+	}
+}
+
+// openResultSinks opens the outputs receiving the outcome of each entry: --results-csv,
+// --results-jsonl, --log-results and webhook_url. It returns nil when there are none.
+func openResultSinks(cfg *config.Config) (*report.Mux, error) {
+	var sinks []report.ResultSink
+	closeAll := func() {
+		for _, sink := range sinks {
+			sink.Close()
+		}
+	}
+	if resultsCSV != "" {
+		csvWriter, err := report.OpenWriter(resultsCSV)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, csvWriter)
+	}
+	if resultsJSONL != "" {
+		jsonl, err := report.OpenJSONLWriter(resultsJSONL)
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		sinks = append(sinks, jsonl)
+	}
+	if logResults {
+		sinks = append(sinks, report.LogSink{})
+	}
+	if cfg.WebhookURL != "" {
+		webhook, err := notify.NewWebhook(cfg.WebhookURL, cfg.WebhookTemplate)
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		sinks = append(sinks, webhook)
 	}
+	if len(cfg.ExecHook) > 0 {
+		hook, err := notify.NewExec(cfg.ExecHook, cfg.ExecHookTimeout)
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		sinks = append(sinks, hook)
+	}
+	return report.NewMux(sinks...), nil
+}
 
-	// todo: 需要处理多个类型的情况（统一用transfer来做，根据Protocol来决定 Switch case）
-	w.ProcessBatchEntry(ctx, transferEntries)
+// finishTransfer writes the --report CSV of the run and returns the batch outcome. A report
+// that can't be written only fails a run that otherwise succeeded.
+func finishTransfer(ctx context.Context, entries []*wtypes.TransferEntry, result *wallet.BatchResult, err error) error {
+	outcome := batchOutcome(result, err)
+	if reportFile == "" {
+		return outcome
+	}
+	// The report is still written after an interrupt
+	ctx = context.WithoutCancel(ctx)
+
+	ids := make([]int32, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ID
+	}
+	txs, reportErr := dal.NewTransactionDAL(dal.InterDB).ListByIDs(ctx, ids)
+	if reportErr == nil {
+		reportErr = utils.WriteTransferReportCSV(reportFile, txs)
+	}
+	if reportErr != nil {
+		if outcome == nil {
+			return fmt.Errorf("failed to write report: %w", reportErr)
+		}
+		log.Printf("⚠️ REPORT FAILED | %s | %v", reportFile, reportErr)
+		return outcome
+	}
+	log.Printf("📄 REPORT | %d transactions written to %s", len(txs), reportFile)
+	return outcome
+}
+
+// printEstimate prints how long the run is expected to take before anything is sent, so a
+// misconfiguration shows up before the operator commits to it
+func printEstimate(wallets []*wallet.Wallet, entries []*wtypes.TransferEntry, cfg *config.Config) {
+	routes, _ := wallet.RouteEntries(wallets, entries)
+	sizes := make([]int, 0, len(routes))
+	for _, batch := range routes {
+		sizes = append(sizes, len(batch))
+	}
+	if len(wallets) == 1 {
+		sizes = []int{len(entries)}
+	}
+
+	nonceWait := cfg.NonceWait
+	if cfg.Concurrency > 1 {
+		nonceWait = 0
+	}
+	estimate := wallet.EstimateBatchDuration(nonceWait, sizes...)
+	fmt.Printf("~%d entries, estimated at most %s based on current settings (up to %s nonce wait per entry, %d sent at once, receipts polled every %s, %d location(s) in parallel)\n",
+		len(entries), estimate.Round(time.Second), nonceWait, cfg.Concurrency, wallet.ReceiptWaitTime, len(sizes))
+	if estimate > time.Hour {
+		fmt.Printf("⚠️ This run is expected to take over an hour, consider splitting the CSV or checking the settings\n")
+	}
+}
+
+// resumeWallet resumes the pending transactions of a wallet under an advisory lock, so two
+// instances sharing the database never resume the same transactions. A wallet another
+// instance is resuming is skipped.
+func resumeWallet(ctx context.Context, w *wallet.Wallet) error {
+	address := w.GetAddress().Hex()
+	lock, err := dal.TryAdvisoryLock(ctx, dal.InterDB, "resume "+(&models.Transaction{}).TableName()+" "+address)
+	if err != nil {
+		return fmt.Errorf("failed to lock %s for resume: %w", address, err)
+	}
+	if lock == nil {
+		log.Printf("⏭️ RESUME SKIPPED | Wallet: %s | Another instance is resuming its pending transactions", address)
+		return nil
+	}
+	defer func() {
+		if err := lock.Release(); err != nil {
+			log.Printf("failed to release resume lock of %s: %v", address, err)
+		}
+	}()
+
+	log.Printf("♻️ RESUMING | Wallet: %s | Rebroadcasting and monitoring the transactions a previous run left pending", address)
+	if err := w.ResumePending(ctx); err != nil {
+		return fmt.Errorf("failed to resume pending transactions of %s: %w", address, err)
+	}
 	return nil
 }
@@ -13,6 +13,12 @@ var (
 	// Configuration file path
 	configFile string
 
+	// Sign with the chain ID the node reports, even if it differs from the configured one
+	trustNodeChainID bool
+
+	// Print no ANSI colors or escape codes, as when NO_COLOR is set or stdout isn't a terminal
+	noColor bool
+
 	// Version information (set via ldflags)
 	Version string
 
@@ -83,4 +89,84 @@ const (
 	// ImportCmdName Import command constants
 	ImportCmdName      = "import"
 	ImportCmdShortDesc = "Import a private key into the keystore"
+
+	// GetRawCmdName Get raw transaction command constants
+	GetRawCmdName      = "get-raw"
+	GetRawCmdShortDesc = "Print the signed raw transaction recorded for an entry"
+
+	// ReplayCmdName Replay command constants
+	ReplayCmdName      = "replay"
+	ReplayCmdShortDesc = "Print the timeline of a batch run from its event log"
+
+	// DeadLetterCmdName Dead-letter command constants
+	DeadLetterCmdName      = "dead-letter"
+	DeadLetterCmdShortDesc = "List dead-lettered transfers and requeue them"
+
+	// WatchCmdName Watch command constants
+	WatchCmdName      = "watch"
+	WatchCmdShortDesc = "Print incoming transactions to a wallet as they arrive"
+
+	// PruneCmdName Prune command constants
+	PruneCmdName      = "prune"
+	PruneCmdShortDesc = "Archive confirmed transaction records older than the retention window"
+
+	// PreviewCmdName Preview command constants
+	PreviewCmdName      = "preview"
+	PreviewCmdShortDesc = "Sign the transaction of a single entry and print it without broadcasting"
+
+	// BroadcastCmdName Broadcast command constants
+	BroadcastCmdName      = "broadcast"
+	BroadcastCmdShortDesc = "Broadcast a signed raw transaction"
+
+	// DoctorCmdName Doctor command constants
+	DoctorCmdName      = "doctor"
+	DoctorCmdShortDesc = "Run read-only diagnostics against the configured node"
+
+	// WhoamiCmdName Whoami command constants
+	WhoamiCmdName      = "whoami"
+	WhoamiCmdShortDesc = "Show the Quai and Qi addresses of a key and the ledger it is scoped to"
+
+	// RekeyCmdName Rekey command constants
+	RekeyCmdName      = "rekey"
+	RekeyCmdShortDesc = "Re-encrypt all keystore files with new scrypt parameters"
+
+	// ListCmdName List command constants
+	ListCmdName      = "list"
+	ListCmdShortDesc = "List the keys in the keystore with their address and location"
+
+	// PasswdCmdName Passwd command constants
+	PasswdCmdName      = "passwd"
+	PasswdCmdShortDesc = "Change the password of a key in the keystore"
+
+	// DeleteCmdName Delete command constants
+	DeleteCmdName      = "delete"
+	DeleteCmdShortDesc = "Delete a key from the keystore after checking its password"
+
+	// CheckPasswordCmdName Check password command constants
+	CheckPasswordCmdName      = "check-password"
+	CheckPasswordCmdShortDesc = "Check the password of a key without loading it into a wallet"
+
+	// ShardStatsCmdName Shard stats command constants
+	ShardStatsCmdName      = "shard-stats"
+	ShardStatsCmdShortDesc = "Print how the entries of a payout file spread across shards"
+
+	// BalanceCmdName Balance command constants
+	BalanceCmdName      = "balance"
+	BalanceCmdShortDesc = "Print the balance of a wallet or any address"
+
+	// MonitorAddressesCmdName Monitor addresses command constants
+	MonitorAddressesCmdName      = "monitor-addresses"
+	MonitorAddressesCmdShortDesc = "Monitor balances and activity of a list of watch-only addresses"
+
+	// ExportQRCmdName Export QR command constants
+	ExportQRCmdName      = "export-qr"
+	ExportQRCmdShortDesc = "Export an encrypted key as a QR code for cold storage"
+
+	// ImportQRCmdName Import QR command constants
+	ImportQRCmdName      = "import-qr"
+	ImportQRCmdShortDesc = "Import an encrypted key from the scanned text of an export-qr QR code"
+
+	// ImportTxCmdName Import transaction command constants
+	ImportTxCmdName      = "import-tx"
+	ImportTxCmdShortDesc = "Record a transaction sent outside this tool as the payment of an entry"
 )
@@ -2,21 +2,27 @@ package main
 
 import (
 	"fmt"
+	"strings"
 
 	"quai-transfer/config"
 	"quai-transfer/keystore"
 	"quai-transfer/utils"
+	"quai-transfer/wallet"
 
+	"github.com/dominant-strategies/go-quai/common"
 	"github.com/spf13/cobra"
 )
 
 var (
-	protocol string
-	location string
+	protocol    string
+	location    string
+	useMnemonic bool
+	hdPath      string
+	deriveCount int
 )
 
 var createWalletCmd = &cobra.Command{
-	Use:     WalletCmdName + " [-p|--protocol quai|qi] [-l|--location zone-region]",
+	Use:     WalletCmdName + " [-p|--protocol quai|qi] [-l|--location zone-region] [--count n] [--mnemonic [--hd-path path]]",
 	Short:   WalletCmdShortDesc,
 	RunE:    runCreateWallet,
 	Version: Version,
@@ -26,13 +32,21 @@ func init() {
 	flags := createWalletCmd.Flags()
 	flags.StringVarP(&protocol, "protocol", "p", "quai", "Protocol type (quai/qi)")
 	flags.StringVarP(&location, "location", "l", "0-0", "Location in format zone-region")
+	flags.BoolVar(&useMnemonic, "mnemonic", false, "Derive the key from a BIP-39 seed phrase read from the terminal, a new one if left empty (quai only)")
+	flags.StringVar(&hdPath, "hd-path", keystore.DefaultHDPath, "BIP-44 path of the parent of the derived addresses, with --mnemonic")
+	flags.IntVar(&deriveCount, "count", 1, "Number of keys to create under one password; with --mnemonic, addresses derived along the default path")
 	flags.SortFlags = false
 }
 
 func runCreateWallet(cmd *cobra.Command, args []string) error {
-	ks, err := keystore.NewKeyManager(keyDir)
+	cfg, err := config.LoadConfig(configFile)
 	if err != nil {
-		return fmt.Errorf("failed to initialize keystore: %w", err)
+		return fmt.Errorf("failed to initialize config: %w", err)
+	}
+
+	ks, err := newKeyManager(cfg)
+	if err != nil {
+		return err
 	}
 
 	normalizedProtocol, err := utils.ValidateProtocol(protocol)
@@ -45,12 +59,80 @@ func runCreateWallet(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("invalid location format: %w", err)
 	}
 
-	address, err := ks.CreateNewKey(loc, normalizedProtocol)
-	if err != nil {
-		return fmt.Errorf("failed to create new key: %w", err)
+	var address common.Address
+	if useMnemonic {
+		if normalizedProtocol != "quai" {
+			return fmt.Errorf("keys derived from a mnemonic are quai only")
+		}
+		if deriveCount > 1 && hdPath != keystore.DefaultHDPath {
+			return fmt.Errorf("--count derives along the default path %s, it can't be combined with --hd-path", keystore.DefaultHDPath)
+		}
+		mnemonic, err := readMnemonic()
+		if err != nil {
+			return err
+		}
+		if deriveCount > 1 {
+			accounts, err := ks.DeriveAccounts(mnemonic, loc, deriveCount)
+			if err != nil {
+				return fmt.Errorf("failed to derive accounts from mnemonic: %w", err)
+			}
+			for _, account := range accounts {
+				fmt.Printf("Wallet address: %s\n", account.Address.Hex())
+			}
+			return nil
+		}
+		address, err = ks.CreateFromMnemonic(mnemonic, hdPath, loc)
+		if err != nil {
+			return fmt.Errorf("failed to create key from mnemonic: %w", err)
+		}
+	} else if deriveCount > 1 {
+		return createKeyBatch(ks, loc, normalizedProtocol)
+	} else {
+		address, err = ks.CreateNewKey(loc, normalizedProtocol)
+		if err != nil {
+			return fmt.Errorf("failed to create new key: %w", err)
+		}
 	}
 
 	fmt.Printf("Creating new wallet with address: %s\n", address.Hex())
+	fmt.Printf("Ledger: %s\n", ledgerHint(wallet.LedgerOf(address.Hex())))
+
+	return nil
+}
+
+// readMnemonic reads a seed phrase from the terminal. When none is entered a new one is
+// generated and printed once, to be written down before the key is used.
+func readMnemonic() (string, error) {
+	mnemonic, err := keystore.ReadPassword("Enter mnemonic (leave empty to generate one): ")
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(mnemonic) != "" {
+		return mnemonic, nil
+	}
+
+	mnemonic, err = keystore.GenerateMnemonic()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate mnemonic: %w", err)
+	}
+	fmt.Printf("\nNew mnemonic, write it down and keep it offline, it is shown only once:\n\n%s\n\n", mnemonic)
+	return mnemonic, nil
+}
 
+// createKeyBatch creates --count keys under one password, reporting the ones that failed
+// without dropping those stored
+func createKeyBatch(ks *keystore.KeyManager, loc common.Location, protocol string) error {
+	password, err := keystore.PromptAndConfirmPassword("Enter password for the new keys: ")
+	if err != nil {
+		return err
+	}
+	addresses, err := ks.CreateBatch(deriveCount, loc, protocol, password)
+	for _, address := range addresses {
+		fmt.Printf("Wallet address: %s\n", address.Hex())
+	}
+	fmt.Printf("Created: %d, Failed: %d\n", len(addresses), deriveCount-len(addresses))
+	if err != nil {
+		return fmt.Errorf("failed to create some keys: %w", err)
+	}
 	return nil
 }
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"os/signal"
+
+	"quai-transfer/config"
+	"quai-transfer/utils"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchKeyFile   string
+	watchFromBlock int64
+)
+
+var watchCmd = &cobra.Command{
+	Use:     WatchCmdName + " [-p|--pk_file /path/to/private_key.json] [--from-block <number>]",
+	Short:   WatchCmdShortDesc,
+	RunE:    runWatch,
+	Version: Version,
+}
+
+func init() {
+	flags := watchCmd.Flags()
+	flags.StringVarP(&watchKeyFile, "pk_file", "p", "", "Private key file of the wallet to watch (defaults to key_file)")
+	flags.Int64Var(&watchFromBlock, "from-block", -1, "Block number to start from, the latest block when omitted")
+	flags.SortFlags = false
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to initialize config: %w", err)
+	}
+
+	w, err := loadWallet(cfg, watchKeyFile)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var fromBlock *big.Int
+	if watchFromBlock >= 0 {
+		fromBlock = big.NewInt(watchFromBlock)
+	}
+	incoming, err := w.WatchIncoming(ctx, fromBlock)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Watching %s for incoming transactions, press Ctrl+C to stop\n", w.GetAddress().Hex())
+	for tx := range incoming {
+		fmt.Printf("📥 DEPOSIT | Block: %d | From: %s | Amount: %s Quai | Tx Hash: %s\n",
+			tx.BlockNumber, tx.From.Hex(), utils.ToQuai(tx.Value.String()), tx.Hash.Hex())
+	}
+	return nil
+}
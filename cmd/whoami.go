@@ -0,0 +1,87 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"os"
+	"strings"
+
+	"quai-transfer/config"
+	"quai-transfer/wallet"
+
+	"github.com/dominant-strategies/go-quai/crypto"
+	"github.com/spf13/cobra"
+)
+
+var whoamiKeyFile string
+
+var whoamiCmd = &cobra.Command{
+	Use:     WhoamiCmdName + " [-p|--pk_file /path/to/private_key.json]",
+	Short:   WhoamiCmdShortDesc,
+	RunE:    runWhoami,
+	Version: Version,
+}
+
+func init() {
+	flags := whoamiCmd.Flags()
+	flags.StringVarP(&whoamiKeyFile, "pk_file", "p", "", "Private key file to describe (defaults to QUAI_PRIVATE_KEY, then key_file)")
+	flags.SortFlags = false
+}
+
+func runWhoami(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to initialize config: %w", err)
+	}
+
+	// Read-only and offline: the key is decrypted but no node is contacted
+	privateKey, source, err := loadPrivateKey(cfg, whoamiKeyFile)
+	if err != nil {
+		return err
+	}
+	scope := wallet.ScopeOfKey(privateKey)
+
+	fmt.Printf("Key:                      %s\n", source)
+	fmt.Printf("Location:                 %d-%d\n", scope.Location.Region(), scope.Location.Zone())
+	fmt.Printf("Quai derivation (ECDSA):  %s\n", scope.QuaiAddress.Hex())
+	fmt.Printf("Qi derivation (Schnorr):  %s\n", scope.QiAddress.Hex())
+	fmt.Printf("Ledger:                   %s\n", ledgerHint(scope.Ledger))
+	if scope.Ledger != cfg.Protocol {
+		fmt.Printf("⚠️ Config protocol is %s, transfers with this key will be rejected\n", cfg.Protocol)
+	}
+	return nil
+}
+
+// ledgerHint explains which ledger a key can send from
+func ledgerHint(ledger string) string {
+	other := "qi"
+	if ledger == "qi" {
+		other = "quai"
+	}
+	return fmt.Sprintf("%s, both derivations share one address so this key can only send %s; "+
+		"create a key with --protocol %s for the %s ledger", ledger, ledger, other, other)
+}
+
+// loadPrivateKey reads the private key the same way loadWallet does, without opening a wallet
+func loadPrivateKey(cfg *config.Config, keyFile string) (*ecdsa.PrivateKey, string, error) {
+	if keyFile == "" && os.Getenv(PrivateKeyEnv) != "" {
+		privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(os.Getenv(PrivateKeyEnv), "0x"))
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid private key in %s: %v", PrivateKeyEnv, err)
+		}
+		return privateKey, PrivateKeyEnv, nil
+	}
+
+	ks, err := newKeyManager(cfg)
+	if err != nil {
+		return nil, "", err
+	}
+	if keyFile == "" {
+		keyFile = cfg.KeyFile
+	}
+	key, err := ks.LoadFile(keyFile)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load key from %s: %w", keyFile, err)
+	}
+	return key.PrivateKey, keyFile, nil
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/spf13/viper"
+)
+
+// loadAddressBook reads an address book, a TOML or JSON file of name = address pairs. Names
+// are matched case-insensitively, so they are returned lowercased.
+func loadAddressBook(path string) (map[string]string, error) {
+	// Names may hold dots, which viper would otherwise read as nested keys
+	v := viper.NewWithOptions(viper.KeyDelimiter("::"))
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read address_book_file %s: %w", path, err)
+	}
+
+	book := make(map[string]string)
+	for name, value := range v.AllSettings() {
+		address, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid address_book_file entry %q: the address must be a string", name)
+		}
+		address = strings.TrimSpace(address)
+		if !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("invalid address_book_file entry %q: %q is not a hex address", name, address)
+		}
+		book[strings.ToLower(strings.TrimSpace(name))] = address
+	}
+	return book, nil
+}
@@ -3,8 +3,12 @@ package config
 import (
 	"fmt"
 	"math/big"
+	"net/url"
 	"strings"
+	"time"
 
+	"quai-transfer/dal/models"
+	"quai-transfer/notify"
 	wtypes "quai-transfer/types"
 
 	"github.com/dominant-strategies/go-quai/common"
@@ -15,8 +19,11 @@ var GlobalLocation common.Location
 
 // NetworkConfig holds network specific configuration
 type NetworkConfig struct {
-	ChainID *big.Int          `mapstructure:"chain_id"`
-	RPCURLs map[string]string `mapstructure:"rpc_urls"`
+	ChainID *big.Int `mapstructure:"chain_id"`
+	// RPCURLs lists the endpoints of each location, a single URL or several tried in order
+	RPCURLs map[string][]string `mapstructure:"rpc_urls"`
+	// ChecksumAddresses rejects mixed-case addresses whose EIP-55 checksum is wrong
+	ChecksumAddresses bool `mapstructure:"checksum_addresses"`
 }
 
 type Config struct {
@@ -27,12 +34,241 @@ type Config struct {
 	KeyFile  string                           `mapstructure:"key_file"`
 	Networks map[wtypes.Network]NetworkConfig `mapstructure:"networks"`
 	Debug    bool                             `mapstructure:"debug"`
+	// TrustNodeChainID signs with the chain ID the node reports when it differs from the
+	// configured chain_id, for a chain_id known to be stale. A mismatch is only warned about.
+	TrustNodeChainID bool `mapstructure:"trust_node_chain_id"`
+	// TableName is the table of the transaction records, optionally schema-qualified, so
+	// instances sharing a database stay apart; its archive table gets an "_archive" suffix
+	TableName string `mapstructure:"table_name"`
+
+	// StrictValidation aborts the whole batch before broadcasting if any entry is invalid, and
+	// requires the value of every CSV row to have an unambiguous unit
+	StrictValidation bool `mapstructure:"strict_validation"`
+	// InputUnit is the unit of CSV values, "wei", "gwei" or "quai", for rows without a unit
+	// column value; wei when empty
+	InputUnit string `mapstructure:"input_unit"`
+	// AddressBookFile is a TOML or JSON file of name = address pairs. A CSV to_address that
+	// isn't a hex address is looked up by name in it.
+	AddressBookFile string `mapstructure:"address_book_file"`
+	// AddressBook maps the lowercased names of AddressBookFile to their address
+	AddressBook map[string]string `mapstructure:"-"`
+	// FailFast stops broadcasting new transactions at the first failed entry
+	FailFast bool `mapstructure:"fail_fast"`
+	// Concurrency is how many entries of a batch are signed and broadcast at once. Nonces are
+	// still assigned one at a time; above one they come from the local counter without waiting
+	// NonceWait for the node to catch up.
+	Concurrency int `mapstructure:"concurrency"`
+	// MaxInFlight caps how many transactions may be broadcast and unconfirmed at once, pausing
+	// broadcasting until confirmations free slots, disabled when zero
+	MaxInFlight int `mapstructure:"max_in_flight"`
+	// MaxBatchSize splits a CSV with more entries into sequential chunks of at most this many,
+	// each broadcast and monitored to the end before the next starts, disabled when zero
+	MaxBatchSize int `mapstructure:"max_batch_size"`
+	// DryRun validates, checks the balance, signs and records every transaction without ever
+	// broadcasting it; the raw transactions are logged and the records marked dry_run
+	DryRun bool `mapstructure:"dry_run"`
+	// ResumeOnStart resumes the transactions a previous run left pending before a transfer
+	// processes its CSV, as --resume does, so an unattended restart heals itself
+	ResumeOnStart bool `mapstructure:"resume_on_start"`
+	// NonceWait is how long a batch entry waits for a node whose pending nonce lags behind our
+	// own broadcasts before its nonce is assigned, disabled when zero
+	NonceWait time.Duration `mapstructure:"nonce_wait"`
+
+	// Priority orders the entries of a batch before it broadcasts, "value-desc", "value-asc" or
+	// "id", and cuts off those the balance can't cover instead of failing the batch. Empty keeps
+	// the CSV order.
+	Priority string `mapstructure:"priority"`
+
+	// MaxGasLimit rejects transactions whose gas limit is above it instead of paying for it
+	MaxGasLimit uint64 `mapstructure:"max_gas_limit"`
+
+	// GasLimit of each transfer. With GasEstimateMultiplier set the node's estimate times the
+	// multiplier is used instead, and GasLimit only when the node can't estimate.
+	GasLimit              uint64  `mapstructure:"gas_limit"`
+	GasEstimateMultiplier float64 `mapstructure:"gas_estimate_multiplier"`
+	// GasReport logs the gas each confirmed transaction used against its gas limit, and sums
+	// it up in the batch summary
+	GasReport bool `mapstructure:"gas_report"`
+
+	// Broadcast retries per entry before it is dead-lettered, with exponential backoff
+	MaxRetries   int           `mapstructure:"max_retries"`
+	RetryBackoff time.Duration `mapstructure:"retry_backoff"`
+	// RPCMaxAttempts is how many times an RPC call that can't reach the node is attempted, with
+	// exponential backoff, before it fails
+	RPCMaxAttempts int `mapstructure:"rpc_max_attempts"`
+	// BroadcastMethod is the RPC method signed transactions are sent with, "quai" for
+	// quai_sendRawTransaction or "eth" for eth_sendRawTransaction, for gateways that only serve
+	// the eth namespace. Both take the proto-encoded transaction.
+	BroadcastMethod string `mapstructure:"broadcast_method"`
+
+	// Gas price spike handling during a batch, disabled when GasPriceRefreshInterval is zero
+	GasPriceRefreshInterval  time.Duration `mapstructure:"gas_price_refresh_interval"`
+	GasSpikeThresholdPercent int64         `mapstructure:"gas_spike_threshold_percent"`
+	GasSpikeAction           string        `mapstructure:"gas_spike_action"`
+
+	// Bounds of the gas price relative to the node's suggested price, each disabled when zero
+	GasPriceMinMultiplier float64 `mapstructure:"gas_price_min_multiplier"`
+	GasPriceMaxMultiplier float64 `mapstructure:"gas_price_max_multiplier"`
+
+	// QiChangeAddresses receive the change of Qi sends, one denomination each. The node rejects
+	// change paid back to the sending address, so these must be other Qi addresses of the wallet.
+	QiChangeAddresses []string `mapstructure:"qi_change_addresses"`
+
+	// Miner tip as a percentage of the base price, never below MinMinerTip wei
+	MinerTipPercent int64  `mapstructure:"miner_tip_percent"`
+	MinMinerTip     uint64 `mapstructure:"min_miner_tip"`
+
+	// BalanceBufferPercent is the headroom the balance must have above what a batch is estimated
+	// to need before it starts, so fees rising during the run don't fail its last entries
+	BalanceBufferPercent int64 `mapstructure:"balance_buffer_percent"`
+	// BalanceCheckInterval re-checks the balance during a batch and pauses broadcasting while it
+	// can't cover the remaining entries, disabled when zero
+	BalanceCheckInterval time.Duration `mapstructure:"balance_check_interval"`
+
+	// EventLog is the path of the append-only event log, disabled when empty
+	EventLog string `mapstructure:"event_log"`
+
+	// TxRetentionDays archives confirmed records older than this many days at the start of each run, disabled when zero
+	TxRetentionDays int `mapstructure:"tx_retention_days"`
+
+	// KeystoreBackend selects where encrypted keys live, "file" for the local keystore directory
+	// or "secret-dir" for a SecretStore rooted at KeystoreSecretDir
+	KeystoreBackend   string `mapstructure:"keystore_backend"`
+	KeystoreSecretDir string `mapstructure:"keystore_secret_dir"`
+	// KeyMaxAttempts is how many random keys are generated for a new key before giving up on
+	// finding an address in the requested location and ledger
+	KeyMaxAttempts int `mapstructure:"key_max_attempts"`
+
+	// MaxClockSkew is how far the local clock may drift from the latest block timestamp before a warning, disabled when zero
+	MaxClockSkew time.Duration `mapstructure:"max_clock_skew"`
+	// MaxHeadAge is how old the node's latest block may be before the node is considered
+	// behind the chain and nothing is sent, disabled when zero
+	MaxHeadAge time.Duration `mapstructure:"max_head_age"`
+
+	// ConfirmationTimeoutBlocks is how many blocks past its broadcast height a transaction may stay
+	// unmined before ConfirmationTimeoutAction is taken, disabled when zero
+	ConfirmationTimeoutBlocks uint64 `mapstructure:"confirmation_timeout_blocks"`
+	ConfirmationTimeoutAction string `mapstructure:"confirmation_timeout_action"`
+
+	// NonceReleaseDepth is how many blocks deep a mined transaction must be before its nonce is
+	// released, so a reorg can't make it reusable; released at the first receipt when zero
+	NonceReleaseDepth uint64 `mapstructure:"nonce_release_depth"`
+	// Confirmations is how many blocks past the one that included a transaction the head must
+	// be before it is recorded as confirmed; confirmed at the first receipt when zero
+	Confirmations int `mapstructure:"confirmations"`
+	// ConfirmationMode is when a mined transaction is recorded as confirmed, "receipt" at its
+	// first receipt, "depth" once Confirmations deep, or "finalized" once the node reports its
+	// block finalized, falling back to depth on nodes that don't. Depth when Confirmations is
+	// set, receipt otherwise, when empty.
+	ConfirmationMode string `mapstructure:"confirmation_mode"`
+
+	// WebhookURL receives a POST for each entry outcome, disabled when empty. WebhookTemplate
+	// is the Go template of the JSON payload, notify.DefaultTemplate when empty.
+	WebhookURL      string `mapstructure:"webhook_url"`
+	WebhookTemplate string `mapstructure:"webhook_template"`
+	// ExecHook is a command run for each entry outcome, disabled when empty: the command and its
+	// arguments, each a Go template with the variables of the webhook payload. Each run is
+	// killed after ExecHookTimeout.
+	ExecHook        []string      `mapstructure:"exec_hook"`
+	ExecHookTimeout time.Duration `mapstructure:"exec_hook_timeout"`
+
+	// MetricsBackend selects where batch metrics go, "none", "statsd" to StatsDAddress with
+	// every name prefixed by StatsDPrefix, or "prometheus" served on /metrics at MetricsAddr.
+	// Setting MetricsAddr alone selects "prometheus".
+	MetricsBackend string `mapstructure:"metrics_backend"`
+	StatsDAddress  string `mapstructure:"statsd_address"`
+	StatsDPrefix   string `mapstructure:"statsd_prefix"`
+	MetricsAddr    string `mapstructure:"metrics_addr"`
+
+	// LogFormat is how the lifecycle of each entry is logged: "text" lines, or "json" objects
+	// with the entry ID, tx hash, miner account, amount and status, for log aggregation
+	LogFormat string `mapstructure:"log_format"`
 }
 
+const (
+	KeystoreBackendFile      = "file"
+	KeystoreBackendSecretDir = "secret-dir"
+)
+
+const (
+	ConfirmationTimeoutRebroadcast = "rebroadcast"
+	ConfirmationTimeoutAbandon     = "abandon"
+)
+
+const (
+	BroadcastMethodQuai = "quai"
+	BroadcastMethodEth  = "eth"
+)
+
+const (
+	ConfirmationModeReceipt   = "receipt"
+	ConfirmationModeDepth     = "depth"
+	ConfirmationModeFinalized = "finalized"
+)
+
+const (
+	MetricsBackendNone       = "none"
+	MetricsBackendStatsD     = "statsd"
+	MetricsBackendPrometheus = "prometheus"
+)
+
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+const (
+	GasSpikeActionPause  = "pause"
+	GasSpikeActionAdjust = "adjust"
+)
+
+// DefaultMaxGasLimit is well above what a plain transfer needs, while still bounding the fee
+const DefaultMaxGasLimit = 2_000_000
+
+// DefaultGasLimit is the gas limit of a transfer when gas_limit is unset
+const DefaultGasLimit = 420000
+
+// DefaultMinMinerTip is the floor of the miner tip in wei
+const DefaultMinMinerTip = 1000
+
+// DefaultKeyMaxAttempts bounds the random keys generated for a new key. About one in 512 is in
+// the Quai or Qi ledger of a given zone, so running out takes far more than bad luck.
+const DefaultKeyMaxAttempts = 100000
+
+// DefaultNonceReleaseDepth is how many blocks deep a mined transaction must be before its nonce is released
+const DefaultNonceReleaseDepth = 5
+
+// minGasLimit is the gas of a plain transfer
+const minGasLimit = 21000
+
 // LoadConfig loads configuration from config file
 func LoadConfig(configPath string) (*Config, error) {
 	viper.SetConfigName("config")
 	viper.SetConfigType("toml")
+	viper.SetDefault("gas_spike_threshold_percent", 50)
+	viper.SetDefault("gas_spike_action", GasSpikeActionPause)
+	viper.SetDefault("max_gas_limit", DefaultMaxGasLimit)
+	viper.SetDefault("gas_limit", DefaultGasLimit)
+	viper.SetDefault("miner_tip_percent", 10)
+	viper.SetDefault("min_miner_tip", DefaultMinMinerTip)
+	viper.SetDefault("balance_buffer_percent", 10)
+	viper.SetDefault("table_name", models.DefaultTableName)
+	viper.SetDefault("concurrency", 1)
+	viper.SetDefault("nonce_wait", "2s")
+	viper.SetDefault("max_retries", 3)
+	viper.SetDefault("retry_backoff", "2s")
+	viper.SetDefault("rpc_max_attempts", 3)
+	viper.SetDefault("exec_hook_timeout", notify.DefaultExecTimeout)
+	viper.SetDefault("broadcast_method", BroadcastMethodQuai)
+	viper.SetDefault("keystore_backend", KeystoreBackendFile)
+	viper.SetDefault("key_max_attempts", DefaultKeyMaxAttempts)
+	viper.SetDefault("max_clock_skew", "2m")
+	viper.SetDefault("max_head_age", "2m")
+	viper.SetDefault("confirmation_timeout_action", ConfirmationTimeoutRebroadcast)
+	viper.SetDefault("nonce_release_depth", DefaultNonceReleaseDepth)
+	viper.SetDefault("metrics_backend", MetricsBackendNone)
+	viper.SetDefault("statsd_prefix", "quai_transfer")
+	viper.SetDefault("log_format", LogFormatText)
 
 	// If configPath is empty, look in default locations
 	if configPath != "" {
@@ -48,17 +284,88 @@ func LoadConfig(configPath string) (*Config, error) {
 	}
 
 	var rawConfig struct {
-		InterDSN string `mapstructure:"dsn"`
-		Network  string `mapstructure:"network"`
-		Rpc      string `mapstructure:"rpc"`
-		Protocol string `mapstructure:"protocol"`
-		Location string `mapstructure:"location"`
-		KeyFile  string `mapstructure:"key_file"`
-		Networks map[string]struct {
-			ChainID int64             `mapstructure:"chain_id"`
-			RPCURLs map[string]string `mapstructure:"rpc_urls"`
+		InterDSN  string `mapstructure:"dsn"`
+		Network   string `mapstructure:"network"`
+		Rpc       string `mapstructure:"rpc"`
+		Protocol  string `mapstructure:"protocol"`
+		Location  string `mapstructure:"location"`
+		KeyFile   string `mapstructure:"key_file"`
+		TableName string `mapstructure:"table_name"`
+		Networks  map[string]struct {
+			ChainID           int64               `mapstructure:"chain_id"`
+			RPCURLs           map[string][]string `mapstructure:"rpc_urls"`
+			ChecksumAddresses bool                `mapstructure:"checksum_addresses"`
 		} `mapstructure:"networks"`
-		Debug bool `mapstructure:"debug"`
+		Debug            bool   `mapstructure:"debug"`
+		TrustNodeChainID bool   `mapstructure:"trust_node_chain_id"`
+		StrictValidation bool   `mapstructure:"strict_validation"`
+		InputUnit        string `mapstructure:"input_unit"`
+		AddressBookFile  string `mapstructure:"address_book_file"`
+		FailFast         bool   `mapstructure:"fail_fast"`
+		Concurrency      int    `mapstructure:"concurrency"`
+		MaxInFlight      int    `mapstructure:"max_in_flight"`
+		MaxBatchSize     int    `mapstructure:"max_batch_size"`
+		DryRun           bool   `mapstructure:"dry_run"`
+		ResumeOnStart    bool   `mapstructure:"resume_on_start"`
+
+		NonceWait time.Duration `mapstructure:"nonce_wait"`
+
+		Priority string `mapstructure:"priority"`
+
+		MaxGasLimit uint64 `mapstructure:"max_gas_limit"`
+
+		GasLimit              uint64  `mapstructure:"gas_limit"`
+		GasEstimateMultiplier float64 `mapstructure:"gas_estimate_multiplier"`
+		GasReport             bool    `mapstructure:"gas_report"`
+
+		MaxRetries      int           `mapstructure:"max_retries"`
+		RetryBackoff    time.Duration `mapstructure:"retry_backoff"`
+		RPCMaxAttempts  int           `mapstructure:"rpc_max_attempts"`
+		BroadcastMethod string        `mapstructure:"broadcast_method"`
+
+		GasPriceRefreshInterval  time.Duration `mapstructure:"gas_price_refresh_interval"`
+		GasSpikeThresholdPercent int64         `mapstructure:"gas_spike_threshold_percent"`
+		GasSpikeAction           string        `mapstructure:"gas_spike_action"`
+
+		GasPriceMinMultiplier float64 `mapstructure:"gas_price_min_multiplier"`
+		GasPriceMaxMultiplier float64 `mapstructure:"gas_price_max_multiplier"`
+
+		QiChangeAddresses []string `mapstructure:"qi_change_addresses"`
+
+		MinerTipPercent int64  `mapstructure:"miner_tip_percent"`
+		MinMinerTip     uint64 `mapstructure:"min_miner_tip"`
+
+		BalanceBufferPercent int64         `mapstructure:"balance_buffer_percent"`
+		BalanceCheckInterval time.Duration `mapstructure:"balance_check_interval"`
+
+		EventLog string `mapstructure:"event_log"`
+
+		TxRetentionDays int `mapstructure:"tx_retention_days"`
+
+		KeystoreBackend   string `mapstructure:"keystore_backend"`
+		KeystoreSecretDir string `mapstructure:"keystore_secret_dir"`
+		KeyMaxAttempts    int    `mapstructure:"key_max_attempts"`
+
+		MaxClockSkew time.Duration `mapstructure:"max_clock_skew"`
+		MaxHeadAge   time.Duration `mapstructure:"max_head_age"`
+
+		ConfirmationTimeoutBlocks uint64 `mapstructure:"confirmation_timeout_blocks"`
+		ConfirmationTimeoutAction string `mapstructure:"confirmation_timeout_action"`
+		NonceReleaseDepth         uint64 `mapstructure:"nonce_release_depth"`
+		Confirmations             int    `mapstructure:"confirmations"`
+		ConfirmationMode          string `mapstructure:"confirmation_mode"`
+
+		WebhookURL      string        `mapstructure:"webhook_url"`
+		WebhookTemplate string        `mapstructure:"webhook_template"`
+		ExecHook        []string      `mapstructure:"exec_hook"`
+		ExecHookTimeout time.Duration `mapstructure:"exec_hook_timeout"`
+
+		MetricsBackend string `mapstructure:"metrics_backend"`
+		StatsDAddress  string `mapstructure:"statsd_address"`
+		StatsDPrefix   string `mapstructure:"statsd_prefix"`
+		MetricsAddr    string `mapstructure:"metrics_addr"`
+
+		LogFormat string `mapstructure:"log_format"`
 	}
 
 	if err := viper.Unmarshal(&rawConfig); err != nil {
@@ -66,27 +373,273 @@ func LoadConfig(configPath string) (*Config, error) {
 	}
 
 	config := &Config{
-		InterDSN: rawConfig.InterDSN,
-		Network:  wtypes.Network(strings.ToLower(rawConfig.Network)),
-		Protocol: rawConfig.Protocol,
-		Location: StringToLocation(rawConfig.Location),
-		KeyFile:  rawConfig.KeyFile,
-		Networks: make(map[wtypes.Network]NetworkConfig),
-		Debug:    rawConfig.Debug,
+		InterDSN:  rawConfig.InterDSN,
+		Network:   wtypes.Network(strings.ToLower(rawConfig.Network)),
+		Protocol:  rawConfig.Protocol,
+		Location:  StringToLocation(rawConfig.Location),
+		KeyFile:   rawConfig.KeyFile,
+		Networks:  make(map[wtypes.Network]NetworkConfig),
+		Debug:     rawConfig.Debug,
+		TableName: rawConfig.TableName,
+
+		TrustNodeChainID: rawConfig.TrustNodeChainID,
+
+		StrictValidation: rawConfig.StrictValidation,
+		InputUnit:        strings.ToLower(rawConfig.InputUnit),
+		AddressBookFile:  rawConfig.AddressBookFile,
+		FailFast:         rawConfig.FailFast,
+		Concurrency:      rawConfig.Concurrency,
+		MaxInFlight:      rawConfig.MaxInFlight,
+		MaxBatchSize:     rawConfig.MaxBatchSize,
+		DryRun:           rawConfig.DryRun,
+		ResumeOnStart:    rawConfig.ResumeOnStart,
+		NonceWait:        rawConfig.NonceWait,
+
+		Priority: strings.ToLower(rawConfig.Priority),
+
+		MaxGasLimit: rawConfig.MaxGasLimit,
+
+		GasLimit:              rawConfig.GasLimit,
+		GasEstimateMultiplier: rawConfig.GasEstimateMultiplier,
+		GasReport:             rawConfig.GasReport,
+
+		MaxRetries:      rawConfig.MaxRetries,
+		RetryBackoff:    rawConfig.RetryBackoff,
+		RPCMaxAttempts:  rawConfig.RPCMaxAttempts,
+		BroadcastMethod: strings.ToLower(rawConfig.BroadcastMethod),
+
+		GasPriceRefreshInterval:  rawConfig.GasPriceRefreshInterval,
+		GasSpikeThresholdPercent: rawConfig.GasSpikeThresholdPercent,
+		GasSpikeAction:           strings.ToLower(rawConfig.GasSpikeAction),
+
+		GasPriceMinMultiplier: rawConfig.GasPriceMinMultiplier,
+		GasPriceMaxMultiplier: rawConfig.GasPriceMaxMultiplier,
+
+		QiChangeAddresses: rawConfig.QiChangeAddresses,
+
+		MinerTipPercent: rawConfig.MinerTipPercent,
+		MinMinerTip:     rawConfig.MinMinerTip,
+
+		BalanceBufferPercent: rawConfig.BalanceBufferPercent,
+		BalanceCheckInterval: rawConfig.BalanceCheckInterval,
+
+		EventLog: rawConfig.EventLog,
+
+		TxRetentionDays: rawConfig.TxRetentionDays,
+
+		KeystoreBackend:   strings.ToLower(rawConfig.KeystoreBackend),
+		KeystoreSecretDir: rawConfig.KeystoreSecretDir,
+		KeyMaxAttempts:    rawConfig.KeyMaxAttempts,
+
+		MaxClockSkew: rawConfig.MaxClockSkew,
+		MaxHeadAge:   rawConfig.MaxHeadAge,
+
+		ConfirmationTimeoutBlocks: rawConfig.ConfirmationTimeoutBlocks,
+		NonceReleaseDepth:         rawConfig.NonceReleaseDepth,
+		Confirmations:             rawConfig.Confirmations,
+		ConfirmationMode:          strings.ToLower(rawConfig.ConfirmationMode),
+		ConfirmationTimeoutAction: strings.ToLower(rawConfig.ConfirmationTimeoutAction),
+
+		WebhookURL:      rawConfig.WebhookURL,
+		WebhookTemplate: rawConfig.WebhookTemplate,
+		ExecHook:        rawConfig.ExecHook,
+		ExecHookTimeout: rawConfig.ExecHookTimeout,
+
+		MetricsBackend: strings.ToLower(rawConfig.MetricsBackend),
+		StatsDAddress:  rawConfig.StatsDAddress,
+		StatsDPrefix:   rawConfig.StatsDPrefix,
+		MetricsAddr:    rawConfig.MetricsAddr,
+
+		LogFormat: strings.ToLower(rawConfig.LogFormat),
 	}
 
 	if !wtypes.ValidNetworks[config.Network] {
 		return nil, fmt.Errorf("invalid network %q", config.Network)
 	}
 
+	if err := models.ValidateTableName(config.TableName); err != nil {
+		return nil, fmt.Errorf("invalid table_name %q: %w", config.TableName, err)
+	}
+
+	if config.Concurrency < 1 {
+		return nil, fmt.Errorf("invalid concurrency %d, must be at least 1", config.Concurrency)
+	}
+
+	if config.MaxInFlight < 0 {
+		return nil, fmt.Errorf("invalid max_in_flight %d, must not be negative", config.MaxInFlight)
+	}
+
+	if config.MaxBatchSize < 0 {
+		return nil, fmt.Errorf("invalid max_batch_size %d, must not be negative", config.MaxBatchSize)
+	}
+
+	if config.NonceWait < 0 {
+		return nil, fmt.Errorf("invalid nonce_wait %s, must not be negative", config.NonceWait)
+	}
+
+	if config.TxRetentionDays < 0 {
+		return nil, fmt.Errorf("invalid tx_retention_days %d, must not be negative", config.TxRetentionDays)
+	}
+
+	switch config.KeystoreBackend {
+	case KeystoreBackendFile:
+	case KeystoreBackendSecretDir:
+		if config.KeystoreSecretDir == "" {
+			return nil, fmt.Errorf("keystore_secret_dir is required for keystore_backend %q", KeystoreBackendSecretDir)
+		}
+	default:
+		return nil, fmt.Errorf("invalid keystore_backend %q, must be %q or %q", config.KeystoreBackend, KeystoreBackendFile, KeystoreBackendSecretDir)
+	}
+	if _, ok := wtypes.UnitExponent(config.InputUnit); config.InputUnit != "" && !ok {
+		return nil, fmt.Errorf("invalid input_unit %q, must be %q, %q or %q", config.InputUnit, wtypes.UnitWei, wtypes.UnitGwei, wtypes.UnitQuai)
+	}
+	if config.AddressBookFile != "" {
+		book, err := loadAddressBook(config.AddressBookFile)
+		if err != nil {
+			return nil, err
+		}
+		config.AddressBook = book
+	}
+	if config.KeyMaxAttempts < 1 {
+		return nil, fmt.Errorf("invalid key_max_attempts %d, must be at least 1", config.KeyMaxAttempts)
+	}
+
+	if config.MaxGasLimit < minGasLimit {
+		return nil, fmt.Errorf("invalid max_gas_limit %d, must be at least %d", config.MaxGasLimit, minGasLimit)
+	}
+	if config.GasLimit < minGasLimit || config.GasLimit > config.MaxGasLimit {
+		return nil, fmt.Errorf("invalid gas_limit %d, must be between %d and max_gas_limit %d", config.GasLimit, minGasLimit, config.MaxGasLimit)
+	}
+	if config.GasEstimateMultiplier != 0 && config.GasEstimateMultiplier < 1 {
+		return nil, fmt.Errorf("invalid gas_estimate_multiplier %g, must be at least 1, or 0 to use gas_limit", config.GasEstimateMultiplier)
+	}
+
+	if config.GasPriceMinMultiplier < 0 || config.GasPriceMaxMultiplier < 0 {
+		return nil, fmt.Errorf("invalid gas price multipliers %g and %g, must not be negative",
+			config.GasPriceMinMultiplier, config.GasPriceMaxMultiplier)
+	}
+	if config.GasPriceMaxMultiplier > 0 && config.GasPriceMaxMultiplier < config.GasPriceMinMultiplier {
+		return nil, fmt.Errorf("invalid gas_price_max_multiplier %g, must not be below gas_price_min_multiplier %g",
+			config.GasPriceMaxMultiplier, config.GasPriceMinMultiplier)
+	}
+
+	seenChange := make(map[string]bool, len(config.QiChangeAddresses))
+	for _, address := range config.QiChangeAddresses {
+		b := common.FromHex(address)
+		if !common.IsHexAddress(address) || b[1] <= 127 {
+			return nil, fmt.Errorf("invalid qi_change_addresses entry %q, must be a Qi address", address)
+		}
+		if seenChange[strings.ToLower(address)] {
+			return nil, fmt.Errorf("duplicate qi_change_addresses entry %q", address)
+		}
+		seenChange[strings.ToLower(address)] = true
+	}
+
+	if config.MinerTipPercent < 0 {
+		return nil, fmt.Errorf("invalid miner_tip_percent %d, must not be negative", config.MinerTipPercent)
+	}
+
+	if config.BalanceBufferPercent < 0 {
+		return nil, fmt.Errorf("invalid balance_buffer_percent %d, must not be negative", config.BalanceBufferPercent)
+	}
+
+	if config.MaxClockSkew < 0 {
+		return nil, fmt.Errorf("invalid max_clock_skew %s, must not be negative", config.MaxClockSkew)
+	}
+	if config.MaxHeadAge < 0 {
+		return nil, fmt.Errorf("invalid max_head_age %s, must not be negative", config.MaxHeadAge)
+	}
+
+	if config.ConfirmationTimeoutAction != ConfirmationTimeoutRebroadcast && config.ConfirmationTimeoutAction != ConfirmationTimeoutAbandon {
+		return nil, fmt.Errorf("invalid confirmation_timeout_action %q, must be %q or %q",
+			config.ConfirmationTimeoutAction, ConfirmationTimeoutRebroadcast, ConfirmationTimeoutAbandon)
+	}
+
+	if config.WebhookURL != "" {
+		if u, err := url.Parse(config.WebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, fmt.Errorf("invalid webhook_url %q, must be an http(s) URL", config.WebhookURL)
+		}
+	}
+	if _, err := notify.ParseTemplate(config.WebhookTemplate); err != nil {
+		return nil, fmt.Errorf("invalid webhook_template: %w", err)
+	}
+	if len(config.ExecHook) > 0 {
+		if _, err := notify.ParseExecArgs(config.ExecHook); err != nil {
+			return nil, fmt.Errorf("invalid exec_hook: %w", err)
+		}
+	}
+	if config.ExecHookTimeout <= 0 {
+		return nil, fmt.Errorf("invalid exec_hook_timeout %s, must be positive", config.ExecHookTimeout)
+	}
+
+	if config.LogFormat != LogFormatText && config.LogFormat != LogFormatJSON {
+		return nil, fmt.Errorf("invalid log_format %q, must be %q or %q", config.LogFormat, LogFormatText, LogFormatJSON)
+	}
+
+	if config.MetricsBackend == MetricsBackendNone && config.MetricsAddr != "" {
+		config.MetricsBackend = MetricsBackendPrometheus
+	}
+	switch config.MetricsBackend {
+	case MetricsBackendNone:
+	case MetricsBackendStatsD:
+		if config.StatsDAddress == "" {
+			return nil, fmt.Errorf("statsd_address is required for metrics_backend %q", MetricsBackendStatsD)
+		}
+	case MetricsBackendPrometheus:
+		if config.MetricsAddr == "" {
+			return nil, fmt.Errorf("metrics_addr is required for metrics_backend %q", MetricsBackendPrometheus)
+		}
+	default:
+		return nil, fmt.Errorf("invalid metrics_backend %q, must be %q, %q or %q", config.MetricsBackend,
+			MetricsBackendNone, MetricsBackendStatsD, MetricsBackendPrometheus)
+	}
+
+	if config.Confirmations < 0 {
+		return nil, fmt.Errorf("invalid confirmations %d, must not be negative", config.Confirmations)
+	}
+	if config.ConfirmationMode == "" {
+		config.ConfirmationMode = ConfirmationModeReceipt
+		if config.Confirmations > 0 {
+			config.ConfirmationMode = ConfirmationModeDepth
+		}
+	}
+	switch config.ConfirmationMode {
+	case ConfirmationModeReceipt:
+		if config.Confirmations > 0 {
+			return nil, fmt.Errorf("confirmations %d has no effect with confirmation_mode %q", config.Confirmations, ConfirmationModeReceipt)
+		}
+	case ConfirmationModeDepth, ConfirmationModeFinalized:
+		// The depth the finalized mode falls back to
+		if config.Confirmations == 0 {
+			return nil, fmt.Errorf("confirmations is required for confirmation_mode %q", config.ConfirmationMode)
+		}
+	default:
+		return nil, fmt.Errorf("invalid confirmation_mode %q, must be %q, %q or %q", config.ConfirmationMode,
+			ConfirmationModeReceipt, ConfirmationModeDepth, ConfirmationModeFinalized)
+	}
+	if config.MaxRetries < 0 {
+		return nil, fmt.Errorf("invalid max_retries %d, must not be negative", config.MaxRetries)
+	}
+	if config.RPCMaxAttempts < 1 {
+		return nil, fmt.Errorf("invalid rpc_max_attempts %d, must be at least 1", config.RPCMaxAttempts)
+	}
+	if config.BroadcastMethod != BroadcastMethodQuai && config.BroadcastMethod != BroadcastMethodEth {
+		return nil, fmt.Errorf("invalid broadcast_method %q, must be %q or %q", config.BroadcastMethod, BroadcastMethodQuai, BroadcastMethodEth)
+	}
+
+	if config.GasSpikeAction != GasSpikeActionPause && config.GasSpikeAction != GasSpikeActionAdjust {
+		return nil, fmt.Errorf("invalid gas_spike_action %q, must be %q or %q", config.GasSpikeAction, GasSpikeActionPause, GasSpikeActionAdjust)
+	}
+
 	for name, netConfig := range rawConfig.Networks {
 		network := wtypes.Network(strings.ToLower(name))
 		if !wtypes.ValidNetworks[network] {
 			return nil, fmt.Errorf("invalid network %q in networks configuration", name)
 		}
 		config.Networks[network] = NetworkConfig{
-			ChainID: big.NewInt(netConfig.ChainID),
-			RPCURLs: netConfig.RPCURLs,
+			ChainID:           big.NewInt(netConfig.ChainID),
+			RPCURLs:           netConfig.RPCURLs,
+			ChecksumAddresses: netConfig.ChecksumAddresses,
 		}
 	}
 
@@ -99,6 +652,21 @@ func LoadConfig(configPath string) (*Config, error) {
 	return config, nil
 }
 
+// Redacted returns a copy of the config safe for logging, with the DSN password masked and
+// the webhook URL cut to its host, since webhook paths often embed a token
+func (c *Config) Redacted() Config {
+	redacted := *c
+	if u, err := url.Parse(c.InterDSN); err == nil {
+		redacted.InterDSN = u.Redacted()
+	} else {
+		redacted.InterDSN = "xxxxx"
+	}
+	if u, err := url.Parse(c.WebhookURL); err == nil && c.WebhookURL != "" {
+		redacted.WebhookURL = u.Scheme + "://" + u.Host + "/xxxxx"
+	}
+	return redacted
+}
+
 func StringToLocation(s string) common.Location {
 	var region, zone int
 	fmt.Sscanf(s, "%d-%d", &region, &zone)
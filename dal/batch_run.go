@@ -0,0 +1,23 @@
+package dal
+
+import (
+	"context"
+
+	"quai-transfer/dal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BatchRunDAL struct {
+	db *gorm.DB
+}
+
+func NewBatchRunDAL(db *gorm.DB) *BatchRunDAL {
+	return &BatchRunDAL{db: db}
+}
+
+// SaveBatchRun writes the progress of a batch run, in a single upsert by run ID and payer
+func (d *BatchRunDAL) SaveBatchRun(ctx context.Context, run *models.BatchRun) error {
+	return d.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(run).Error
+}
@@ -0,0 +1,58 @@
+package dal
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+
+	"gorm.io/gorm"
+)
+
+// AdvisoryLock is a Postgres session advisory lock, held on a connection of its own so that
+// instances sharing the database can take turns at work only one of them may do
+type AdvisoryLock struct {
+	conn *sql.Conn
+	key  int64
+}
+
+// TryAdvisoryLock takes the advisory lock of the given name without waiting. It returns nil
+// when another session holds the lock.
+func TryAdvisoryLock(ctx context.Context, db *gorm.DB, name string) (*AdvisoryLock, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database handle: %v", err)
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection for lock %q: %v", name, err)
+	}
+
+	key := advisoryLockKey(name)
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to take lock %q: %v", name, err)
+	}
+	if !locked {
+		conn.Close()
+		return nil, nil
+	}
+	return &AdvisoryLock{conn: conn, key: key}, nil
+}
+
+// Release releases the lock and returns its connection to the pool
+func (l *AdvisoryLock) Release() error {
+	defer l.conn.Close()
+	if _, err := l.conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", l.key); err != nil {
+		return fmt.Errorf("failed to release lock: %v", err)
+	}
+	return nil
+}
+
+// advisoryLockKey maps a lock name to the 64-bit key Postgres locks on
+func advisoryLockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
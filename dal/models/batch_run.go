@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Batch run statuses
+const (
+	BatchRunning  = "running"
+	BatchFinished = "finished"
+)
+
+// BatchRun is the live progress of the batch of one wallet in a transfer run, written while
+// the batch runs so dashboards can follow it with SQL
+type BatchRun struct {
+	RunID      string     `gorm:"type:varchar(64);primaryKey"`
+	Payer      string     `gorm:"type:varchar(42);primaryKey"`
+	Location   string     `gorm:"type:varchar(16)"`
+	Status     string     `gorm:"type:varchar(16);index"` // running or finished
+	Total      int        // entries of the batch
+	Broadcast  int        // transactions the node accepted
+	Confirmed  int        // transactions mined successfully
+	Failed     int        // entries invalid, failed, dead-lettered or reverted
+	QueueDepth int        // transactions broadcast and not confirmed yet
+	Summary    *string    `gorm:"type:jsonb"` // the batch result, once finished
+	StartedAt  time.Time  `gorm:"index"`
+	UpdatedAt  time.Time  // time of the last write
+	FinishedAt *time.Time // set once finished
+}
+
+func (r *BatchRun) TableName() string {
+	return "batch_runs"
+}
@@ -1,6 +1,9 @@
 package models
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/lib/pq"
@@ -9,11 +12,36 @@ import (
 
 type TxStatus uint64
 
+// Statuses are stored as their numbers, so each is pinned to its value
 const (
-	Generated TxStatus = iota
-	Confirmed
+	Generated TxStatus = 0
+	Confirmed TxStatus = 1
+	// Failed marks a transaction mined but reverted
+	Failed TxStatus = 2
+	// DeadLetter marks a transaction whose broadcast kept failing; it's skipped until requeued
+	DeadLetter TxStatus = 3
+	// DryRun marks a transaction signed by a dry run, never broadcast; a real run signs it anew
+	DryRun TxStatus = 4
 )
 
+// String returns the name of a status as written to reports
+func (s TxStatus) String() string {
+	switch s {
+	case Generated:
+		return "pending"
+	case Confirmed:
+		return "confirmed"
+	case Failed:
+		return "failed"
+	case DeadLetter:
+		return "dead_letter"
+	case DryRun:
+		return "dry_run"
+	default:
+		return fmt.Sprintf("status_%d", uint64(s))
+	}
+}
+
 type Transaction struct {
 	ID                int32           `gorm:"primaryKey"` // not auto increment, but business increment (for deduplication)
 	MinerAccount      string          `gorm:"type:varchar(42)"`
@@ -23,18 +51,76 @@ type Transaction struct {
 	TxHash            string          `gorm:"type:varchar(66);uniqueIndex"`
 	Value             decimal.Decimal `gorm:"type:decimal(78,0)"`
 	Gas               decimal.Decimal `gorm:"type:decimal(78,0)"`
-	GasLimit          decimal.Decimal `gorm:"type:decimal(78,0)"` // real gas limit
-	GasUsed           decimal.Decimal `gorm:"type:decimal(78,0)"` // real gas used
-	CumulativeGasUsed decimal.Decimal `gorm:"type:decimal(78,0)"` // calculated gas used
-	GasPrice          decimal.Decimal `gorm:"type:decimal(78,0)"` // real gas price
-	Status            TxStatus        `gorm:"default:0"`          // 0: pending, 1: success, 2: failed
+	GasLimit          decimal.Decimal `gorm:"type:decimal(78,0)"`           // real gas limit
+	GasEstimate       decimal.Decimal `gorm:"type:decimal(78,0);default:0"` // node's estimate the gas limit was derived from, 0 when gas_limit was used
+	GasUsed           decimal.Decimal `gorm:"type:decimal(78,0)"`           // real gas used
+	CumulativeGasUsed decimal.Decimal `gorm:"type:decimal(78,0)"`           // calculated gas used
+	GasPrice          decimal.Decimal `gorm:"type:decimal(78,0)"`           // real gas price
+	Status            TxStatus        `gorm:"default:0"`                    // 0: pending, 1: success, 2: failed, 3: dead letter, 4: dry run
 	CreatedAt         time.Time       `gorm:"index"`
-	ConfirmedAt       *time.Time      `gorm:"index"`
+	ConfirmedAt       *time.Time      `gorm:"index"`                 // local time the receipt was seen
+	BlockNumber       uint64          `gorm:"type:bigint;default:0"` // block that included the transaction
+	BlockTime         *time.Time      `gorm:"index"`                 // timestamp of that block
 	AggregateIds      pq.Int64Array   `gorm:"type:int8[]"`
 	Tx                string          `gorm:"type:jsonb"`
 	Entry             string          `gorm:"type:jsonb"`
+	IdempotencyKey    *string         `gorm:"type:varchar(128);uniqueIndex"` // optional, dedups payouts independently of ID
+	RetryCount        int             `gorm:"default:0"`                     // failed broadcast attempts
+	LastError         string          `gorm:"type:text"`                     // last broadcast error
+	BroadcastHeight   uint64          `gorm:"type:bigint;default:0"`         // head block number at the last broadcast
+	FailureCode       string          `gorm:"type:varchar(32);index"`        // why the entry was dead-lettered, one of the wallet failure codes
+	FailureReason     string          `gorm:"type:text"`                     // the error that dead-lettered it
+}
+
+// DefaultTableName is the table of the transaction records unless table_name is set
+const DefaultTableName = "quai_transfer_record"
+
+// archiveSuffix names the archive table after the records table
+const archiveSuffix = "_archive"
+
+// tableName is the table of the transaction records, so instances sharing a database can keep
+// their records apart. gorm caches the table of a model, so it's set once before the first query.
+var tableName = DefaultTableName
+
+// tableNamePattern matches a lowercase identifier, optionally qualified with a schema
+var tableNamePattern = regexp.MustCompile(`^(?:[a-z_][a-z0-9_]*\.)?[a-z_][a-z0-9_]*$`)
+
+// ValidateTableName checks a table name is safe to use. It ends up in SQL unquoted, so only
+// lowercase identifiers are allowed, short enough for Postgres once the archive suffix is added.
+func ValidateTableName(name string) error {
+	if !tableNamePattern.MatchString(name) {
+		return fmt.Errorf("must be a lowercase identifier of letters, digits and underscores, optionally prefixed with a schema and a dot")
+	}
+	_, table, found := strings.Cut(name, ".")
+	if !found {
+		table = name
+	}
+	if maxLen := 63 - len(archiveSuffix); len(table) > maxLen {
+		return fmt.Errorf("table name is longer than %d characters", maxLen)
+	}
+	return nil
+}
+
+// SetTableName sets the table of the transaction records, and its archive table to the same
+// name with the archive suffix. It must be called before the database is first used.
+func SetTableName(name string) error {
+	if err := ValidateTableName(name); err != nil {
+		return err
+	}
+	tableName = name
+	return nil
 }
 
 func (t *Transaction) TableName() string {
-	return "quai_transfer_record"
+	return tableName
+}
+
+// ArchivedTransaction is a confirmed transaction moved out of the active table by the
+// retention policy. Archived records still count when deduplicating entries.
+type ArchivedTransaction struct {
+	Transaction
+}
+
+func (t *ArchivedTransaction) TableName() string {
+	return tableName + archiveSuffix
 }
@@ -10,6 +10,8 @@ import (
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 	"quai-transfer/config"
+	"quai-transfer/dal/models"
+	"quai-transfer/utils"
 )
 
 var (
@@ -30,8 +32,11 @@ func DBInit(config *config.Config) {
 		{config.InterDSN, &InterDB},
 	}
 
+	opened := false
 	for _, dbItem := range dbConfigs {
-		if dbItem.DSN != "" {
+		// the connection is shared by every wallet of the process, open it only once
+		if dbItem.DSN != "" && *dbItem.DB == nil {
+			opened = true
 			if *dbItem.DB, err = gorm.Open(postgres.Open(dbItem.DSN), &gorm.Config{}); err != nil {
 				log.Fatal(err)
 			}
@@ -42,7 +47,7 @@ func DBInit(config *config.Config) {
 					SlowThreshold:             time.Second,
 					LogLevel:                  logger.Error,
 					IgnoreRecordNotFoundError: true,
-					Colorful:                  true,
+					Colorful:                  utils.ColorEnabled(),
 				},
 			)
 
@@ -65,4 +70,26 @@ func DBInit(config *config.Config) {
 		}
 	}
 
+	// Migrated once, when the connection is opened, rather than for every wallet
+	if opened && InterDB != nil {
+		if err = models.SetTableName(config.TableName); err != nil {
+			log.Fatalf("invalid table_name %q: %v", config.TableName, err)
+		}
+		if err = InterDB.AutoMigrate(&models.Transaction{}, &models.ArchivedTransaction{}, &models.BatchRun{}); err != nil {
+			log.Fatalf("failed to migrate transaction table: %v", err)
+		}
+	}
+}
+
+// DBClose closes the database connections opened by DBInit, if any
+func DBClose() {
+	if InterDB == nil {
+		return
+	}
+	if sqlDB, err := InterDB.DB(); err == nil {
+		if err := sqlDB.Close(); err != nil {
+			log.Printf("failed to close database: %v", err)
+		}
+	}
+	InterDB = nil
 }
@@ -3,6 +3,7 @@ package dal
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	"quai-transfer/dal/models"
@@ -24,21 +25,154 @@ func (d *TransactionDAL) CreateTransaction(ctx context.Context, tx *models.Trans
 	return d.db.WithContext(ctx).Create(tx).Error
 }
 
-func (d *TransactionDAL) UpdateTransactionStatus(ctx context.Context, txHash string, gasUsedAmount decimal.Decimal, receipt *types.Receipt) error {
+// UpdateTransactionStatus records the receipt of a transaction. blockTime is the timestamp of
+// the block that included it and is left unset when zero; confirmed_at is the local time.
+func (d *TransactionDAL) UpdateTransactionStatus(ctx context.Context, txHash string, gasUsedAmount decimal.Decimal, receipt *types.Receipt, blockTime time.Time) error {
 	gasUsedCalculated := decimal.NewFromInt(int64(receipt.GasUsed))
 	cumulativeGasUsed := decimal.NewFromInt(int64(receipt.CumulativeGasUsed))
 
+	status := models.Confirmed
+	if receipt.Status == types.ReceiptStatusFailed {
+		status = models.Failed
+	}
+	updates := map[string]interface{}{
+		"status":              status,
+		"gas":                 gasUsedAmount,
+		"gas_used":            gasUsedCalculated,
+		"cumulative_gas_used": cumulativeGasUsed,
+		"confirmed_at":        time.Now(),
+	}
+	if receipt.BlockNumber != nil {
+		updates["block_number"] = receipt.BlockNumber.Uint64()
+	}
+	if !blockTime.IsZero() {
+		updates["block_time"] = blockTime
+	}
+	return d.db.WithContext(ctx).Model(&models.Transaction{}).
+		Where("tx_hash = ?", txHash).
+		Updates(updates).Error
+}
+
+// MarkConfirmed records a transaction confirmed without a receipt, as Qi transactions are,
+// once mined in block blockNumber. blockTime is left unset when zero.
+func (d *TransactionDAL) MarkConfirmed(ctx context.Context, txHash string, blockNumber uint64, blockTime time.Time) error {
+	updates := map[string]interface{}{
+		"status":       models.Confirmed,
+		"confirmed_at": time.Now(),
+		"block_number": blockNumber,
+	}
+	if !blockTime.IsZero() {
+		updates["block_time"] = blockTime
+	}
+	return d.db.WithContext(ctx).Model(&models.Transaction{}).
+		Where("tx_hash = ?", txHash).
+		Updates(updates).Error
+}
+
+// MarkPending moves a transaction whose block was reorged away back to pending, clearing
+// its confirmation
+func (d *TransactionDAL) MarkPending(ctx context.Context, txHash string) error {
 	return d.db.WithContext(ctx).Model(&models.Transaction{}).
 		Where("tx_hash = ?", txHash).
 		Updates(map[string]interface{}{
-			"status":              receipt.Status,
-			"gas":                 gasUsedAmount,
-			"gas_used":            gasUsedCalculated,
-			"cumulative_gas_used": cumulativeGasUsed,
-			"confirmed_at":        time.Now(),
+			"status":       models.Generated,
+			"confirmed_at": nil,
+			"block_number": 0,
+			"block_time":   nil,
 		}).Error
 }
 
+// MarkDryRun marks a pending transaction as signed by a dry run. Only pending records are
+// touched, so a real transaction is never mistaken for one that wasn't broadcast.
+func (d *TransactionDAL) MarkDryRun(ctx context.Context, txHash string) error {
+	return d.db.WithContext(ctx).Model(&models.Transaction{}).
+		Where("tx_hash = ? AND status = ?", txHash, models.Generated).
+		Update("status", models.DryRun).Error
+}
+
+// DeleteDryRun deletes the record of a transaction signed by a dry run, so a real run can
+// record the entry again
+func (d *TransactionDAL) DeleteDryRun(ctx context.Context, txHash string) error {
+	return d.db.WithContext(ctx).
+		Where("tx_hash = ? AND status = ?", txHash, models.DryRun).
+		Delete(&models.Transaction{}).Error
+}
+
+// GetPendingByNonce returns the pending transaction of a payer at a nonce, or nil if there is none
+func (d *TransactionDAL) GetPendingByNonce(ctx context.Context, payer string, nonce uint64) (*models.Transaction, error) {
+	var tx models.Transaction
+	err := d.db.WithContext(ctx).
+		Where("payer = ? AND nonce = ? AND status = ?", payer, nonce, models.Generated).
+		First(&tx).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending transaction: %v", err)
+	}
+	return &tx, nil
+}
+
+// ListPendingByPayer returns the pending transactions sent from payer, lowest nonce first
+func (d *TransactionDAL) ListPendingByPayer(ctx context.Context, payer string) ([]*models.Transaction, error) {
+	var txs []*models.Transaction
+	err := d.db.WithContext(ctx).
+		Where("status = ? AND payer = ?", models.Generated, payer).
+		Order("nonce").
+		Find(&txs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending transactions: %v", err)
+	}
+	return txs, nil
+}
+
+// ListByIDs returns the transactions with the given IDs, ordered by ID
+func (d *TransactionDAL) ListByIDs(ctx context.Context, ids []int32) ([]*models.Transaction, error) {
+	const chunk = 1000
+	txs := make([]*models.Transaction, 0, len(ids))
+	for start := 0; start < len(ids); start += chunk {
+		end := min(start+chunk, len(ids))
+		var page []*models.Transaction
+		if err := d.db.WithContext(ctx).Where("id IN ?", ids[start:end]).Find(&page).Error; err != nil {
+			return nil, fmt.Errorf("failed to list transactions: %v", err)
+		}
+		txs = append(txs, page...)
+	}
+	sort.Slice(txs, func(i, j int) bool { return txs[i].ID < txs[j].ID })
+	return txs, nil
+}
+
+// ListByStatus returns a page of the transactions with the given status, oldest first.
+// A limit of zero or less returns every matching transaction from offset on.
+func (d *TransactionDAL) ListByStatus(ctx context.Context, status models.TxStatus, limit, offset int) ([]*models.Transaction, error) {
+	query := d.db.WithContext(ctx).
+		Where("status = ?", status).
+		Order("created_at").
+		Order("id").
+		Offset(offset)
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var txs []*models.Transaction
+	if err := query.Find(&txs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list transactions: %v", err)
+	}
+	return txs, nil
+}
+
+// CountByStatus counts the transactions with the given status
+func (d *TransactionDAL) CountByStatus(ctx context.Context, status models.TxStatus) (int64, error) {
+	var count int64
+	err := d.db.WithContext(ctx).Model(&models.Transaction{}).
+		Where("status = ?", status).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count transactions: %v", err)
+	}
+	return count, nil
+}
+
 // IsTransactionExist checks if a transaction exists by its ID
 func (d *TransactionDAL) IsTransactionExist(ctx context.Context, id int32) (bool, error) {
 	var tx models.Transaction
@@ -51,18 +185,189 @@ func (d *TransactionDAL) IsTransactionExist(ctx context.Context, id int32) (bool
 
 // GetTransactionByID retrieves a transaction by its ID
 func (d *TransactionDAL) GetTransactionByID(ctx context.Context, id int32) (*models.Transaction, error) {
-	var tx models.Transaction
-	result := d.db.WithContext(ctx).
-		Select("tx", "entry", "status").
-		Where("id = ?", id).
-		First(&tx)
+	return d.getTransaction(ctx, "id = ?", id)
+}
+
+// GetTransactionByIdempotencyKey retrieves a transaction by its idempotency key
+func (d *TransactionDAL) GetTransactionByIdempotencyKey(ctx context.Context, key string) (*models.Transaction, error) {
+	return d.getTransaction(ctx, "idempotency_key = ?", key)
+}
+
+// GetTransactionByHash retrieves a transaction by its hash
+func (d *TransactionDAL) GetTransactionByHash(ctx context.Context, txHash string) (*models.Transaction, error) {
+	return d.getTransaction(ctx, "tx_hash = ?", txHash)
+}
 
+// getTransaction looks a transaction up in the active table, then in the archive
+func (d *TransactionDAL) getTransaction(ctx context.Context, query string, args ...interface{}) (*models.Transaction, error) {
+	for _, table := range []string{(&models.Transaction{}).TableName(), (&models.ArchivedTransaction{}).TableName()} {
+		var tx models.Transaction
+		result := d.db.WithContext(ctx).
+			Table(table).
+			Select("id", "tx_hash", "tx", "entry", "status").
+			Where(query, args...).
+			First(&tx)
+
+		if result.Error != nil {
+			if result.Error == gorm.ErrRecordNotFound {
+				continue
+			}
+			return nil, fmt.Errorf("failed to get transaction: %v", result.Error)
+		}
+		return &tx, nil
+	}
+
+	return nil, nil // Return nil if no record found
+}
+
+// ReplaceTransaction points the pending record of a transaction at its replacement, a signed
+// transaction of the same nonce with another hash and gas price
+func (d *TransactionDAL) ReplaceTransaction(ctx context.Context, oldHash string, newHash string, gasPrice decimal.Decimal, txJSON string) error {
+	result := d.db.WithContext(ctx).Model(&models.Transaction{}).
+		Where("tx_hash = ? AND status = ?", oldHash, models.Generated).
+		Updates(map[string]interface{}{
+			"tx_hash":   newHash,
+			"gas_price": gasPrice,
+			"tx":        txJSON,
+		})
 	if result.Error != nil {
-		if result.Error == gorm.ErrRecordNotFound {
-			return nil, nil // Return nil if no record found
+		return fmt.Errorf("failed to replace transaction: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("no pending record of transaction %s", oldHash)
+	}
+	return nil
+}
+
+// RecordBroadcastFailure increments the retry count of a transaction and stores its last error
+func (d *TransactionDAL) RecordBroadcastFailure(ctx context.Context, txHash string, lastErr string) error {
+	return d.db.WithContext(ctx).Model(&models.Transaction{}).
+		Where("tx_hash = ?", txHash).
+		Updates(map[string]interface{}{
+			"retry_count": gorm.Expr("retry_count + 1"),
+			"last_error":  lastErr,
+		}).Error
+}
+
+// RecordBroadcastHeight stores the head block number at the time a transaction was broadcast
+func (d *TransactionDAL) RecordBroadcastHeight(ctx context.Context, txHash string, height uint64) error {
+	return d.db.WithContext(ctx).Model(&models.Transaction{}).
+		Where("tx_hash = ?", txHash).
+		Update("broadcast_height", height).Error
+}
+
+// MarkDeadLetter moves a transaction to the dead-letter status and stores why it failed
+func (d *TransactionDAL) MarkDeadLetter(ctx context.Context, txHash string, failureCode string, failureReason string) error {
+	return d.db.WithContext(ctx).Model(&models.Transaction{}).
+		Where("tx_hash = ?", txHash).
+		Updates(map[string]interface{}{
+			"status":         models.DeadLetter,
+			"failure_code":   failureCode,
+			"failure_reason": failureReason,
+		}).Error
+}
+
+// ListDeadLetters returns the dead-lettered transactions, oldest first
+func (d *TransactionDAL) ListDeadLetters(ctx context.Context) ([]*models.Transaction, error) {
+	var txs []*models.Transaction
+	err := d.db.WithContext(ctx).
+		Where("status = ?", models.DeadLetter).
+		Order("id").
+		Find(&txs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %v", err)
+	}
+	return txs, nil
+}
+
+// RequeueDeadLetters moves dead-lettered transactions back to pending so the next run
+// broadcasts them again. The records of dead letters with a failure code in spentNonceCodes,
+// whose nonce another transaction took, are deleted instead: their signed transaction can
+// never be mined, so the next run signs their entry anew with a fresh nonce. All dead
+// letters are requeued when no IDs are given.
+func (d *TransactionDAL) RequeueDeadLetters(ctx context.Context, spentNonceCodes []string, ids ...int32) (int64, error) {
+	var requeued int64
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		deadLetters := func() *gorm.DB {
+			query := tx.Model(&models.Transaction{}).Where("status = ?", models.DeadLetter)
+			if len(ids) > 0 {
+				query = query.Where("id IN ?", ids)
+			}
+			return query
+		}
+
+		if len(spentNonceCodes) > 0 {
+			result := deadLetters().Where("failure_code IN ?", spentNonceCodes).Delete(&models.Transaction{})
+			if result.Error != nil {
+				return result.Error
+			}
+			requeued += result.RowsAffected
 		}
-		return nil, fmt.Errorf("failed to get transaction: %v", result.Error)
+		result := deadLetters().Updates(map[string]interface{}{
+			"status":         models.Generated,
+			"retry_count":    0,
+			"failure_code":   "",
+			"failure_reason": "",
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		requeued += result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue dead letters: %v", err)
 	}
+	return requeued, nil
+}
 
-	return &tx, nil
+// CountConfirmedBefore counts the confirmed transactions confirmed before the given time
+func (d *TransactionDAL) CountConfirmedBefore(ctx context.Context, before time.Time) (int64, error) {
+	var count int64
+	err := d.db.WithContext(ctx).Model(&models.Transaction{}).
+		Where("status = ? AND confirmed_at < ?", models.Confirmed, before).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count confirmed transactions: %v", err)
+	}
+	return count, nil
+}
+
+// ArchiveConfirmedBefore moves the transactions confirmed before the given time to the archive
+// table and returns how many were moved. Rows that are not confirmed are never touched.
+func (d *TransactionDAL) ArchiveConfirmedBefore(ctx context.Context, before time.Time) (int64, error) {
+	var archived int64
+	err := d.db.WithContext(ctx).Transaction(func(db *gorm.DB) error {
+		var txs []models.Transaction
+		if err := db.Where("status = ? AND confirmed_at < ?", models.Confirmed, before).Find(&txs).Error; err != nil {
+			return err
+		}
+		if len(txs) == 0 {
+			return nil
+		}
+
+		rows := make([]models.ArchivedTransaction, len(txs))
+		ids := make([]int32, len(txs))
+		for i := range txs {
+			rows[i] = models.ArchivedTransaction{Transaction: txs[i]}
+			ids[i] = txs[i].ID
+		}
+		if err := db.CreateInBatches(rows, 500).Error; err != nil {
+			return err
+		}
+
+		const chunk = 1000
+		for start := 0; start < len(ids); start += chunk {
+			end := min(start+chunk, len(ids))
+			if err := db.Where("status = ? AND id IN ?", models.Confirmed, ids[start:end]).Delete(&models.Transaction{}).Error; err != nil {
+				return err
+			}
+		}
+		archived = int64(len(txs))
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive confirmed transactions: %v", err)
+	}
+	return archived, nil
 }
@@ -0,0 +1,140 @@
+package eventlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types recorded during a batch run
+const (
+	RunStarted    = "run_started"
+	EntriesLoaded = "entries_loaded"
+	NonceAssigned = "nonce_assigned"
+	TxSigned      = "tx_signed"
+	TxBroadcast   = "tx_broadcast"
+	TxConfirmed   = "tx_confirmed"
+	TxAbandoned   = "tx_abandoned"
+	BatchSummary  = "batch_summary"
+)
+
+// Event is a single line of the event log
+type Event struct {
+	Time    time.Time      `json:"time"`
+	Type    string         `json:"type"`
+	EntryID int32          `json:"entry_id,omitempty"`
+	TxHash  string         `json:"tx_hash,omitempty"`
+	Nonce   *uint64        `json:"nonce,omitempty"`
+	Error   string         `json:"error,omitempty"`
+	Data    map[string]any `json:"data,omitempty"`
+}
+
+// Log is an append-only JSON lines event stream. A nil *Log discards all events.
+type Log struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+// Open opens (or creates) the event log at path for appending
+func Open(path string) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create event log directory: %v", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event log: %v", err)
+	}
+	return &Log{file: file, enc: json.NewEncoder(file)}, nil
+}
+
+// Append writes an event to the log, stamping it with the current time if unset
+func (l *Log) Append(e Event) {
+	if l == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.enc.Encode(e); err != nil {
+		log.Printf("failed to append %s event to event log: %v", e.Type, err)
+	}
+}
+
+// Close closes the underlying file
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+	return l.file.Close()
+}
+
+// Read loads all events from the log at path
+func Read(path string) ([]Event, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event log: %w", err)
+	}
+	defer file.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("failed to parse event log line %d: %w", line, err)
+		}
+		events = append(events, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read event log: %w", err)
+	}
+	return events, nil
+}
+
+// Format renders an event as a single human-readable timeline line
+func Format(e Event) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s  %-15s", e.Time.Format("2006-01-02 15:04:05.000"), e.Type)
+	if e.EntryID != 0 {
+		fmt.Fprintf(&b, " entry=%d", e.EntryID)
+	}
+	if e.Nonce != nil {
+		fmt.Fprintf(&b, " nonce=%d", *e.Nonce)
+	}
+	if e.TxHash != "" {
+		fmt.Fprintf(&b, " hash=%s", e.TxHash)
+	}
+
+	keys := make([]string, 0, len(e.Data))
+	for k := range e.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v, err := json.Marshal(e.Data[k])
+		if err != nil {
+			v = []byte(fmt.Sprint(e.Data[k]))
+		}
+		fmt.Fprintf(&b, " %s=%s", k, v)
+	}
+
+	if e.Error != "" {
+		fmt.Fprintf(&b, " error=%q", e.Error)
+	}
+	return b.String()
+}
@@ -0,0 +1,96 @@
+package keystore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dominant-strategies/go-quai/common"
+)
+
+// keyBackupPrefix starts every key backup payload, with the version of its format
+const keyBackupPrefix = "QUAIKEY1"
+
+// ErrBackupChecksum is returned for a key backup whose checksum doesn't match its key, as
+// when a QR code was misread
+var ErrBackupChecksum = errors.New("key backup checksum mismatch")
+
+// EncodeKeyBackup wraps encrypted key JSON for a cold backup, such as a QR code, as
+// QUAIKEY1:<checksum>:<key JSON>. The checksum is the first 4 bytes of the SHA-256 of the
+// JSON, in hex. The key stays encrypted.
+func EncodeKeyBackup(keyjson []byte) string {
+	sum := sha256.Sum256(keyjson)
+	return fmt.Sprintf("%s:%x:%s", keyBackupPrefix, sum[:4], keyjson)
+}
+
+// DecodeKeyBackup returns the encrypted key JSON of a payload of EncodeKeyBackup, once its
+// checksum has been verified
+func DecodeKeyBackup(payload string) ([]byte, error) {
+	parts := strings.SplitN(strings.TrimSpace(payload), ":", 3)
+	if len(parts) != 3 || parts[0] != keyBackupPrefix {
+		return nil, fmt.Errorf("not a key backup: expected a payload starting with %s:", keyBackupPrefix)
+	}
+	keyjson := []byte(parts[2])
+	sum := sha256.Sum256(keyjson)
+	if !strings.EqualFold(parts[1], hex.EncodeToString(sum[:4])) {
+		return nil, fmt.Errorf("%w: have %s, want %x", ErrBackupChecksum, parts[1], sum[:4])
+	}
+	return keyjson, nil
+}
+
+// ExportBackup re-encrypts the key of addr with newPassphrase, as Export does, checks that the
+// result decrypts with it, and returns it as a payload of EncodeKeyBackup
+func (k *KeyManager) ExportBackup(addr common.Address, passphrase, newPassphrase string) (string, error) {
+	name, err := k.keyFileOf(addr)
+	if err != nil {
+		return "", err
+	}
+	keyjson, err := k.Export(Account{Address: addr, URL: URL{Scheme: KeyStoreScheme, Path: name}}, passphrase, newPassphrase)
+	if err != nil {
+		return "", err
+	}
+	// A cold backup is only read once it is needed, too late to find it broken
+	check, err := DecryptKey(keyjson, newPassphrase)
+	if err != nil {
+		return "", fmt.Errorf("failed to verify the exported key: %w", err)
+	}
+	zeroKey(check.PrivateKey)
+	if !check.Address.Equal(addr) {
+		return "", fmt.Errorf("key content mismatch: have account %x, want %x", check.Address, addr)
+	}
+	return EncodeKeyBackup(keyjson), nil
+}
+
+// ImportBackup stores the key of a payload of EncodeKeyBackup as it is, still encrypted with
+// the password it was exported with. A key already in the keystore is left alone and
+// ErrAccountAlreadyExists returned.
+func (k *KeyManager) ImportBackup(payload string) (common.Address, error) {
+	keyjson, err := DecodeKeyBackup(payload)
+	if err != nil {
+		return common.Address{}, err
+	}
+	var key encryptedKeyJSONV3
+	if err := json.Unmarshal(keyjson, &key); err != nil {
+		return common.Address{}, fmt.Errorf("invalid key in backup: %w", err)
+	}
+	if key.Crypto.CipherText == "" {
+		return common.Address{}, errors.New("invalid key in backup: not an encrypted key")
+	}
+	address, err := addressOfKeyJSON(keyjson)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	if _, err := k.keyFilesOf(address); err == nil {
+		return address, fmt.Errorf("%w: %s", ErrAccountAlreadyExists, address.Hex())
+	} else if !errors.Is(err, ErrNoMatch) {
+		return address, err
+	}
+	if err := k.storage.WriteKey(k.storage.JoinPath(keyFileName(address)), keyjson); err != nil {
+		return address, fmt.Errorf("failed to store key: %w", err)
+	}
+	return address, nil
+}
@@ -0,0 +1,99 @@
+package keystore
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dominant-strategies/go-quai/common"
+)
+
+// newTestKeyManager returns a KeyManager over a temporary directory, with light scrypt parameters
+func newTestKeyManager(t *testing.T) *KeyManager {
+	t.Helper()
+	return &KeyManager{storage: NewKeyStore(t.TempDir(), LightScryptN, LightScryptP)}
+}
+
+// testBackup exports the backup of testQuaiKey, encrypted with "backup"
+func testBackup(t *testing.T) (string, common.Address) {
+	t.Helper()
+	km := newTestKeyManager(t)
+	key := testKey(t, testQuaiKey)
+	address := key.Address
+	if _, err := storeKey(km.storage, key, "password", common.Location{0, 0}, "quai"); err != nil {
+		t.Fatal(err)
+	}
+	payload, err := km.ExportBackup(address, "password", "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payload, address
+}
+
+func TestBackupRoundTrip(t *testing.T) {
+	payload, address := testBackup(t)
+	if !strings.HasPrefix(payload, keyBackupPrefix+":") {
+		t.Fatalf("payload %.20q..., want it to start with %s:", payload, keyBackupPrefix)
+	}
+
+	km := newTestKeyManager(t)
+	imported, err := km.ImportBackup(payload + "\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !imported.Equal(address) {
+		t.Fatalf("imported %s, want %s", imported.Hex(), address.Hex())
+	}
+	name, err := km.keyFileOf(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := km.GetKey(address, name, "password"); err == nil {
+		t.Error("imported key decrypts with the keystore password, want the backup one")
+	}
+	key, err := km.GetKey(address, name, "backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := testKey(t, testQuaiKey); !key.PrivateKey.Equal(want.PrivateKey) {
+		t.Error("imported key differs from the exported one")
+	}
+
+	if _, err := km.ImportBackup(payload); !errors.Is(err, ErrAccountAlreadyExists) {
+		t.Errorf("second import: error %v, want %v", err, ErrAccountAlreadyExists)
+	}
+}
+
+func TestBackupChecksumMismatch(t *testing.T) {
+	payload, _ := testBackup(t)
+	parts := strings.SplitN(payload, ":", 3)
+	flip := func(s string, i int) string {
+		c := byte('0')
+		if s[i] == c {
+			c = '1'
+		}
+		return s[:i] + string(c) + s[i+1:]
+	}
+	tests := []struct {
+		name    string
+		payload string
+	}{
+		{name: "key changed", payload: parts[0] + ":" + parts[1] + ":" + strings.Replace(parts[2], `"ciphertext":"`, `"ciphertext":"0`, 1)},
+		{name: "checksum changed", payload: parts[0] + ":" + flip(parts[1], 0) + ":" + parts[2]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			km := newTestKeyManager(t)
+			if _, err := km.ImportBackup(tt.payload); !errors.Is(err, ErrBackupChecksum) {
+				t.Fatalf("error %v, want %v", err, ErrBackupChecksum)
+			}
+			if names, err := km.storage.ListKeys(); err != nil || len(names) > 0 {
+				t.Errorf("keys %v stored from a corrupt backup (%v)", names, err)
+			}
+		})
+	}
+
+	if _, err := DecodeKeyBackup("QUAIKEY2:00000000:{}"); err == nil || errors.Is(err, ErrBackupChecksum) {
+		t.Errorf("payload of another format: error %v, want it rejected as not a backup", err)
+	}
+}
@@ -0,0 +1,34 @@
+package keystore
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dominant-strategies/go-quai/common"
+)
+
+// DeleteAccount removes the key of addr from the store once passphrase has been checked to
+// decrypt it, so a key can't be deleted by mistake. More than one file matching the address
+// is an error, as it isn't clear which one is meant; nothing is deleted then.
+func (k *KeyManager) DeleteAccount(addr common.Address, passphrase string) error {
+	names, err := k.keyFilesOf(addr)
+	if err != nil {
+		return err
+	}
+	if len(names) > 1 {
+		return fmt.Errorf("%d key files match address %x, remove the extra ones by hand: %s",
+			len(names), addr, strings.Join(names, ", "))
+	}
+	name := names[0]
+
+	key, err := k.GetKey(addr, name, passphrase)
+	if err != nil {
+		return err
+	}
+	zeroKey(key.PrivateKey)
+
+	if err := k.storage.DeleteKey(name); err != nil {
+		return fmt.Errorf("failed to delete key %s: %w", name, err)
+	}
+	return nil
+}
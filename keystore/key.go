@@ -3,10 +3,13 @@ package keystore
 import (
 	"crypto/ecdsa"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/dominant-strategies/go-quai/common"
@@ -98,42 +101,111 @@ func writeTemporaryKeyFile(file string, content []byte) (string, error) {
 	return f.Name(), nil
 }
 
-// storeNewKey creates a new key and stores it in the keystore.
-// todo Quai and Qi have different address formats
-func storeNewKey(ks keyStore, rand io.Reader, auth string, location common.Location, protocol string) (*Key, Account, error) {
-	key, err := newKey(rand, location)
-	if err != nil {
-		return nil, Account{}, err
-	}
-
-	for {
-		firstByte := key.Address.Bytes()[0]
-		region := firstByte & 0x0F      // Get bits 0-3 for region
-		zone := (firstByte >> 4) & 0x0F // Get bits 4-7 for zone
-		if int(region) == location.Region() && int(zone) == location.Zone() {
-			if protocol == "quai" && key.Address.Bytes()[1] <= 127 {
-				break
-			} else if protocol == "qi" && key.Address.Bytes()[1] > 127 {
-				break
-			}
-		}
+// keyProgressInterval is how many attempts pass between progress logs of storeNewKey in debug mode
+const keyProgressInterval = 10000
+
+// storeNewKey creates a new key whose address is in the scope of location and of the
+// protocol's ledger, and stores it in the keystore. Random keys are generated until one is in
+// scope, at most maxAttempts of them; with debug set, progress is logged as they are tried.
+func storeNewKey(ks keyStore, rand io.Reader, auth string, location common.Location, protocol string, maxAttempts int, debug bool) (*Key, Account, error) {
+	if protocol != "quai" && protocol != "qi" {
+		return nil, Account{}, fmt.Errorf("invalid protocol: %s. Must be either 'quai' or 'qi'", protocol)
+	}
 
-		// 如果上面没有生成符合location的地址，则重试
-		key, err = newKey(rand, location)
+	var key *Key
+	for attempt := 1; ; attempt++ {
+		if attempt > maxAttempts {
+			return nil, Account{}, fmt.Errorf("no address in the %s ledger of location %v after %d attempts, check the location or raise key_max_attempts",
+				protocol, location, maxAttempts)
+		}
+		candidate, err := newKey(rand, location)
 		if err != nil {
 			return nil, Account{}, err
 		}
+		if isInScope(candidate.Address, location, protocol) {
+			key = candidate
+			break
+		}
+		zeroKey(candidate.PrivateKey)
+		if debug && attempt%keyProgressInterval == 0 {
+			log.Printf("🔑 KEY SEARCH | %d of %d attempts, no address in the %s ledger of location %v yet", attempt, maxAttempts, protocol, location)
+		}
+	}
+	a, err := storeKey(ks, key, auth, location, protocol)
+	if err != nil {
+		return nil, a, err
 	}
+	return key, a, nil
+}
+
+// storeKey encrypts key into the keystore and checks that the stored file holds its address,
+// in the scope of location and of the protocol's ledger
+func storeKey(ks keyStore, key *Key, auth string, location common.Location, protocol string) (Account, error) {
 	a := Account{
 		Address: key.Address,
 		URL:     URL{Scheme: KeyStoreScheme, Path: ks.JoinPath(keyFileName(key.Address))},
 	}
-	fmt.Println(hex.EncodeToString(crypto.FromECDSA(key.PrivateKey)))
 	if err := ks.StoreKey(a.URL.Path, key, auth); err != nil {
 		zeroKey(key.PrivateKey)
-		return nil, a, err
+		return a, err
+	}
+
+	// Make sure the key file on disk holds an address in the requested scope, and remove it
+	// otherwise so it isn't listed or matched later
+	stored, err := storedAddress(ks, a.URL.Path)
+	if err != nil {
+		err = fmt.Errorf("failed to verify stored key: %v", err)
+	} else if !stored.Equal(key.Address) || !isInScope(stored, location, protocol) {
+		err = fmt.Errorf("stored key %x is not in %s ledger scope of location %v", stored.Bytes(), protocol, location)
+	}
+	if err != nil {
+		if removeErr := ks.DeleteKey(a.URL.Path); removeErr != nil {
+			return a, fmt.Errorf("%v, and failed to remove it: %v", err, removeErr)
+		}
+		return a, err
+	}
+	return a, nil
+}
+
+// isInScope reports whether addr is in the chain scope of location and in the ledger of
+// protocol. The first bit of the second byte is set for Qi addresses and not set for Quai.
+func isInScope(addr common.Address, location common.Location, protocol string) bool {
+	addrBytes := addr.Bytes()
+	if !common.IsInChainScope(addrBytes, location) {
+		return false
+	}
+	switch protocol {
+	case "quai":
+		return addrBytes[1] <= 127
+	case "qi":
+		return addrBytes[1] > 127
+	default:
+		return false
+	}
+}
+
+// storedAddress reads the address field of a stored key without decrypting it
+func storedAddress(ks keyStore, filename string) (common.Address, error) {
+	keyjson, err := ks.ReadKey(filename)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return addressOfKeyJSON(keyjson)
+}
+
+// addressOfKeyJSON reads the address field of encrypted key JSON without decrypting it
+func addressOfKeyJSON(keyjson []byte) (common.Address, error) {
+	var k struct {
+		Address string `json:"address"`
+	}
+	if err := json.Unmarshal(keyjson, &k); err != nil {
+		return common.Address{}, err
+	}
+	addrBytes, err := hex.DecodeString(strings.TrimPrefix(k.Address, "0x"))
+	if err != nil || len(addrBytes) != common.AddressLength {
+		return common.Address{}, fmt.Errorf("invalid address %q in key file", k.Address)
 	}
-	return key, a, err
+	return common.BytesToAddress(addrBytes, common.LocationFromAddressBytes(addrBytes)), nil
 }
 
 func newKey(rand io.Reader, location common.Location) (*Key, error) {
@@ -0,0 +1,159 @@
+package keystore
+
+import (
+	crand "crypto/rand"
+	"encoding/hex"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/crypto"
+	"github.com/google/uuid"
+)
+
+// Keys of zone 0-0: the Keccak hashes of "quai-transfer signing vector 0" and of
+// "quai-transfer qi key 1486", whose addresses are in the Quai and the Qi ledger
+const (
+	testQuaiKey = "13221fe46bde6a5de07d45248101760b6e32ccd6d36e97ae6950ba95298e4da6"
+	testQiKey   = "5d07b13427dae1bea979be99b9002e8d1bc9b6882912b596b657f99ceb07d6a0"
+)
+
+func testKey(t *testing.T, hexKey string) *Key {
+	t.Helper()
+	privateKey, err := crypto.HexToECDSA(hexKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Key{
+		Id:         uuid.New(),
+		Address:    crypto.PubkeyToAddress(privateKey.PublicKey, common.Location{0, 0}),
+		PrivateKey: privateKey,
+	}
+}
+
+func TestIsInScope(t *testing.T) {
+	zone00 := common.Location{0, 0}
+	tests := []struct {
+		name     string
+		address  string
+		location common.Location
+		protocol string
+		want     bool
+	}{
+		{name: "quai address, quai scope", address: "0x001c985AC09F71218bF38b60D52cedf2Dfbb72DD", location: zone00, protocol: "quai", want: true},
+		{name: "qi address, qi scope", address: "0x00b4a4fcB624F3F86CF918Ec7E0a42815A310D57", location: zone00, protocol: "qi", want: true},
+		{name: "qi address, quai scope", address: "0x00b4a4fcB624F3F86CF918Ec7E0a42815A310D57", location: zone00, protocol: "quai"},
+		{name: "quai address, qi scope", address: "0x001c985AC09F71218bF38b60D52cedf2Dfbb72DD", location: zone00, protocol: "qi"},
+		{name: "highest quai byte", address: "0x007f000000000000000000000000000000000000", location: zone00, protocol: "quai", want: true},
+		{name: "lowest qi byte", address: "0x0080000000000000000000000000000000000000", location: zone00, protocol: "qi", want: true},
+		{name: "other zone", address: "0x1076b67a0cfC64Cfe3798670427215D759494167", location: zone00, protocol: "quai"},
+		{name: "its own zone", address: "0x1076b67a0cfC64Cfe3798670427215D759494167", location: common.Location{1, 0}, protocol: "quai", want: true},
+		{name: "unknown protocol", address: "0x001c985AC09F71218bF38b60D52cedf2Dfbb72DD", location: zone00, protocol: "eth"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr := common.HexToAddress(tt.address, tt.location)
+			if got := isInScope(addr, tt.location, tt.protocol); got != tt.want {
+				t.Errorf("isInScope(%s, %v, %s) = %t, want %t", tt.address, tt.location, tt.protocol, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStoreKeyScope(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      string
+		protocol string
+		wantErr  bool
+	}{
+		{name: "quai key, quai scope", key: testQuaiKey, protocol: "quai"},
+		{name: "qi key, qi scope", key: testQiKey, protocol: "qi"},
+		{name: "quai key, qi scope", key: testQuaiKey, protocol: "qi", wantErr: true},
+		{name: "qi key, quai scope", key: testQiKey, protocol: "quai", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			ks := NewKeyStore(dir, LightScryptN, LightScryptP)
+			key := testKey(t, tt.key)
+			address := key.Address
+			account, err := storeKey(ks, key, "password", common.Location{0, 0}, tt.protocol)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "ledger scope") {
+					t.Fatalf("error %v, want a ledger scope error", err)
+				}
+				files, err := os.ReadDir(dir)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(files) > 0 {
+					t.Errorf("key file %s left behind", files[0].Name())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stored, err := storedAddress(ks, account.URL.Path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !stored.Equal(address) {
+				t.Errorf("stored address %s, want %s", stored.Hex(), address.Hex())
+			}
+		})
+	}
+}
+
+func TestStoredAddressInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		keyjson string
+	}{
+		{name: "not JSON", keyjson: "not a key"},
+		{name: "no address", keyjson: `{"version":3}`},
+		{name: "short address", keyjson: `{"address":"001c985ac09f71218bf38b60d52cedf2dfbb72"}`},
+		{name: "not hex", keyjson: `{"address":"zz1c985ac09f71218bf38b60d52cedf2dfbb72dd"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks := NewKeyStore(t.TempDir(), LightScryptN, LightScryptP)
+			path := ks.JoinPath("key.json")
+			if err := os.WriteFile(path, []byte(tt.keyjson), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if address, err := storedAddress(ks, path); err == nil {
+				t.Errorf("address %s read, want an error", address.Hex())
+			}
+		})
+	}
+}
+
+func TestStoreNewKeyKeepsKeyOffStdout(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	ks := NewKeyStore(t.TempDir(), LightScryptN, LightScryptP)
+	key, _, err := storeNewKey(ks, crand.Reader, "password", common.Location{0, 0}, "quai", 100_000, false)
+	os.Stdout = stdout
+	w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if privateKey := hex.EncodeToString(crypto.FromECDSA(key.PrivateKey)); strings.Contains(string(out), privateKey) {
+		t.Error("private key written to stdout")
+	}
+}
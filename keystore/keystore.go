@@ -1,6 +1,7 @@
 package keystore
 
 import (
+	"os"
 	"path/filepath"
 
 	"github.com/dominant-strategies/go-quai/common"
@@ -13,6 +14,14 @@ type keyStore interface {
 	StoreKey(filename string, k *Key, auth string) error
 	// JoinPath Joins filename with the key directory unless it is already absolute.
 	JoinPath(filename string) string
+	// ReadKey Returns the stored key JSON as is, without decrypting it.
+	ReadKey(filename string) ([]byte, error)
+	// ListKeys Returns the names of the stored keys, ready to pass to GetKey.
+	ListKeys() ([]string, error)
+	// WriteKey Atomically replaces a stored key with already encrypted key JSON.
+	WriteKey(filename string, keyjson []byte) error
+	// DeleteKey Removes a stored key.
+	DeleteKey(filename string) error
 }
 
 func NewKeyStore(keydir string, scryptN, scryptP int) keyStore {
@@ -20,3 +29,19 @@ func NewKeyStore(keydir string, scryptN, scryptP int) keyStore {
 	ks := &keyStorePassphrase{keydir, scryptN, scryptP, false}
 	return ks
 }
+
+// listKeyFiles returns the paths of the files in a key directory
+func listKeyFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() || entry.Name()[0] == '.' {
+			continue
+		}
+		files = append(files, filepath.Join(dir, entry.Name()))
+	}
+	return files, nil
+}
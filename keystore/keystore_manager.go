@@ -10,6 +10,8 @@ import (
 	"path/filepath"
 	"strings"
 
+	"quai-transfer/config"
+
 	"github.com/dominant-strategies/go-quai/common"
 	"github.com/dominant-strategies/go-quai/crypto"
 	"github.com/google/uuid"
@@ -32,7 +34,9 @@ const KeyStoreScheme = "keystore"
 // KeyManager manages the creation, storage and loading of private keys
 type KeyManager struct {
 	storage keyStore // Storage backend, might be cleartext or encrypted
-	keyDir  string
+
+	maxKeyAttempts int  // random keys tried for a new key, DefaultKeyMaxAttempts when zero
+	debug          bool // log the progress of the key search
 }
 
 var _ KeyStoreManager = (*KeyManager)(nil)
@@ -50,14 +54,28 @@ func NewKeyManager(keyDir string) (*KeyManager, error) {
 
 	return &KeyManager{
 		storage: ks,
-		keyDir:  keyDir,
 	}, nil
 }
 
+// NewSecretKeyManager creates a KeyManager that keeps its encrypted keys in a SecretStore.
+// Key files are then referred to by their secret name.
+func NewSecretKeyManager(store SecretStore) *KeyManager {
+	return &KeyManager{
+		storage: keyStoreSecret{store: store, scryptN: StandardScryptN, scryptP: StandardScryptP},
+	}
+}
+
+// SetKeyGeneration sets how many random keys NewAccount tries before giving up, and whether
+// it logs its progress
+func (k *KeyManager) SetKeyGeneration(maxAttempts int, debug bool) {
+	k.maxKeyAttempts = maxAttempts
+	k.debug = debug
+}
+
 // CreateNewKey creates a new private key and stores it encrypted
 func (k *KeyManager) CreateNewKey(location common.Location, protocol string) (common.Address, error) {
 	// Get password with confirmation
-	password, err := promptAndConfirmPassword("Enter password for new key: ")
+	password, err := PromptAndConfirmPassword("Enter password for new key: ")
 	if err != nil {
 		return common.Address{}, err
 	}
@@ -74,13 +92,13 @@ func (k *KeyManager) CreateNewKey(location common.Location, protocol string) (co
 // LoadFile loads a private key from a keystore file
 func (k *KeyManager) LoadFile(keyFile string) (*Key, error) {
 	// Read key file content
-	keyjson, err := os.ReadFile(keyFile)
+	keyjson, err := k.storage.ReadKey(keyFile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read key file: %v", err)
 	}
 
 	// Read password
-	password, err := readPassword("Enter password to decrypt key: ")
+	password, err := ReadPassword("Enter password to decrypt key: ")
 	if err != nil {
 		return nil, err
 	}
@@ -97,27 +115,15 @@ func (k *KeyManager) LoadFile(keyFile string) (*Key, error) {
 // LoadKey loads a private key from the keystore
 func (k *KeyManager) LoadKey(address common.Address) (*Key, error) {
 	// Read password
-	password, err := readPassword("Enter password to decrypt key: ")
+	password, err := ReadPassword("Enter password to decrypt key: ")
 	if err != nil {
 		return nil, err
 	}
 
-	// Find key file with matching address prefix
-	files, err := os.ReadDir(k.keyDir)
+	keyFile, err := k.keyFileOf(address)
 	if err != nil {
 		return nil, err
 	}
-	addrHex := hex.EncodeToString(address.Bytes()[:])
-	var keyFile string
-	for _, file := range files {
-		if !file.IsDir() && strings.HasPrefix(file.Name(), addrHex) {
-			keyFile = filepath.Join(k.keyDir, file.Name())
-			break
-		}
-	}
-	if keyFile == "" {
-		return nil, fmt.Errorf("key file not found for address %x", address)
-	}
 
 	// Get decrypted key
 	key, err := k.GetKey(address, keyFile, password)
@@ -128,8 +134,36 @@ func (k *KeyManager) LoadKey(address common.Address) (*Key, error) {
 	return key, nil
 }
 
-// readPassword securely reads a password
-func readPassword(prompt string) (string, error) {
+// keyFileOf finds the key file of an address by its file name prefix
+func (k *KeyManager) keyFileOf(address common.Address) (string, error) {
+	files, err := k.keyFilesOf(address)
+	if err != nil {
+		return "", err
+	}
+	return files[0], nil
+}
+
+// keyFilesOf returns every key file whose name starts with the address, at least one
+func (k *KeyManager) keyFilesOf(address common.Address) ([]string, error) {
+	files, err := k.storage.ListKeys()
+	if err != nil {
+		return nil, err
+	}
+	addrHex := hex.EncodeToString(address.Bytes()[:])
+	var matches []string
+	for _, file := range files {
+		if strings.HasPrefix(filepath.Base(file), addrHex) {
+			matches = append(matches, file)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("key file not found for address %x: %w", address, ErrNoMatch)
+	}
+	return matches, nil
+}
+
+// ReadPassword securely reads a password without echoing it
+func ReadPassword(prompt string) (string, error) {
 	fmt.Print(prompt)
 	bytePassword, err := term.ReadPassword(int(os.Stdin.Fd()))
 	fmt.Println() // New line
@@ -140,20 +174,7 @@ func readPassword(prompt string) (string, error) {
 }
 
 func (k *KeyManager) GetKey(addr common.Address, filename, auth string) (*Key, error) {
-	// Load the key from the keystore and decrypt its contents
-	keyjson, err := os.ReadFile(filename)
-	if err != nil {
-		return nil, err
-	}
-	key, err := DecryptKey(keyjson, auth)
-	if err != nil {
-		return nil, err
-	}
-	// Make sure we're really operating on the requested key (no swap attacks)
-	if !key.Address.Equal(addr) {
-		return nil, fmt.Errorf("key content mismatch: have account %x, want %x", key.Address, addr)
-	}
-	return key, nil
+	return k.storage.GetKey(addr, filename, auth)
 }
 
 // Export exports as a JSON key, encrypted with newPassphrase.
@@ -162,15 +183,22 @@ func (k *KeyManager) Export(a Account, passphrase, newPassphrase string) (keyJSO
 	if err != nil {
 		return nil, err
 	}
-	var N, P int
-	if store, ok := k.storage.(*keyStorePassphrase); ok {
-		N, P = store.scryptN, store.scryptP
-	} else {
-		N, P = StandardScryptN, StandardScryptP
-	}
+	N, P := k.scryptParams()
 	return EncryptKey(key, newPassphrase, N, P)
 }
 
+// scryptParams returns the scrypt parameters the storage backend encrypts new keys with
+func (k *KeyManager) scryptParams() (int, int) {
+	switch store := k.storage.(type) {
+	case *keyStorePassphrase:
+		return store.scryptN, store.scryptP
+	case keyStoreSecret:
+		return store.scryptN, store.scryptP
+	default:
+		return StandardScryptN, StandardScryptP
+	}
+}
+
 func (k *KeyManager) getDecryptedKey(a Account, auth string) (*Key, error) {
 	key, err := k.GetKey(a.Address, a.URL.Path, auth)
 	return key, err
@@ -185,13 +213,37 @@ func zeroKey(k *ecdsa.PrivateKey) {
 // NewAccount generates a new key and stores it into the key directory,
 // encrypting it with the passphrase.
 func (k *KeyManager) NewAccount(passphrase string, location common.Location, protocol string) (Account, error) {
-	_, account, err := storeNewKey(k.storage, crand.Reader, passphrase, location, protocol)
+	maxAttempts := k.maxKeyAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = config.DefaultKeyMaxAttempts
+	}
+	_, account, err := storeNewKey(k.storage, crand.Reader, passphrase, location, protocol, maxAttempts, k.debug)
 	if err != nil {
 		return Account{}, err
 	}
 	return account, nil
 }
 
+// CreateBatch generates count keys of location and protocol, all encrypted with passphrase, and
+// returns the addresses of those stored. A key that fails to be stored doesn't stop the batch;
+// the failures are returned together once every key has been tried.
+func (k *KeyManager) CreateBatch(count int, location common.Location, protocol, passphrase string) ([]common.Address, error) {
+	if count < 1 {
+		return nil, fmt.Errorf("invalid count %d, must be at least 1", count)
+	}
+	addresses := make([]common.Address, 0, count)
+	var errs []error
+	for i := 0; i < count; i++ {
+		account, err := k.NewAccount(passphrase, location, protocol)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("key %d of %d: %w", i+1, count, err))
+			continue
+		}
+		addresses = append(addresses, account.Address)
+	}
+	return addresses, errors.Join(errs...)
+}
+
 // NewAuthNeededError creates a new authentication error with the extra details
 // about the needed fields set.
 func NewAuthNeededError(needed string) error {
@@ -217,7 +269,7 @@ type AuthNeededError struct {
 // ImportPrivateKey imports a private key from a hex string and stores it encrypted
 func (k *KeyManager) ImportPrivateKey() (common.Address, error) {
 	// Read private key with hidden input
-	privateKeyStr, err := readPassword("Enter private key (hex format): ")
+	privateKeyStr, err := ReadPassword("Enter private key (hex format): ")
 	if err != nil {
 		return common.Address{}, err
 	}
@@ -245,7 +297,7 @@ func (k *KeyManager) ImportPrivateKey() (common.Address, error) {
 	}
 
 	// Get password with confirmation
-	password, err := promptAndConfirmPassword("Enter password to encrypt key: ")
+	password, err := PromptAndConfirmPassword("Enter password to encrypt key: ")
 	if err != nil {
 		return common.Address{}, err
 	}
@@ -274,16 +326,16 @@ func PubkeyToAddressWithoutLocation(p ecdsa.PublicKey) common.Address {
 	return crypto.PubkeyToAddress(p, location)
 }
 
-// promptAndConfirmPassword prompts the user for a password and confirms it
-func promptAndConfirmPassword(initialPrompt string) (string, error) {
+// PromptAndConfirmPassword prompts the user for a password and confirms it
+func PromptAndConfirmPassword(initialPrompt string) (string, error) {
 	// Read password
-	password, err := readPassword(initialPrompt)
+	password, err := ReadPassword(initialPrompt)
 	if err != nil {
 		return "", err
 	}
 
 	// Confirm password
-	confirmPass, err := readPassword("Confirm password: ")
+	confirmPass, err := ReadPassword("Confirm password: ")
 	if err != nil {
 		return "", err
 	}
@@ -295,3 +347,24 @@ func promptAndConfirmPassword(initialPrompt string) (string, error) {
 
 	return password, nil
 }
+
+// ListAccounts returns the accounts of the stored keys, read from the address field of each
+// key file without decrypting it. Files that aren't key files are skipped.
+func (k *KeyManager) ListAccounts() ([]Account, error) {
+	files, err := k.storage.ListKeys()
+	if err != nil {
+		return nil, err
+	}
+	accounts := make([]Account, 0, len(files))
+	for _, file := range files {
+		address, err := storedAddress(k.storage, file)
+		if err != nil {
+			continue
+		}
+		accounts = append(accounts, Account{
+			Address: address,
+			URL:     URL{Scheme: KeyStoreScheme, Path: k.storage.JoinPath(file)},
+		})
+	}
+	return accounts, nil
+}
@@ -5,7 +5,11 @@ import "github.com/dominant-strategies/go-quai/common"
 type KeyCreator interface {
 	CreateNewKey(location common.Location, protocol string) (common.Address, error)
 	NewAccount(passphrase string, location common.Location, protocol string) (Account, error)
+	CreateBatch(count int, location common.Location, protocol, passphrase string) ([]common.Address, error)
 	ImportPrivateKey() (common.Address, error)
+	ImportBackup(payload string) (common.Address, error)
+	CreateFromMnemonic(mnemonic string, path string, location common.Location) (common.Address, error)
+	DeriveAccounts(mnemonic string, location common.Location, count int) ([]Account, error)
 }
 
 type KeyLoader interface {
@@ -16,6 +20,7 @@ type KeyLoader interface {
 
 type KeyExporter interface {
 	Export(a Account, passphrase, newPassphrase string) ([]byte, error)
+	ExportBackup(addr common.Address, passphrase, newPassphrase string) (string, error)
 }
 
 type KeyStoreManager interface {
@@ -0,0 +1,325 @@
+package keystore
+
+import (
+	"crypto/hmac"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	_ "embed"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/crypto"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// DefaultHDPath is the BIP-44 path of the Quai addresses of the first account, coin type 994.
+// Addresses are derived from its children, see DeriveMnemonicKey.
+const DefaultHDPath = "m/44'/994'/0'/0"
+
+// hardenedOffset is added to the index of a hardened child, written with a ' in paths
+const hardenedOffset = 1 << 31
+
+// maxAddressIndex bounds the search for each child whose address is in the requested scope.
+// About one child in 512 is in the Quai ledger of a given zone.
+const maxAddressIndex = 1 << 17
+
+// ErrInvalidMnemonic is returned for a seed phrase that isn't a valid BIP-39 mnemonic
+var ErrInvalidMnemonic = errors.New("invalid mnemonic")
+
+// englishWords is the BIP-39 English wordlist
+//
+//go:embed bip39_english.txt
+var englishWords string
+
+var (
+	wordList  = strings.Fields(englishWords)
+	wordIndex = make(map[string]int, len(wordList))
+)
+
+func init() {
+	for i, word := range wordList {
+		wordIndex[word] = i
+	}
+}
+
+// GenerateMnemonic returns a new random 24 word BIP-39 mnemonic
+func GenerateMnemonic() (string, error) {
+	entropy := make([]byte, 32)
+	if _, err := crand.Read(entropy); err != nil {
+		return "", fmt.Errorf("failed to read entropy: %v", err)
+	}
+	return entropyToMnemonic(entropy), nil
+}
+
+// entropyToMnemonic encodes entropy and its checksum, the first bits of its SHA-256, as
+// words of 11 bits each
+func entropyToMnemonic(entropy []byte) string {
+	checksumBits := len(entropy) / 4
+	hash := sha256.Sum256(entropy)
+
+	bits := new(big.Int).SetBytes(entropy)
+	bits.Lsh(bits, uint(checksumBits))
+	bits.Or(bits, big.NewInt(int64(hash[0]>>(8-checksumBits))))
+
+	words := make([]string, (len(entropy)*8+checksumBits)/11)
+	mask := big.NewInt(2047)
+	for i := len(words) - 1; i >= 0; i-- {
+		words[i] = wordList[new(big.Int).And(bits, mask).Int64()]
+		bits.Rsh(bits, 11)
+	}
+	return strings.Join(words, " ")
+}
+
+// normalizeMnemonic checks the words and checksum of a mnemonic and returns it lowercased
+// with single spaces
+func normalizeMnemonic(mnemonic string) (string, error) {
+	words := strings.Fields(strings.ToLower(mnemonic))
+	switch len(words) {
+	case 12, 15, 18, 21, 24:
+	default:
+		return "", fmt.Errorf("%w: %d words, expected 12, 15, 18, 21 or 24", ErrInvalidMnemonic, len(words))
+	}
+
+	bits := new(big.Int)
+	for _, word := range words {
+		index, ok := wordIndex[word]
+		if !ok {
+			return "", fmt.Errorf("%w: %q is not in the BIP-39 English wordlist", ErrInvalidMnemonic, word)
+		}
+		bits.Lsh(bits, 11)
+		bits.Or(bits, big.NewInt(int64(index)))
+	}
+
+	checksumBits := len(words) * 11 / 33
+	checksum := new(big.Int).And(bits, big.NewInt(int64(1<<checksumBits-1)))
+	entropy := new(big.Int).Rsh(bits, uint(checksumBits)).FillBytes(make([]byte, checksumBits*4))
+	hash := sha256.Sum256(entropy)
+	if checksum.Int64() != int64(hash[0]>>(8-checksumBits)) {
+		return "", fmt.Errorf("%w: checksum mismatch", ErrInvalidMnemonic)
+	}
+	return strings.Join(words, " "), nil
+}
+
+// mnemonicSeed returns the BIP-39 seed of a normalized mnemonic, without passphrase
+func mnemonicSeed(mnemonic string) []byte {
+	return pbkdf2.Key([]byte(mnemonic), []byte("mnemonic"), 2048, 64, sha512.New)
+}
+
+// parseHDPath parses a derivation path such as m/44'/994'/0'/0
+func parseHDPath(path string) ([]uint32, error) {
+	parts := strings.Split(strings.TrimSpace(path), "/")
+	if len(parts) == 0 || parts[0] != "m" {
+		return nil, fmt.Errorf("invalid derivation path %q: must start with m", path)
+	}
+	indexes := make([]uint32, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		hardened := strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h")
+		if hardened {
+			part = part[:len(part)-1]
+		}
+		index, err := strconv.ParseUint(part, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("invalid derivation path %q: bad index %q", path, part)
+		}
+		if hardened {
+			index += hardenedOffset
+		}
+		indexes = append(indexes, uint32(index))
+	}
+	return indexes, nil
+}
+
+// extendedKey is a BIP-32 private key with its chain code
+type extendedKey struct {
+	key       *btcec.PrivateKey
+	chainCode []byte
+}
+
+// newMasterKey derives the BIP-32 master key of a seed
+func newMasterKey(seed []byte) (*extendedKey, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	var scalar btcec.ModNScalar
+	if overflow := scalar.SetByteSlice(sum[:32]); overflow || scalar.IsZero() {
+		return nil, errors.New("seed yields an invalid master key")
+	}
+	return &extendedKey{key: btcec.PrivKeyFromScalar(&scalar), chainCode: sum[32:]}, nil
+}
+
+// child derives the private child key at index, hardened from hardenedOffset on
+func (k *extendedKey) child(index uint32) (*extendedKey, error) {
+	data := make([]byte, 0, 37)
+	if index >= hardenedOffset {
+		data = append(data, 0)
+		data = append(data, k.key.Serialize()...)
+	} else {
+		data = append(data, k.key.PubKey().SerializeCompressed()...)
+	}
+	data = binary.BigEndian.AppendUint32(data, index)
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	var scalar btcec.ModNScalar
+	if overflow := scalar.SetByteSlice(sum[:32]); overflow {
+		return nil, fmt.Errorf("child %d is invalid", index)
+	}
+	scalar.Add(&k.key.Key)
+	if scalar.IsZero() {
+		return nil, fmt.Errorf("child %d is invalid", index)
+	}
+	return &extendedKey{key: btcec.PrivKeyFromScalar(&scalar), chainCode: sum[32:]}, nil
+}
+
+// DeriveMnemonicKey derives the key of a BIP-39 mnemonic, without passphrase, for the Quai
+// ledger of location. The path leads to the parent of the addresses; its children are tried
+// from index 0 on and the first one whose address is in scope is used, so the same mnemonic,
+// path and location always give the same key. It returns the key and the full path of it.
+func DeriveMnemonicKey(mnemonic string, path string, location common.Location) (*Key, string, error) {
+	keys, paths, err := deriveMnemonicKeys(mnemonic, path, location, 1)
+	if err != nil {
+		return nil, "", err
+	}
+	return keys[0], paths[0], nil
+}
+
+// deriveMnemonicKeys derives the first count keys of a mnemonic for the Quai ledger of
+// location, as DeriveMnemonicKey does for the first one. Random keys are regenerated until one
+// is in scope; a derived key can't be, so the child index is scanned instead, with the same
+// chain scope check. It returns the keys and their full paths.
+func deriveMnemonicKeys(mnemonic string, path string, location common.Location, count int) ([]*Key, []string, error) {
+	if count < 1 {
+		return nil, nil, fmt.Errorf("invalid count %d, must be at least 1", count)
+	}
+	mnemonic, err := normalizeMnemonic(mnemonic)
+	if err != nil {
+		return nil, nil, err
+	}
+	if path == "" {
+		path = DefaultHDPath
+	}
+	path = strings.TrimSpace(path)
+	indexes, err := parseHDPath(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	parent, err := newMasterKey(mnemonicSeed(mnemonic))
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, index := range indexes {
+		if parent, err = parent.child(index); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	keys := make([]*Key, 0, count)
+	paths := make([]string, 0, count)
+	limit := uint64(maxAddressIndex) * uint64(count)
+	for index := uint64(0); index < limit && index < hardenedOffset && len(keys) < count; index++ {
+		child, err := parent.child(uint32(index))
+		if err != nil {
+			// BIP-32 skips to the next index
+			continue
+		}
+		privateKey, err := crypto.ToECDSA(child.key.Serialize())
+		if err != nil {
+			return nil, nil, err
+		}
+		key := newKeyFromECDSA(privateKey, location)
+		if !isInScope(key.Address, location, "quai") {
+			zeroKey(privateKey)
+			continue
+		}
+		keys = append(keys, key)
+		paths = append(paths, fmt.Sprintf("%s/%d", path, index))
+	}
+	if len(keys) < count {
+		for _, key := range keys {
+			zeroKey(key.PrivateKey)
+		}
+		return nil, nil, fmt.Errorf("only %d of %d addresses in the quai ledger of location %v among the first %d children of %s",
+			len(keys), count, location, limit, path)
+	}
+	return keys, paths, nil
+}
+
+// CreateFromMnemonic derives the key of a mnemonic for location, as DeriveMnemonicKey does,
+// and stores it encrypted with a password read from the terminal
+func (k *KeyManager) CreateFromMnemonic(mnemonic string, path string, location common.Location) (common.Address, error) {
+	key, keyPath, err := DeriveMnemonicKey(mnemonic, path, location)
+	if err != nil {
+		return common.Address{}, err
+	}
+	defer zeroKey(key.PrivateKey)
+	fmt.Printf("Derived address %s at path %s\n", key.Address.Hex(), keyPath)
+
+	password, err := PromptAndConfirmPassword("Enter password for new key: ")
+	if err != nil {
+		return common.Address{}, err
+	}
+	if _, err := k.storeDerivedKey(key, password, location); err != nil {
+		return common.Address{}, err
+	}
+	return key.Address, nil
+}
+
+// DeriveAccounts derives the first count accounts of a mnemonic for location along
+// DefaultHDPath, as CreateFromMnemonic does for the first one, and stores each encrypted with
+// one password read from the terminal. Accounts already in the keystore are kept as they are,
+// so deriving more accounts of the same mnemonic later only stores the new ones.
+func (k *KeyManager) DeriveAccounts(mnemonic string, location common.Location, count int) ([]Account, error) {
+	keys, paths, err := deriveMnemonicKeys(mnemonic, DefaultHDPath, location, count)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for _, key := range keys {
+			zeroKey(key.PrivateKey)
+		}
+	}()
+	for i, key := range keys {
+		fmt.Printf("Derived address %s at path %s\n", key.Address.Hex(), paths[i])
+	}
+
+	password, err := PromptAndConfirmPassword("Enter password for the new keys: ")
+	if err != nil {
+		return nil, err
+	}
+	accounts := make([]Account, 0, len(keys))
+	for _, key := range keys {
+		account, err := k.storeDerivedKey(key, password, location)
+		if err != nil {
+			return accounts, err
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+// storeDerivedKey stores a key derived from a mnemonic, unless the keystore already holds its
+// address, since deriving again always gives the same key
+func (k *KeyManager) storeDerivedKey(key *Key, password string, location common.Location) (Account, error) {
+	if files, err := k.keyFilesOf(key.Address); err == nil {
+		fmt.Printf("Address %s is already in the keystore, skipped\n", key.Address.Hex())
+		return Account{Address: key.Address, URL: URL{Scheme: KeyStoreScheme, Path: files[0]}}, nil
+	} else if !errors.Is(err, ErrNoMatch) {
+		return Account{}, err
+	}
+	account, err := storeKey(k.storage, key, password, location, "quai")
+	if err != nil {
+		return Account{}, fmt.Errorf("failed to store key of %s: %v", key.Address.Hex(), err)
+	}
+	return account, nil
+}
@@ -0,0 +1,95 @@
+package keystore
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/crypto"
+)
+
+// testMnemonic is the all-zero entropy vector of BIP-39, "abandon" eleven times then "about"
+var testMnemonic = strings.Repeat("abandon ", 11) + "about"
+
+func TestMnemonicSeed(t *testing.T) {
+	mnemonic, err := normalizeMnemonic(testMnemonic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = "5eb00bbddcf069084889a8ab9155568165f5c453ccb85e70811aaed6f6da5fc19a5ac40b389cd370d086206dec8aa6c43daea6690f20ad3d8d48b2d2ce9e38e4"
+	if got := hex.EncodeToString(mnemonicSeed(mnemonic)); got != want {
+		t.Errorf("seed %s, want %s", got, want)
+	}
+	if got := entropyToMnemonic(make([]byte, 16)); got != testMnemonic {
+		t.Errorf("mnemonic of zero entropy %q, want %q", got, testMnemonic)
+	}
+}
+
+func TestDeriveHDPath(t *testing.T) {
+	// The first Ethereum account of the mnemonic, as every BIP-44 wallet derives it
+	indexes, err := parseHDPath("m/44'/60'/0'/0/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := newMasterKey(mnemonicSeed(testMnemonic))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, index := range indexes {
+		if key, err = key.child(index); err != nil {
+			t.Fatal(err)
+		}
+	}
+	privateKey, err := crypto.ToECDSA(key.key.Serialize())
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := crypto.PubkeyToAddress(privateKey.PublicKey, common.Location{0, 0})
+	if want := "0x9858EfFD232B4033E47d90003D41EC34EcaEda94"; !strings.EqualFold(address.Hex(), want) {
+		t.Errorf("address %s, want %s", address.Hex(), want)
+	}
+}
+
+func TestDeriveMnemonicKey(t *testing.T) {
+	location := common.Location{0, 0}
+	key, path, err := DeriveMnemonicKey(testMnemonic, "", location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(path, DefaultHDPath+"/") {
+		t.Errorf("path %s, want a child of %s", path, DefaultHDPath)
+	}
+	if !isInScope(key.Address, location, "quai") {
+		t.Errorf("address %s is not in the quai ledger of %v", key.Address.Hex(), location)
+	}
+	again, againPath, err := DeriveMnemonicKey("  ABANDON "+strings.Repeat("abandon  ", 10)+"about\n", "", location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !again.Address.Equal(key.Address) || againPath != path {
+		t.Errorf("same mnemonic gave %s at %s, then %s at %s", key.Address.Hex(), path, again.Address.Hex(), againPath)
+	}
+}
+
+func TestInvalidMnemonic(t *testing.T) {
+	tests := []struct {
+		name     string
+		mnemonic string
+	}{
+		{name: "bad checksum", mnemonic: strings.Repeat("abandon ", 12)},
+		{name: "word not in list", mnemonic: strings.Repeat("abandon ", 11) + "quai"},
+		{name: "word count", mnemonic: strings.Repeat("abandon ", 10) + "about"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := normalizeMnemonic(tt.mnemonic); !errors.Is(err, ErrInvalidMnemonic) {
+				t.Errorf("error %v, want %v", err, ErrInvalidMnemonic)
+			}
+			if _, _, err := DeriveMnemonicKey(tt.mnemonic, "", common.Location{0, 0}); !errors.Is(err, ErrInvalidMnemonic) {
+				t.Errorf("DeriveMnemonicKey error %v, want %v", err, ErrInvalidMnemonic)
+			}
+		})
+	}
+}
@@ -387,3 +387,19 @@ func ensureInt(x interface{}) int {
 	}
 	return res
 }
+
+func (ks keyStorePassphrase) ReadKey(filename string) ([]byte, error) {
+	return os.ReadFile(filename)
+}
+
+func (ks keyStorePassphrase) ListKeys() ([]string, error) {
+	return listKeyFiles(ks.keysDirPath)
+}
+
+func (ks keyStorePassphrase) WriteKey(filename string, keyjson []byte) error {
+	return writeKeyFile(filename, keyjson)
+}
+
+func (ks keyStorePassphrase) DeleteKey(filename string) error {
+	return os.Remove(filename)
+}
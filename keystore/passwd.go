@@ -0,0 +1,68 @@
+package keystore
+
+import (
+	"fmt"
+
+	"github.com/dominant-strategies/go-quai/common"
+)
+
+// ChangePassword re-encrypts the key of addr with newPass in place. The key keeps its scrypt
+// parameters, and the new file replaces the old one atomically only once it has been checked
+// to decrypt with newPass. A wrong oldPass returns ErrDecrypt and leaves the key untouched.
+func (k *KeyManager) ChangePassword(addr common.Address, oldPass, newPass string) error {
+	name, err := k.keyFileOf(addr)
+	if err != nil {
+		return err
+	}
+	keyjson, err := k.storage.ReadKey(name)
+	if err != nil {
+		return fmt.Errorf("failed to read key: %w", err)
+	}
+
+	key, err := DecryptKey(keyjson, oldPass)
+	if err != nil {
+		return err
+	}
+	defer zeroKey(key.PrivateKey)
+	if !key.Address.Equal(addr) {
+		return fmt.Errorf("key content mismatch: have account %x, want %x", key.Address, addr)
+	}
+
+	scryptN, scryptP, ok := scryptParamsOf(keyjson)
+	if !ok {
+		scryptN, scryptP = k.scryptParams()
+	}
+	newjson, err := EncryptKey(key, newPass, scryptN, scryptP)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt: %w", err)
+	}
+	// Never replace a key with one that can't be decrypted
+	if check, err := DecryptKey(newjson, newPass); err != nil || !check.Address.Equal(key.Address) {
+		return fmt.Errorf("failed to verify the re-encrypted key: %v", err)
+	}
+	return k.storage.WriteKey(name, newjson)
+}
+
+// VerifyPassword reports whether password decrypts the key of addr, without returning the key:
+// it is zeroed as soon as it has been decrypted. A wrong password returns false with
+// ErrDecrypt, a missing key false with ErrNoMatch.
+func (k *KeyManager) VerifyPassword(addr common.Address, password string) (bool, error) {
+	name, err := k.keyFileOf(addr)
+	if err != nil {
+		return false, err
+	}
+	keyjson, err := k.storage.ReadKey(name)
+	if err != nil {
+		return false, fmt.Errorf("failed to read key: %w", err)
+	}
+
+	key, err := DecryptKey(keyjson, password)
+	if err != nil {
+		return false, err
+	}
+	zeroKey(key.PrivateKey)
+	if !key.Address.Equal(addr) {
+		return false, fmt.Errorf("key content mismatch: have account %x, want %x", key.Address, addr)
+	}
+	return true, nil
+}
@@ -49,3 +49,15 @@ func (ks keyStorePlain) JoinPath(filename string) string {
 	}
 	return filepath.Join(ks.keysDirPath, filename)
 }
+
+func (ks keyStorePlain) ReadKey(filename string) ([]byte, error) {
+	return os.ReadFile(filename)
+}
+
+func (ks keyStorePlain) ListKeys() ([]string, error) {
+	return listKeyFiles(ks.keysDirPath)
+}
+
+func (ks keyStorePlain) WriteKey(filename string, keyjson []byte) error {
+	return writeKeyFile(filename, keyjson)
+}
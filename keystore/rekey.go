@@ -0,0 +1,94 @@
+package keystore
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Named scrypt cost levels accepted by ScryptParams
+const (
+	ScryptStandard = "standard"
+	ScryptLight    = "light"
+)
+
+// ScryptParams returns the scrypt N and P parameters of a named cost level
+func ScryptParams(name string) (int, int, error) {
+	switch name {
+	case ScryptStandard:
+		return StandardScryptN, StandardScryptP, nil
+	case ScryptLight:
+		return LightScryptN, LightScryptP, nil
+	default:
+		return 0, 0, fmt.Errorf("unknown scrypt parameters %q, must be %q or %q", name, ScryptStandard, ScryptLight)
+	}
+}
+
+// RekeyResult lists what Rekey did with each stored key
+type RekeyResult struct {
+	Rekeyed []string
+	Skipped []string // already at the requested parameters
+	Failed  map[string]error
+}
+
+// Rekey re-encrypts every stored key with new scrypt parameters, keeping the same password.
+// Keys already at those parameters are skipped, and a key that fails, for instance because
+// it uses another password, is reported without stopping the others. Each key is decrypted
+// again before it replaces the old one, and replaced atomically.
+func (k *KeyManager) Rekey(auth string, scryptN, scryptP int) (*RekeyResult, error) {
+	names, err := k.storage.ListKeys()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	result := &RekeyResult{Failed: make(map[string]error)}
+	for _, name := range names {
+		keyjson, err := k.storage.ReadKey(name)
+		if err != nil {
+			result.Failed[name] = err
+			continue
+		}
+		if n, p, ok := scryptParamsOf(keyjson); ok && n == scryptN && p == scryptP {
+			result.Skipped = append(result.Skipped, name)
+			continue
+		}
+		if err := k.rekeyOne(name, keyjson, auth, scryptN, scryptP); err != nil {
+			result.Failed[name] = err
+			continue
+		}
+		result.Rekeyed = append(result.Rekeyed, name)
+	}
+	return result, nil
+}
+
+func (k *KeyManager) rekeyOne(name string, keyjson []byte, auth string, scryptN, scryptP int) error {
+	key, err := DecryptKey(keyjson, auth)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt: %w", err)
+	}
+	defer zeroKey(key.PrivateKey)
+
+	newjson, err := EncryptKey(key, auth, scryptN, scryptP)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt: %w", err)
+	}
+	// Never replace a key with one that can't be decrypted
+	if check, err := DecryptKey(newjson, auth); err != nil || !check.Address.Equal(key.Address) {
+		return fmt.Errorf("failed to verify the re-encrypted key: %v", err)
+	}
+	return k.storage.WriteKey(name, newjson)
+}
+
+// scryptParamsOf reads the scrypt parameters of encrypted key JSON without decrypting it.
+// It reports false for keys that don't use scrypt.
+func scryptParamsOf(keyjson []byte) (int, int, bool) {
+	var k encryptedKeyJSONV3
+	if err := json.Unmarshal(keyjson, &k); err != nil || k.Crypto.KDF != keyHeaderKDF {
+		return 0, 0, false
+	}
+	n, okN := k.Crypto.KDFParams["n"].(float64)
+	p, okP := k.Crypto.KDFParams["p"].(float64)
+	if !okN || !okP {
+		return 0, 0, false
+	}
+	return int(n), int(p), true
+}
@@ -0,0 +1,101 @@
+package keystore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dominant-strategies/go-quai/common"
+)
+
+// newTestSecretKeyManager returns a KeyManager over a DirSecretStore in a temporary directory,
+// with light scrypt parameters
+func newTestSecretKeyManager(t *testing.T) (*KeyManager, *DirSecretStore) {
+	t.Helper()
+	store, err := NewDirSecretStore(filepath.Join(t.TempDir(), "secrets"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &KeyManager{storage: keyStoreSecret{store: store, scryptN: LightScryptN, scryptP: LightScryptP}}, store
+}
+
+func TestSecretStoreResolve(t *testing.T) {
+	km, store := newTestSecretKeyManager(t)
+	key := testKey(t, testQuaiKey)
+	address := key.Address
+	account, err := storeKey(km.storage, key, "password", common.Location{0, 0}, "quai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(account.URL.Path) != account.URL.Path {
+		t.Errorf("stored under %q, want a bare secret name", account.URL.Path)
+	}
+
+	names, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 || names[0] != account.URL.Path {
+		t.Fatalf("secrets %v, want [%s]", names, account.URL.Path)
+	}
+
+	name, err := km.keyFileOf(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := km.GetKey(address, name, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Address.Equal(address) {
+		t.Errorf("resolved key of %s, want %s", loaded.Address.Hex(), address.Hex())
+	}
+
+	if _, err := km.GetKey(address, name, "wrong password"); err == nil {
+		t.Error("key decrypted with a wrong password")
+	}
+	other := testKey(t, testQiKey).Address
+	if _, err := km.GetKey(other, name, "password"); err == nil {
+		t.Error("key of another address accepted")
+	}
+}
+
+func TestSecretStoreMissing(t *testing.T) {
+	km, store := newTestSecretKeyManager(t)
+	address := testKey(t, testQuaiKey).Address
+
+	if _, err := km.keyFileOf(address); !errors.Is(err, ErrNoMatch) {
+		t.Errorf("key file lookup of a missing key: error %v, want %v", err, ErrNoMatch)
+	}
+	if _, err := km.GetKey(address, keyFileName(address), "password"); !errors.Is(err, ErrNoMatch) {
+		t.Errorf("GetKey of a missing secret: error %v, want %v", err, ErrNoMatch)
+	}
+	if _, err := km.storage.ReadKey(keyFileName(address)); !errors.Is(err, ErrNoMatch) {
+		t.Errorf("ReadKey of a missing secret: error %v, want %v", err, ErrNoMatch)
+	}
+	if err := store.Delete("missing"); !errors.Is(err, ErrNoMatch) {
+		t.Errorf("Delete of a missing secret: error %v, want %v", err, ErrNoMatch)
+	}
+}
+
+func TestDirSecretStoreStaysInDir(t *testing.T) {
+	root := t.TempDir()
+	store, err := NewDirSecretStore(filepath.Join(root, "secrets"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put("../escaped", []byte("secret")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "escaped")); !os.IsNotExist(err) {
+		t.Errorf("secret written outside the store directory: %v", err)
+	}
+	data, err := store.Get("escaped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "secret" {
+		t.Errorf("secret %q, want %q", data, "secret")
+	}
+}
@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"quai-transfer/config"
+)
+
+// Metric names, shared by every backend
+const (
+	Broadcast           = "broadcast"
+	Queued              = "queued"
+	Confirmed           = "confirmed"
+	Failed              = "failed"
+	ConfirmationLatency = "confirmation_latency"
+	PendingTxs          = "pending_txs"
+	GasPrice            = "gas_price_wei"
+)
+
+// Sink is a metrics backend. Labels qualify a metric, such as the status of a failure;
+// backends without labels fold them into the metric name.
+type Sink interface {
+	Count(name string, labels map[string]string)
+	Timing(name string, d time.Duration)
+	Gauge(name string, value float64)
+	Close() error
+}
+
+// Metrics is the instrumentation of a batch run, the single place that decides what is
+// measured so every backend reports the same metrics. A nil *Metrics records nothing.
+type Metrics struct {
+	sink Sink
+}
+
+// New wraps a backend
+func New(sink Sink) *Metrics {
+	return &Metrics{sink: sink}
+}
+
+// Open returns the backend selected by metrics_backend, nil when metrics are disabled
+func Open(cfg *config.Config) (*Metrics, error) {
+	switch cfg.MetricsBackend {
+	case config.MetricsBackendStatsD:
+		sink, err := NewStatsD(cfg.StatsDAddress, cfg.StatsDPrefix)
+		if err != nil {
+			return nil, err
+		}
+		return New(sink), nil
+	case config.MetricsBackendPrometheus:
+		sink, err := NewPrometheus(cfg.MetricsAddr)
+		if err != nil {
+			return nil, err
+		}
+		return New(sink), nil
+	case config.MetricsBackendNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unsupported metrics_backend %q", cfg.MetricsBackend)
+	}
+}
+
+// Broadcast counts a transaction accepted by the node
+func (m *Metrics) Broadcast() {
+	if m == nil {
+		return
+	}
+	m.sink.Count(Broadcast, nil)
+}
+
+// Queued counts an entry broadcast and waiting for its receipt
+func (m *Metrics) Queued() {
+	if m == nil {
+		return
+	}
+	m.sink.Count(Queued, nil)
+}
+
+// Confirmed counts a successful receipt and times it from the broadcast, when that is known
+func (m *Metrics) Confirmed(latency time.Duration) {
+	if m == nil {
+		return
+	}
+	m.sink.Count(Confirmed, nil)
+	if latency > 0 {
+		m.sink.Timing(ConfirmationLatency, latency)
+	}
+}
+
+// Failed counts an entry that will not be paid in this run, by its result status
+func (m *Metrics) Failed(status string) {
+	if m == nil {
+		return
+	}
+	m.sink.Count(Failed, map[string]string{"status": status})
+}
+
+// Pending sets the number of transactions waiting for their receipt
+func (m *Metrics) Pending(n int) {
+	if m == nil {
+		return
+	}
+	m.sink.Gauge(PendingTxs, float64(n))
+}
+
+// GasPrice sets the gas price last suggested by the node, in wei
+func (m *Metrics) GasPrice(price *big.Int) {
+	if m == nil || price == nil {
+		return
+	}
+	wei, _ := new(big.Float).SetInt(price).Float64()
+	m.sink.Gauge(GasPrice, wei)
+}
+
+// Close flushes and closes the backend
+func (m *Metrics) Close() error {
+	if m == nil {
+		return nil
+	}
+	return m.sink.Close()
+}
@@ -0,0 +1,188 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// prometheusPrefix starts the name of every metric served
+const prometheusPrefix = "quai_transfer_"
+
+// latencyBuckets are the upper bounds, in seconds, of the confirmation latency histogram.
+// Blocks come every few seconds, so the range goes from one block to a stuck transaction.
+var latencyBuckets = []float64{1, 2, 5, 10, 15, 30, 60, 120, 300, 600, 1800}
+
+// prometheusHelp describes each metric in the exposition
+var prometheusHelp = map[string]string{
+	Broadcast:           "Transactions accepted by the node.",
+	Queued:              "Entries broadcast and waiting for their receipt.",
+	Confirmed:           "Entries with a successful receipt.",
+	Failed:              "Entries that will not be paid in this run, by result status.",
+	ConfirmationLatency: "Time from broadcast to receipt.",
+	PendingTxs:          "Transactions waiting for their receipt.",
+	GasPrice:            "Gas price last suggested by the node, in wei.",
+}
+
+// histogram is a cumulative histogram over latencyBuckets
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// Prometheus keeps metrics in memory and serves them in the Prometheus text format on
+// /metrics, so they can be scraped while a batch runs
+type Prometheus struct {
+	mu         sync.Mutex
+	counters   map[string]map[string]float64 // name, then rendered labels
+	histograms map[string]*histogram
+	gauges     map[string]float64
+	server     *http.Server
+}
+
+// NewPrometheus listens on address, host:port, and serves the metrics in the background.
+// Failing to listen is returned, so a taken port stops the run before it starts.
+func NewPrometheus(address string) (*Prometheus, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for metrics on %s: %w", address, err)
+	}
+	p := &Prometheus{
+		counters:   make(map[string]map[string]float64),
+		histograms: make(map[string]*histogram),
+		gauges:     make(map[string]float64),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", p.serveHTTP)
+	p.server = &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+
+	go func() {
+		if err := p.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("metrics server on %s stopped: %v", address, err)
+		}
+	}()
+	log.Printf("📈 METRICS | Serving http://%s/metrics", listener.Addr())
+	return p, nil
+}
+
+// Count increments a counter
+func (p *Prometheus) Count(name string, labels map[string]string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	series, ok := p.counters[name]
+	if !ok {
+		series = make(map[string]float64)
+		p.counters[name] = series
+	}
+	series[renderLabels(labels)]++
+}
+
+// Timing adds a duration, in seconds, to a histogram
+func (p *Prometheus) Timing(name string, d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	h, ok := p.histograms[name]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(latencyBuckets))}
+		p.histograms[name] = h
+	}
+	seconds := d.Seconds()
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// Gauge sets a gauge to value
+func (p *Prometheus) Gauge(name string, value float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.gauges[name] = value
+}
+
+// Close stops serving
+func (p *Prometheus) Close() error {
+	return p.server.Close()
+}
+
+func (p *Prometheus) serveHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	p.write(w)
+}
+
+// write renders every metric in the text exposition format, sorted by name
+func (p *Prometheus) write(out io.Writer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var b strings.Builder
+	for _, name := range sortedKeys(p.counters) {
+		full := prometheusPrefix + name + "_total"
+		writeHeader(&b, full, name, "counter")
+		series := p.counters[name]
+		for _, labels := range sortedKeys(series) {
+			fmt.Fprintf(&b, "%s%s %s\n", full, labels, formatFloat(series[labels]))
+		}
+	}
+	for _, name := range sortedKeys(p.histograms) {
+		full := prometheusPrefix + name + "_seconds"
+		writeHeader(&b, full, name, "histogram")
+		h := p.histograms[name]
+		for i, bound := range latencyBuckets {
+			fmt.Fprintf(&b, "%s_bucket{le=\"%s\"} %d\n", full, formatFloat(bound), h.counts[i])
+		}
+		fmt.Fprintf(&b, "%s_bucket{le=\"+Inf\"} %d\n", full, h.count)
+		fmt.Fprintf(&b, "%s_sum %s\n", full, formatFloat(h.sum))
+		fmt.Fprintf(&b, "%s_count %d\n", full, h.count)
+	}
+	for _, name := range sortedKeys(p.gauges) {
+		full := prometheusPrefix + name
+		writeHeader(&b, full, name, "gauge")
+		fmt.Fprintf(&b, "%s %s\n", full, formatFloat(p.gauges[name]))
+	}
+	io.WriteString(out, b.String())
+}
+
+func writeHeader(b *strings.Builder, full, name, kind string) {
+	if help, ok := prometheusHelp[name]; ok {
+		fmt.Fprintf(b, "# HELP %s %s\n", full, help)
+	}
+	fmt.Fprintf(b, "# TYPE %s %s\n", full, kind)
+}
+
+// renderLabels renders labels as {k="v",...}, sorted by key, empty without labels
+func renderLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(labels))
+	for _, k := range sortedKeys(labels) {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", k, strconv.Quote(labels[k])))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StatsD sends metrics to a StatsD or DogStatsD agent over UDP. Each metric is a datagram of
+// its own and send errors are dropped, as UDP delivery is best effort anyway.
+type StatsD struct {
+	mu     sync.Mutex
+	conn   net.Conn
+	prefix string
+}
+
+// NewStatsD connects to the agent at address, host:port, prefixing every metric name
+func NewStatsD(address, prefix string) (*StatsD, error) {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to statsd at %s: %w", address, err)
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, ".") {
+		prefix += "."
+	}
+	return &StatsD{conn: conn, prefix: prefix}, nil
+}
+
+// Count increments a counter. Label values are appended to the name, failed.dead_lettered,
+// so plain StatsD servers keep them apart.
+func (s *StatsD) Count(name string, labels map[string]string) {
+	s.send(fmt.Sprintf("%s:1|c", s.name(name, labels)))
+}
+
+// Timing records a duration in milliseconds
+func (s *StatsD) Timing(name string, d time.Duration) {
+	s.send(fmt.Sprintf("%s:%d|ms", s.name(name, nil), d.Milliseconds()))
+}
+
+// Gauge sets a gauge to value
+func (s *StatsD) Gauge(name string, value float64) {
+	s.send(fmt.Sprintf("%s:%s|g", s.name(name, nil), strconv.FormatFloat(value, 'f', -1, 64)))
+}
+
+func (s *StatsD) name(name string, labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		name += "." + labels[k]
+	}
+	return s.prefix + name
+}
+
+func (s *StatsD) send(line string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conn.Write([]byte(line))
+}
+
+// Close closes the connection
+func (s *StatsD) Close() error {
+	return s.conn.Close()
+}
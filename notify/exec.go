@@ -0,0 +1,143 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"quai-transfer/report"
+
+	"github.com/shopspring/decimal"
+)
+
+// DefaultExecTimeout bounds each run of the exec hook when exec_hook_timeout is unset
+const DefaultExecTimeout = 30 * time.Second
+
+// maxHookOutput is how much of the output of a hook is logged
+const maxHookOutput = 4096
+
+// hookEnv are the variables of the environment a hook inherits. The rest, the private key of
+// QUAI_PRIVATE_KEY or database passwords among them, is withheld.
+var hookEnv = []string{"PATH", "HOME", "TMPDIR", "LANG", "TZ"}
+
+// ParseExecArgs parses the arguments of an exec hook, the command first, each a Go template
+// with the variables of Payload, and checks they render for a sample payload
+func ParseExecArgs(args []string) ([]*template.Template, error) {
+	if len(args) == 0 || strings.TrimSpace(args[0]) == "" {
+		return nil, errors.New("no command")
+	}
+	sample := PayloadOf(report.Row{
+		ID:     1,
+		TxHash: "0x0000000000000000000000000000000000000000000000000000000000000000",
+		Value:  decimal.NewFromInt(1),
+		Status: report.StatusConfirmed,
+		Fee:    decimal.Zero,
+	})
+	tmpls := make([]*template.Template, len(args))
+	for i, arg := range args {
+		tmpl, err := template.New("exec").Funcs(funcs).Option("missingkey=error").Parse(arg)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %w", i, err)
+		}
+		if err := tmpl.Execute(&bytes.Buffer{}, sample); err != nil {
+			return nil, fmt.Errorf("argument %d: %w", i, err)
+		}
+		tmpls[i] = tmpl
+	}
+	return tmpls, nil
+}
+
+// Exec runs a command for each entry outcome, with the outcome in its arguments and in
+// QUAI_* environment variables. It's a report.ResultSink like Webhook: a hook that fails,
+// exits non-zero or times out is only logged and never stalls or aborts a batch. The command
+// is run directly, without a shell, so outcome values can't inject commands.
+type Exec struct {
+	args    []*template.Template
+	timeout time.Duration
+}
+
+// NewExec creates an exec hook running args, the command first, each run bounded by timeout
+// or DefaultExecTimeout when zero
+func NewExec(args []string, timeout time.Duration) (*Exec, error) {
+	tmpls, err := ParseExecArgs(args)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exec_hook: %w", err)
+	}
+	if timeout <= 0 {
+		timeout = DefaultExecTimeout
+	}
+	return &Exec{args: tmpls, timeout: timeout}, nil
+}
+
+// Write runs the hook for the outcome of an entry and waits for it, logging its output
+func (h *Exec) Write(row report.Row) error {
+	payload := PayloadOf(row)
+	args := make([]string, len(h.args))
+	for i, tmpl := range h.args {
+		var arg strings.Builder
+		if err := tmpl.Execute(&arg, payload); err != nil {
+			return fmt.Errorf("failed to render exec hook argument %d: %w", i, err)
+		}
+		args[i] = arg.String()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = execEnv(payload)
+	// Output pipes held open by children of a killed hook don't hold up the batch
+	cmd.WaitDelay = time.Second
+	out, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", h.timeout)
+	}
+
+	output := strings.TrimSpace(string(out))
+	if len(output) > maxHookOutput {
+		output = output[:maxHookOutput] + "... (truncated)"
+	}
+	if err != nil {
+		return fmt.Errorf("exec hook for entry %d failed: %w | Output: %s", row.ID, err, output)
+	}
+	log.Printf("🪝 EXEC HOOK | ID: %d | Status: %s | Tx Hash: %s | Output: %s", row.ID, row.Status, row.TxHash, output)
+	return nil
+}
+
+// execEnv returns the environment of a hook run: the allowed variables of ours and the outcome
+func execEnv(p Payload) []string {
+	env := make([]string, 0, len(hookEnv)+10)
+	for _, name := range hookEnv {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	return append(env,
+		"QUAI_ENTRY_ID="+strconv.FormatInt(int64(p.EntryID), 10),
+		"QUAI_TX_HASH="+p.TxHash,
+		"QUAI_STATUS="+p.Status,
+		"QUAI_VALUE="+p.Value,
+		"QUAI_ERROR="+p.Error,
+		"QUAI_GAS_USED="+strconv.FormatUint(p.GasUsed, 10),
+		"QUAI_FEE="+p.Fee,
+		"QUAI_TIMESTAMP="+p.Timestamp,
+		"QUAI_BLOCK_NUMBER="+strconv.FormatUint(p.BlockNumber, 10),
+		"QUAI_BLOCK_TIME="+p.BlockTime,
+	)
+}
+
+// Close does nothing, every run is done once Write returns
+func (h *Exec) Close() error {
+	return nil
+}
+
+func (h *Exec) String() string {
+	return "exec hook"
+}
@@ -0,0 +1,157 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"quai-transfer/report"
+
+	"github.com/shopspring/decimal"
+)
+
+// DefaultTemplate is the webhook payload used when webhook_template is unset
+const DefaultTemplate = `{"entry_id": {{.EntryID}}, "tx_hash": {{json .TxHash}}, "value": {{json .Value}}, ` +
+	`"status": {{json .Status}}, "error": {{json .Error}}, "timestamp": {{json .Timestamp}}}`
+
+// webhookTimeout bounds each delivery so a slow endpoint can't hold up the batch
+const webhookTimeout = 10 * time.Second
+
+// Payload holds the variables available to a webhook template. Use the json function to
+// quote strings, e.g. {{json .TxHash}}.
+type Payload struct {
+	EntryID   int32
+	TxHash    string
+	Value     string // wei
+	Status    string // one of the report statuses
+	Error     string
+	GasUsed   uint64
+	Fee       string    // wei
+	Time      time.Time // when the outcome was recorded
+	Timestamp string    // Time in RFC 3339
+	Unix      int64     // Time in seconds since the epoch
+
+	BlockNumber uint64 // block that included the transaction, 0 unless confirmed
+	BlockTime   string // timestamp of that block in RFC 3339, empty unless confirmed
+}
+
+// PayloadOf builds the template variables of a result row
+func PayloadOf(row report.Row) Payload {
+	if row.Time.IsZero() {
+		row.Time = time.Now()
+	}
+	var blockTime string
+	if !row.BlockTime.IsZero() {
+		blockTime = row.BlockTime.UTC().Format(time.RFC3339)
+	}
+	return Payload{
+		EntryID:   row.ID,
+		TxHash:    row.TxHash,
+		Value:     row.Value.String(),
+		Status:    row.Status,
+		Error:     row.Error,
+		GasUsed:   row.GasUsed,
+		Fee:       row.Fee.String(),
+		Time:      row.Time,
+		Timestamp: row.Time.UTC().Format(time.RFC3339),
+		Unix:      row.Time.Unix(),
+
+		BlockNumber: row.BlockNumber,
+		BlockTime:   blockTime,
+	}
+}
+
+var funcs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// ParseTemplate parses a webhook payload template, DefaultTemplate when empty, and checks
+// that it renders valid JSON for a sample payload
+func ParseTemplate(text string) (*template.Template, error) {
+	if strings.TrimSpace(text) == "" {
+		text = DefaultTemplate
+	}
+	tmpl, err := template.New("webhook").Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	sample := PayloadOf(report.Row{
+		ID:     1,
+		TxHash: "0x0000000000000000000000000000000000000000000000000000000000000000",
+		Value:  decimal.NewFromInt(1),
+		Status: report.StatusConfirmed,
+		Fee:    decimal.Zero,
+	})
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, sample); err != nil {
+		return nil, err
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("template does not render valid JSON: %s", buf.String())
+	}
+	return tmpl, nil
+}
+
+// Webhook posts a templated JSON payload for each entry outcome. It's a report.ResultSink:
+// deliveries run on the sink's own goroutine and failures are only logged, so the endpoint
+// can never stall a batch.
+type Webhook struct {
+	url    string
+	tmpl   *template.Template
+	client *http.Client
+}
+
+// NewWebhook creates a webhook posting to url with the given payload template
+func NewWebhook(url, tmplText string) (*Webhook, error) {
+	tmpl, err := ParseTemplate(tmplText)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook template: %w", err)
+	}
+	return &Webhook{url: url, tmpl: tmpl, client: &http.Client{Timeout: webhookTimeout}}, nil
+}
+
+// Write sends the outcome of an entry and waits for the endpoint
+func (h *Webhook) Write(row report.Row) error {
+	var body bytes.Buffer
+	if err := h.tmpl.Execute(&body, PayloadOf(row)); err != nil {
+		return fmt.Errorf("failed to render webhook payload: %w", err)
+	}
+	return h.post(body.Bytes())
+}
+
+func (h *Webhook) post(body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Close does nothing, every delivery is done once Write returns
+func (h *Webhook) Close() error {
+	return nil
+}
+
+func (h *Webhook) String() string {
+	return "webhook"
+}
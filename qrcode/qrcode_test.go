@@ -0,0 +1,123 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// readReference reads a reference symbol of testdata: the encoded data on the first line, then
+// a row of modules per line, # for dark. The references were encoded at level M by
+// github.com/skip2/go-qrcode, an independent encoder.
+func readReference(t *testing.T, name string) ([]byte, []string) {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	return []byte(lines[0]), lines[1:]
+}
+
+func TestEncodeReference(t *testing.T) {
+	tests := []struct {
+		file    string
+		version int
+	}{
+		{file: "hello.txt", version: 1},
+		{file: "text40.txt", version: 3},
+		{file: "text100.txt", version: 6},
+		{file: "text180.txt", version: 9},  // last version with an 8-bit length
+		{file: "text300.txt", version: 13}, // 16-bit length, version information
+		{file: "backup.txt", version: 18},  // a key backup payload
+		{file: "text900.txt", version: 24}, // blocks of two lengths
+		{file: "text1500.txt", version: 32},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, rows := readReference(t, tt.file)
+			c, err := Encode(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.version != tt.version || c.Size != len(rows) {
+				t.Fatalf("version %d of size %d, want version %d of size %d", c.version, c.Size, tt.version, len(rows))
+			}
+			mismatches := 0
+			for y, row := range rows {
+				for x, module := range row {
+					if c.Dark(x, y) != (module == '#') {
+						if mismatches == 0 {
+							t.Errorf("first mismatch at column %d, row %d", x, y)
+						}
+						mismatches++
+					}
+				}
+			}
+			if mismatches > 0 {
+				t.Errorf("%d of %d modules differ from the reference", mismatches, c.Size*c.Size)
+			}
+		})
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	// Byte mode capacity at level M by version, from table 7 of the standard. Index 0 is unused.
+	want := [41]int{-1,
+		14, 26, 42, 62, 84, 106, 122, 152, 180, 213, 251, 287, 331, 362, 412, 450, 504, 560, 624, 666,
+		711, 779, 857, 911, 997, 1059, 1125, 1190, 1264, 1370, 1452, 1538, 1628, 1722, 1809, 1911, 1989, 2099, 2213, 2331}
+	for version := 1; version <= 40; version++ {
+		if got := (8*numDataCodewords(version) - 4 - countBits(version)) / 8; got != want[version] {
+			t.Errorf("version %d holds %d bytes, want %d", version, got, want[version])
+		}
+	}
+}
+
+func TestEncodeCapacity(t *testing.T) {
+	// Byte mode capacity of version 40 at level M, from table 7 of the standard
+	const capacity = 2331
+	c, err := Encode(bytes.Repeat([]byte("q"), capacity))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.version != 40 || c.Size != 177 {
+		t.Errorf("version %d of size %d, want 40 of size 177", c.version, c.Size)
+	}
+	if _, err := Encode(bytes.Repeat([]byte("q"), capacity+1)); !errors.Is(err, ErrTooLong) {
+		t.Errorf("error %v, want %v", err, ErrTooLong)
+	}
+}
+
+func TestImage(t *testing.T) {
+	data, rows := readReference(t, "hello.txt")
+	c, err := Encode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := c.WritePNG(&buf, 3); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if side := (len(rows) + 2*quietZone) * 3; img.Bounds().Dx() != side || img.Bounds().Dy() != side {
+		t.Fatalf("image of %v, want %dx%d with the quiet zone", img.Bounds(), side, side)
+	}
+	for y, row := range rows {
+		for x, module := range row {
+			r, _, _, _ := img.At((x+quietZone)*3+1, (y+quietZone)*3+1).RGBA()
+			if dark := r == 0; dark != (module == '#') {
+				t.Fatalf("pixel of module %d,%d is dark %t, want %t", x, y, dark, module == '#')
+			}
+		}
+	}
+	if r, _, _, _ := img.At(0, 0).RGBA(); r == 0 {
+		t.Error("quiet zone is dark")
+	}
+}
@@ -0,0 +1,133 @@
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Result statuses written to the results CSV
+const (
+	StatusConfirmed    = "confirmed"
+	StatusReverted     = "reverted"
+	StatusFailed       = "failed"
+	StatusDeadLettered = "dead_lettered"
+	StatusInvalid      = "invalid"
+)
+
+// Columns of the results CSV, shared by every export so the files are interchangeable
+var Columns = []string{"id", "tx_hash", "status", "gas_used", "fee", "timestamp", "error", "block_number", "block_time"}
+
+// Row is the outcome of a single entry
+type Row struct {
+	ID      int32
+	TxHash  string
+	Status  string
+	GasUsed uint64
+	Fee     decimal.Decimal // wei
+	Time    time.Time
+	Error   string
+	Value   decimal.Decimal // wei, not part of Columns
+
+	// Block that included the transaction, unset for entries that weren't confirmed
+	BlockNumber uint64
+	BlockTime   time.Time
+}
+
+// Record returns the row in the order of Columns
+func (r Row) Record() []string {
+	var blockNumber, blockTime string
+	if r.BlockNumber > 0 {
+		blockNumber = strconv.FormatUint(r.BlockNumber, 10)
+	}
+	if !r.BlockTime.IsZero() {
+		blockTime = r.BlockTime.UTC().Format(time.RFC3339)
+	}
+	return []string{
+		strconv.FormatInt(int64(r.ID), 10),
+		r.TxHash,
+		r.Status,
+		strconv.FormatUint(r.GasUsed, 10),
+		r.Fee.String(),
+		r.Time.UTC().Format(time.RFC3339),
+		r.Error,
+		blockNumber,
+		blockTime,
+	}
+}
+
+// Writer appends rows to a results CSV, flushing each one so the file survives a crash.
+// It's safe for concurrent use, and a nil *Writer discards all rows.
+type Writer struct {
+	mu   sync.Mutex
+	file *os.File
+	csv  *csv.Writer
+}
+
+// OpenWriter opens (or creates) the results CSV at path for appending, writing the header
+// if the file is new
+func OpenWriter(path string) (*Writer, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create results directory: %v", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open results file: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to stat results file: %v", err)
+	}
+
+	w := &Writer{file: file, csv: csv.NewWriter(file)}
+	if info.Size() == 0 {
+		if err := w.write(Columns); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// Write appends a row, stamping it with the current time if unset
+func (w *Writer) Write(row Row) error {
+	if w == nil {
+		return nil
+	}
+	if row.Time.IsZero() {
+		row.Time = time.Now()
+	}
+	return w.write(row.Record())
+}
+
+func (w *Writer) write(record []string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.csv.Write(record); err != nil {
+		return fmt.Errorf("failed to write results row: %v", err)
+	}
+	w.csv.Flush()
+	if err := w.csv.Error(); err != nil {
+		return fmt.Errorf("failed to write results row: %v", err)
+	}
+	return nil
+}
+
+// Close closes the underlying file
+func (w *Writer) Close() error {
+	if w == nil {
+		return nil
+	}
+	return w.file.Close()
+}
+
+func (w *Writer) String() string {
+	return "results CSV " + w.file.Name()
+}
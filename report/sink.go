@@ -0,0 +1,236 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ResultSink receives the outcome of each entry: a results CSV, a JSONL stream, the log or a
+// webhook. Sinks are driven by a Mux, one goroutine each, so Write may be slow.
+type ResultSink interface {
+	// Write delivers the outcome of an entry
+	Write(row Row) error
+	// Close flushes and releases the sink once every row was written
+	Close() error
+}
+
+// Mux fans each row out to several sinks. Every sink has its own queue and goroutine, so a
+// slow or failing sink never holds up the others or the batch; a failed write is logged and
+// the sink keeps receiving rows. A nil *Mux discards all rows.
+type Mux struct {
+	queues []*sinkQueue
+	wg     sync.WaitGroup
+}
+
+// sinkQueue is the unbounded queue of the rows a sink hasn't written yet
+type sinkQueue struct {
+	name   string
+	sink   ResultSink
+	mu     sync.Mutex
+	rows   []Row
+	closed bool
+	ready  chan struct{} // signaled when rows are queued or the queue closes
+}
+
+// NewMux starts delivering to sinks, named in logs by their String method, else their type.
+// It returns nil, which discards all rows, when there is no sink.
+func NewMux(sinks ...ResultSink) *Mux {
+	if len(sinks) == 0 {
+		return nil
+	}
+	m := &Mux{}
+	for _, sink := range sinks {
+		name := fmt.Sprintf("%T", sink)
+		if s, ok := sink.(fmt.Stringer); ok {
+			name = s.String()
+		}
+		q := &sinkQueue{name: name, sink: sink, ready: make(chan struct{}, 1)}
+		m.queues = append(m.queues, q)
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			q.run()
+		}()
+	}
+	return m
+}
+
+// Write queues a row for every sink, stamping it with the current time if unset. It never
+// blocks on a sink.
+func (m *Mux) Write(row Row) {
+	if m == nil {
+		return
+	}
+	if row.Time.IsZero() {
+		row.Time = time.Now()
+	}
+	for _, q := range m.queues {
+		q.mu.Lock()
+		if !q.closed {
+			q.rows = append(q.rows, row)
+		}
+		q.mu.Unlock()
+		q.signal()
+	}
+}
+
+// Close waits for every sink to write the rows queued, then closes them
+func (m *Mux) Close() error {
+	if m == nil {
+		return nil
+	}
+	for _, q := range m.queues {
+		q.mu.Lock()
+		q.closed = true
+		q.mu.Unlock()
+		q.signal()
+	}
+	m.wg.Wait()
+
+	var errs []error
+	for _, q := range m.queues {
+		if err := q.sink.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close %s: %w", q.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (q *sinkQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// run writes the queued rows until the queue is closed and drained
+func (q *sinkQueue) run() {
+	for {
+		q.mu.Lock()
+		rows, closed := q.rows, q.closed
+		q.rows = nil
+		q.mu.Unlock()
+
+		for _, row := range rows {
+			if err := q.sink.Write(row); err != nil {
+				log.Printf("failed to write result of entry %d to %s: %v", row.ID, q.name, err)
+			}
+		}
+		if closed && len(rows) == 0 {
+			return
+		}
+		if len(rows) == 0 {
+			<-q.ready
+		}
+	}
+}
+
+// JSONLWriter appends one JSON object per row to a file, for data pipelines. Each line has
+// the fields of Columns and the value, and is flushed as it's written.
+type JSONLWriter struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// jsonlRow is the line written for a row
+type jsonlRow struct {
+	ID          int32  `json:"id"`
+	TxHash      string `json:"tx_hash"`
+	Status      string `json:"status"`
+	Value       string `json:"value"`
+	GasUsed     uint64 `json:"gas_used"`
+	Fee         string `json:"fee"`
+	Timestamp   string `json:"timestamp"`
+	Error       string `json:"error,omitempty"`
+	BlockNumber uint64 `json:"block_number,omitempty"`
+	BlockTime   string `json:"block_time,omitempty"`
+}
+
+// OpenJSONLWriter opens (or creates) the JSONL file at path for appending
+func OpenJSONLWriter(path string) (*JSONLWriter, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create results directory: %v", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open results file: %v", err)
+	}
+	return &JSONLWriter{file: file}, nil
+}
+
+// Write appends a row
+func (w *JSONLWriter) Write(row Row) error {
+	line := jsonlRow{
+		ID:          row.ID,
+		TxHash:      row.TxHash,
+		Status:      row.Status,
+		Value:       row.Value.String(),
+		GasUsed:     row.GasUsed,
+		Fee:         row.Fee.String(),
+		Timestamp:   row.Time.UTC().Format(time.RFC3339),
+		Error:       row.Error,
+		BlockNumber: row.BlockNumber,
+	}
+	if !row.BlockTime.IsZero() {
+		line.BlockTime = row.BlockTime.UTC().Format(time.RFC3339)
+	}
+	b, err := json.Marshal(line)
+	if err != nil {
+		return fmt.Errorf("failed to encode results row: %v", err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := w.file.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("failed to write results row: %v", err)
+	}
+	return nil
+}
+
+// Close closes the underlying file
+func (w *JSONLWriter) Close() error {
+	return w.file.Close()
+}
+
+func (w *JSONLWriter) String() string {
+	return "results JSONL " + w.file.Name()
+}
+
+// LogSink logs one line per row
+type LogSink struct{}
+
+// Write logs a row
+func (LogSink) Write(row Row) error {
+	fields := []string{
+		fmt.Sprintf("ID: %d", row.ID),
+		"Status: " + row.Status,
+		"Value: " + row.Value.String() + " wei",
+	}
+	if row.TxHash != "" {
+		fields = append(fields, "Tx Hash: "+row.TxHash)
+	}
+	if row.BlockNumber > 0 {
+		fields = append(fields, fmt.Sprintf("Block: %d", row.BlockNumber), "Fee: "+row.Fee.String()+" wei")
+	}
+	if row.Error != "" {
+		fields = append(fields, "Error: "+row.Error)
+	}
+	log.Printf("📄 RESULT | %s", strings.Join(fields, " | "))
+	return nil
+}
+
+// Close does nothing
+func (LogSink) Close() error {
+	return nil
+}
+
+func (LogSink) String() string {
+	return "results log"
+}
@@ -1,6 +1,8 @@
 package wtypes
 
 import (
+	"math/big"
+
 	"github.com/lib/pq"
 	"github.com/shopspring/decimal"
 )
@@ -12,4 +14,15 @@ type TransferEntry struct {
 	ToAddress      string
 	AggregateIds   pq.Int64Array
 	MinerAccountID uint64
+	// IdempotencyKey optionally identifies the logical payout independently of ID
+	IdempotencyKey string `json:",omitempty"`
+	// GasLimit and GasPrice, in wei, override the estimates of the wallet when set, for
+	// recipients such as contracts that need more than a plain transfer
+	GasLimit uint64   `json:",omitempty"`
+	GasPrice *big.Int `json:",omitempty"`
+	// Data is the calldata of a contract call, such as a token transfer, empty for a payment
+	Data []byte `json:",omitempty"`
+	// TokenAddress is the ERC20 token a token payout pays Value of, in its smallest unit,
+	// empty for a Quai payment
+	TokenAddress string `json:",omitempty"`
 }
@@ -3,3 +3,38 @@ package wtypes
 import "errors"
 
 var ErrAlreadyProcessed = errors.New("already processed")
+
+var ErrInvalidAddress = errors.New("invalid address")
+
+var ErrLedgerMismatch = errors.New("ledger mismatch")
+
+var ErrInvalidEntries = errors.New("batch contains invalid entries")
+
+var ErrDeadLettered = errors.New("dead-lettered")
+
+var ErrGasLimitExceeded = errors.New("gas limit exceeds max_gas_limit")
+
+var ErrInsufficientBalance = errors.New("insufficient balance")
+
+var ErrNodeNotSynced = errors.New("node not synced")
+
+// ErrDryRun is returned by operations on transactions already broadcast, which dry_run can't simulate
+var ErrDryRun = errors.New("not available in a dry run")
+
+// ErrDuplicateID is returned for transfer entries sharing an ID, which deduplicates re-runs
+var ErrDuplicateID = errors.New("duplicate entry ID")
+
+// ErrInvalidValue is returned for a transfer value that is negative, or zero without calldata
+var ErrInvalidValue = errors.New("invalid value")
+
+// ErrStoredTxMismatch is returned for a recorded transaction that doesn't hash to its recorded
+// hash, as after database corruption. Its entry is not signed anew, which could pay it twice.
+var ErrStoredTxMismatch = errors.New("stored transaction hash mismatch")
+
+// ErrReverted is returned for an entry whose recorded transaction was mined but reverted. The
+// entry is unpaid, and it isn't signed again with a fresh nonce; paying it is left to the operator.
+var ErrReverted = errors.New("transaction reverted")
+
+// ErrNonceGap is returned for a Quai entry dead-lettered at broadcast. Its nonce stays reserved but
+// unsent, so no later nonce of the wallet can be mined until the entry is requeued.
+var ErrNonceGap = errors.New("nonce left unsent")
@@ -0,0 +1,21 @@
+package wtypes
+
+// Value units of a transfer CSV
+const (
+	UnitWei  = "wei"
+	UnitGwei = "gwei"
+	UnitQuai = "quai"
+)
+
+// unitExponents is the power of ten of wei in each unit
+var unitExponents = map[string]int32{
+	UnitWei:  0,
+	UnitGwei: 9,
+	UnitQuai: 18,
+}
+
+// UnitExponent returns the power of ten of wei in unit, lowercase, and whether it is known
+func UnitExponent(unit string) (int32, bool) {
+	exp, ok := unitExponents[unit]
+	return exp, ok
+}
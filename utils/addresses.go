@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dominant-strategies/go-quai/common"
+)
+
+// ReadAddressFile reads a list of addresses, one per line. Blank lines and lines starting with
+// '#' are skipped, as is anything after the first comma, so the first column of a CSV works
+// too. Duplicates are dropped and every address is bound to the location of its own prefix.
+func ReadAddressFile(path string) ([]common.Address, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open address file: %w", err)
+	}
+	defer file.Close()
+
+	var addresses []common.Address
+	seen := make(map[common.AddressBytes]bool)
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text, _, _ := strings.Cut(scanner.Text(), ",")
+		text = strings.TrimSpace(text)
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if !common.IsHexAddress(text) {
+			// Tolerate a header row
+			if line == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("invalid address on line %d: %s", line, text)
+		}
+
+		b := common.FromHex(text)
+		address := common.BytesToAddress(b, common.LocationFromAddressBytes(b))
+		if seen[address.Bytes20()] {
+			continue
+		}
+		seen[address.Bytes20()] = true
+		addresses = append(addresses, address)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read address file: %w", err)
+	}
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("no addresses in %s", path)
+	}
+	return addresses, nil
+}
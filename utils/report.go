@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"quai-transfer/dal/models"
+)
+
+// TransferReportColumns are the columns of the transfer report, values in Quai and gas
+// prices in wei
+var TransferReportColumns = []string{
+	"id", "payer", "to_address", "tx_hash", "nonce", "value", "gas_used", "gas_price", "status", "confirmed_at",
+	"block_number", "block_time", "failure_code", "failure_reason", "gas_limit", "gas_estimate",
+}
+
+// WriteTransferReportCSV writes the transaction records to a CSV at path, replacing any
+// previous file. Times are RFC 3339 and left empty while unset.
+func WriteTransferReportCSV(path string, txs []*models.Transaction) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create report directory: %w", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create report file: %w", err)
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	if err := w.Write(TransferReportColumns); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	for _, tx := range txs {
+		var blockNumber string
+		if tx.BlockNumber > 0 {
+			blockNumber = strconv.FormatUint(tx.BlockNumber, 10)
+		}
+		record := []string{
+			strconv.FormatInt(int64(tx.ID), 10),
+			tx.Payer,
+			tx.ToAddress,
+			tx.TxHash,
+			strconv.FormatUint(tx.Nonce, 10),
+			ToQuai(tx.Value.BigInt()).String(),
+			tx.GasUsed.String(),
+			tx.GasPrice.String(),
+			tx.Status.String(),
+			formatTime(tx.ConfirmedAt),
+			blockNumber,
+			formatTime(tx.BlockTime),
+			tx.FailureCode,
+			tx.FailureReason,
+			tx.GasLimit.String(),
+			tx.GasEstimate.String(),
+		}
+		if err := w.Write(record); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return file.Close()
+}
+
+// formatTime formats an optional time as RFC 3339, empty when unset
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
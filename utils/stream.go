@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"quai-transfer/types"
+)
+
+// ParseTransferStream parses a transfer CSV one row at a time, so memory stays bounded however
+// large the input is. ParseTransferCSV is simpler for small files. Entries are sent as they
+// are parsed; the error channel receives at most one error, and both channels are closed
+// once the input is exhausted, a row fails to parse or ctx is done. Drain the entries before
+// reading the error.
+func ParseTransferStream(ctx context.Context, r io.Reader, opts CSVOptions) (<-chan *wtypes.TransferEntry, <-chan error) {
+	entries := make(chan *wtypes.TransferEntry, 64)
+	errc := make(chan error, 1)
+
+	go func() {
+		defer close(errc)
+		defer close(entries)
+
+		err := readTransfers(r, opts, func(entry *wtypes.TransferEntry) error {
+			select {
+			case entries <- entry:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil {
+			errc <- err
+		}
+	}()
+
+	return entries, errc
+}
+
+// StreamTransferCSV reads a transfer CSV through ParseTransferStream and calls fn with each
+// entry, so memory stays flat however large the file is. The header is validated once,
+// before the first row. It stops at the first row that fails to parse, the first error of
+// fn or once ctx is done, and returns the reason.
+func StreamTransferCSV(ctx context.Context, path string, opts CSVOptions, fn func(*wtypes.TransferEntry) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open CSV file: %w", err)
+	}
+	defer file.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	entries, errc := ParseTransferStream(ctx, file, opts)
+	for entry := range entries {
+		if err := fn(entry); err != nil {
+			// Let the parser stop before the file is closed
+			cancel()
+			for range entries {
+			}
+			return err
+		}
+	}
+	return <-errc
+}
+
+// readTransfers parses the rows of a transfer CSV in order and calls fn with each entry
+func readTransfers(r io.Reader, opts CSVOptions, fn func(*wtypes.TransferEntry) error) error {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return errors.New("CSV file must contain at least a header row and one data row")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	headerLen := len(header)
+	columns, err := transferColumns(header)
+	if err != nil {
+		return err
+	}
+
+	rows := 0
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read CSV file: %w", err)
+		}
+		entry, err := parseTransferRecord(columns, headerLen, record, opts)
+		if err != nil {
+			return fmt.Errorf("row %d: %w", rows+1, err)
+		}
+		rows++
+
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	if rows == 0 {
+		return errors.New("CSV file must contain at least a header row and one data row")
+	}
+	return nil
+}
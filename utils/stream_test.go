@@ -0,0 +1,229 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	wtypes "quai-transfer/types"
+)
+
+const testTransferHeader = "id,miner_account,value,to_address,aggregate_ids,miner_account_id"
+
+// testTransferRow is row i of a generated transfer CSV
+func testTransferRow(i int) string {
+	return fmt.Sprintf("%d,miner%d,%d,0x0063983e573E5AB68efF7057C7ccE0902256e029,%d %d,%d", i, i, 1000+i, 2*i, 2*i+1, i)
+}
+
+// testTransferCSV returns a transfer CSV of the generated rows 1 to n
+func testTransferCSV(n int) string {
+	var b strings.Builder
+	b.WriteString(testTransferHeader + "\n")
+	for i := 1; i <= n; i++ {
+		b.WriteString(testTransferRow(i) + "\n")
+	}
+	return b.String()
+}
+
+// drain reads every entry of a stream, then its error
+func drain(entries <-chan *wtypes.TransferEntry, errc <-chan error) ([]*wtypes.TransferEntry, error) {
+	var got []*wtypes.TransferEntry
+	for entry := range entries {
+		got = append(got, entry)
+	}
+	return got, <-errc
+}
+
+func TestParseTransferStream(t *testing.T) {
+	entries, err := drain(ParseTransferStream(context.Background(), strings.NewReader(testTransferCSV(100)), CSVOptions{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 100 {
+		t.Fatalf("%d entries, want 100", len(entries))
+	}
+	for i, entry := range entries {
+		if entry.ID != int32(i+1) || entry.MinerAccount != fmt.Sprintf("miner%d", i+1) || entry.Value.IntPart() != int64(1001+i) {
+			t.Fatalf("entry %d is %+v, out of order or misparsed", i, entry)
+		}
+	}
+}
+
+func TestParseTransferStreamBadRow(t *testing.T) {
+	csv := testTransferHeader + "\n" + testTransferRow(1) + "\n" + testTransferRow(2) + "\n3,miner3,x,0x00,,3\n" + testTransferRow(4) + "\n"
+	entries, err := drain(ParseTransferStream(context.Background(), strings.NewReader(csv), CSVOptions{}))
+	if err == nil || !strings.Contains(err.Error(), "row 3") {
+		t.Fatalf("error %v, want one of row 3", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("%d entries before the bad row, want 2", len(entries))
+	}
+}
+
+func TestParseTransferStreamEmpty(t *testing.T) {
+	entries, err := drain(ParseTransferStream(context.Background(), strings.NewReader(testTransferHeader+"\n"), CSVOptions{}))
+	if err == nil {
+		t.Fatal("CSV without rows accepted")
+	}
+	if len(entries) > 0 {
+		t.Errorf("%d entries from a CSV without rows", len(entries))
+	}
+}
+
+func TestParseTransferStreamCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	entries, errc := ParseTransferStream(ctx, strings.NewReader(testTransferCSV(1000)), CSVOptions{})
+	first := <-entries
+	if first == nil || first.ID != 1 {
+		t.Fatalf("first entry %+v, want ID 1", first)
+	}
+	cancel()
+
+	// Nothing reads the entries, so the parser stops once the buffer is full
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("error %v, want %v", err, context.Canceled)
+	}
+	n := 1
+	for range entries {
+		n++
+	}
+	if n >= 1000 {
+		t.Errorf("all %d entries sent after cancel", n)
+	}
+	if _, ok := <-errc; ok {
+		t.Error("error channel not closed")
+	}
+}
+
+func TestStreamTransferCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transfers.csv")
+	if err := os.WriteFile(path, []byte(testTransferCSV(500)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []int32
+	if err := StreamTransferCSV(context.Background(), path, CSVOptions{}, func(entry *wtypes.TransferEntry) error {
+		ids = append(ids, entry.ID)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 500 || ids[0] != 1 || ids[499] != 500 {
+		t.Fatalf("read %d entries, want 1 to 500 in order", len(ids))
+	}
+
+	stop := errors.New("stop")
+	n := 0
+	err := StreamTransferCSV(context.Background(), path, CSVOptions{}, func(entry *wtypes.TransferEntry) error {
+		if n++; entry.ID == 10 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) {
+		t.Fatalf("error %v, want the one of fn", err)
+	}
+	if n != 10 {
+		t.Errorf("fn called %d times, want it to stop at the 10th entry", n)
+	}
+}
+
+// benchmarkRows is the size of the generated CSV of the benchmarks, about 20 MB
+const benchmarkRows = 200_000
+
+// writeBenchmarkCSV writes the generated CSV of the benchmarks to a temporary file
+func writeBenchmarkCSV(b *testing.B) string {
+	b.Helper()
+	path := filepath.Join(b.TempDir(), "transfers.csv")
+	file, err := os.Create(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	w := bufio.NewWriter(file)
+	fmt.Fprintln(w, testTransferHeader)
+	for i := 1; i <= benchmarkRows; i++ {
+		fmt.Fprintln(w, testTransferRow(i))
+	}
+	if err := w.Flush(); err != nil {
+		b.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		b.Fatal(err)
+	}
+	return path
+}
+
+// heapSampleRows is how many rows pass between samples of the live heap while streaming
+const heapSampleRows = 10_000
+
+// liveHeap returns the heap in use after a collection
+func liveHeap() uint64 {
+	var stats runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+// reportLiveHeap reports the largest live heap seen while parsing, over the heap before it
+func reportLiveHeap(b *testing.B, before, peak uint64) {
+	b.ReportMetric(float64(peak-min(before, peak))/(1<<20), "live-heap-MB")
+}
+
+// BenchmarkParseTransferStream parses the generated CSV row by row, keeping no entry, as the
+// batch pipeline does. Its live-heap-MB, sampled along the way, compares with the one of
+// BenchmarkParseTransferCSV.
+func BenchmarkParseTransferStream(b *testing.B) {
+	path := writeBenchmarkCSV(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		file, err := os.Open(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		before := liveHeap()
+		peak := before
+
+		entries, errc := ParseTransferStream(context.Background(), file, CSVOptions{})
+		n := 0
+		for range entries {
+			if n++; n%heapSampleRows == 0 {
+				peak = max(peak, liveHeap())
+			}
+		}
+		if err := <-errc; err != nil {
+			b.Fatal(err)
+		}
+		reportLiveHeap(b, before, peak)
+		file.Close()
+		if n != benchmarkRows {
+			b.Fatalf("%d entries, want %d", n, benchmarkRows)
+		}
+	}
+}
+
+// BenchmarkParseTransferCSV parses the generated CSV whole, holding every entry at once
+func BenchmarkParseTransferCSV(b *testing.B) {
+	path := writeBenchmarkCSV(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		before := liveHeap()
+
+		entries, err := ParseTransferCSV(path, CSVOptions{})
+		if err != nil {
+			b.Fatal(err)
+		}
+		reportLiveHeap(b, before, liveHeap())
+		if len(entries) != benchmarkRows {
+			b.Fatalf("%d entries, want %d", len(entries), benchmarkRows)
+		}
+		runtime.KeepAlive(entries)
+	}
+}
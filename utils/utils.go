@@ -12,11 +12,26 @@ import (
 
 	"quai-transfer/types"
 
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/common/hexutil"
 	"github.com/fatih/color"
 	"github.com/shopspring/decimal"
 )
 
-func ParseTransferCSV(filepath string) ([]*wtypes.TransferEntry, error) {
+// CSVOptions controls how the values of a transfer CSV are read
+type CSVOptions struct {
+	// Unit is the unit of the value of rows without a unit column value, wei when empty
+	Unit string
+	// Strict rejects rows whose unit is missing, or differs from Unit
+	Strict bool
+	// AddressBook maps lowercased recipient names to addresses. With one, a to_address that
+	// isn't a hex address must be one of its names.
+	AddressBook map[string]string
+}
+
+// ParseTransferCSV reads a whole transfer CSV. Values are converted to wei from the unit of
+// their row, see CSVOptions.
+func ParseTransferCSV(filepath string, opts CSVOptions) ([]*wtypes.TransferEntry, error) {
 	file, err := os.Open(filepath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open CSV file: %w", err)
@@ -35,60 +50,229 @@ func ParseTransferCSV(filepath string) ([]*wtypes.TransferEntry, error) {
 
 	// Validate header
 	header := records[0]
-	expectedHeaders := []string{"id", "miner_account", "value", "to_address", "aggregate_ids", "miner_account_id"}
-	if !validateHeaders(header, expectedHeaders) {
-		return nil, fmt.Errorf("invalid CSV headers, expected: %v", expectedHeaders)
+	columns, err := transferColumns(header)
+	if err != nil {
+		return nil, err
 	}
 
 	transfers := make([]*wtypes.TransferEntry, 0, len(records)-1)
 	for _, record := range records[1:] {
-		if len(record) != len(expectedHeaders) {
-			return nil, fmt.Errorf("invalid record length: %v", record)
+		transfer, err := parseTransferRecord(columns, len(header), record, opts)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	return transfers, nil
+}
+
+// parseTransferRecord parses one data row of a transfer CSV, using the column indexes
+// returned by validateHeaders
+func parseTransferRecord(columns map[string]int, headerLen int, record []string, opts CSVOptions) (*wtypes.TransferEntry, error) {
+	if len(record) != headerLen {
+		return nil, fmt.Errorf("invalid record length: %v", record)
+	}
+	field := func(name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(record[i])
 		}
+		return ""
+	}
 
-		minerAccountID, err := strconv.ParseUint(record[5], 10, 64)
+	minerAccountID, err := strconv.ParseUint(field("miner_account_id"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse miner_account_id: %w", err)
+	}
+
+	aggregateIds := make([]int64, 0)
+	for _, id := range strings.Fields(field("aggregate_ids")) {
+		aggregateId, err := strconv.ParseInt(id, 10, 64)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse miner_account_id: %w", err)
+			return nil, fmt.Errorf("failed to parse aggregate_id: %w", err)
 		}
+		aggregateIds = append(aggregateIds, aggregateId)
+	}
 
-		aggregateIds := make([]int64, 0)
-		for _, id := range strings.Fields(record[4]) {
-			aggregateId, err := strconv.ParseInt(id, 10, 64)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse aggregate_id: %w", err)
-			}
-			aggregateIds = append(aggregateIds, aggregateId)
+	id, err := strconv.ParseInt(field("id"), 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse id: %w", err)
+	}
+
+	unit, err := rowUnit(field("unit"), opts)
+	if err != nil {
+		return nil, fmt.Errorf("entry %d: %w", id, err)
+	}
+	value, err := ParseValue(field("value"), unit)
+	if err != nil {
+		return nil, fmt.Errorf("entry %d: %w", id, err)
+	}
+	toAddress, err := resolveAddress(field("to_address"), opts.AddressBook)
+	if err != nil {
+		return nil, fmt.Errorf("entry %d: %w", id, err)
+	}
+
+	var gasLimit uint64
+	if s := field("gas_limit"); s != "" {
+		if gasLimit, err = strconv.ParseUint(s, 10, 64); err != nil || gasLimit == 0 {
+			return nil, fmt.Errorf("entry %d: invalid gas_limit %q, must be a positive whole number", id, s)
+		}
+	}
+	var gasPrice *big.Int
+	if s := field("gas_price"); s != "" {
+		price, err := ParseValue(s, wtypes.UnitWei)
+		if err != nil || !price.IsPositive() {
+			return nil, fmt.Errorf("entry %d: invalid gas_price %q, must be a positive whole number of wei", id, s)
+		}
+		gasPrice = price.BigInt()
+	}
+	var data []byte
+	if s := field("data"); s != "" {
+		if data, err = hexutil.Decode(s); err != nil {
+			return nil, fmt.Errorf("entry %d: invalid data %q, must be 0x and hex digits: %w", id, s, err)
 		}
+	}
+	tokenAddress := field("token_address")
+	if tokenAddress != "" {
+		if !common.IsHexAddress(tokenAddress) {
+			return nil, fmt.Errorf("entry %d: invalid token_address %q", id, tokenAddress)
+		}
+		if data != nil {
+			return nil, fmt.Errorf("entry %d: token_address and data can't both be set, a token payout encodes its own call", id)
+		}
+	}
 
-		id, err := strconv.ParseInt(record[0], 10, 32)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse id: %w", err)
+	return &wtypes.TransferEntry{
+		ID:             int32(id),
+		MinerAccount:   field("miner_account"),
+		Value:          value,
+		ToAddress:      toAddress,
+		AggregateIds:   aggregateIds,
+		MinerAccountID: minerAccountID,
+		IdempotencyKey: field("idempotency_key"),
+		GasLimit:       gasLimit,
+		GasPrice:       gasPrice,
+		Data:           data,
+		TokenAddress:   tokenAddress,
+	}, nil
+}
+
+var (
+	// expectedHeaders are the columns every transfer CSV must contain
+	expectedHeaders = []string{"id", "miner_account", "value", "to_address", "aggregate_ids", "miner_account_id"}
+	// optionalHeaders are the columns a transfer CSV may contain in addition
+	optionalHeaders = []string{"idempotency_key", "unit", "value_unit", "gas_limit", "gas_price", "data", "token_address"}
+)
+
+// transferColumns validates the header of a transfer CSV and returns the column index of each
+// header. value_unit is another name of the unit column.
+func transferColumns(header []string) (map[string]int, error) {
+	columns, err := validateHeaders(header, expectedHeaders, optionalHeaders)
+	if err != nil {
+		return nil, err
+	}
+	if i, ok := columns["value_unit"]; ok {
+		if _, dup := columns["unit"]; dup {
+			return nil, fmt.Errorf("invalid CSV headers: unit and value_unit are the same column, keep one")
 		}
+		columns["unit"] = i
+	}
+	return columns, nil
+}
 
-		transfer := &wtypes.TransferEntry{
-			ID:             int32(id),
-			MinerAccount:   record[1],
-			Value:          decimal.RequireFromString(record[2]),
-			ToAddress:      record[3],
-			AggregateIds:   aggregateIds,
-			MinerAccountID: minerAccountID,
+// rowUnit returns the unit of a row's value: its unit column, else the unit of the file, else
+// wei. In strict mode a row must have a unit and may not contradict the one of the file.
+func rowUnit(unit string, opts CSVOptions) (string, error) {
+	unit = strings.ToLower(unit)
+	fileUnit := strings.ToLower(opts.Unit)
+	if opts.Strict {
+		if unit == "" && fileUnit == "" {
+			return "", fmt.Errorf("value has no unit, strict validation requires a unit column or --input-unit")
 		}
-		transfers = append(transfers, transfer)
+		if unit != "" && fileUnit != "" && unit != fileUnit {
+			return "", fmt.Errorf("ambiguous unit, the row says %s but --input-unit says %s", unit, fileUnit)
+		}
+	}
+	if unit == "" {
+		unit = fileUnit
 	}
+	if unit == "" {
+		unit = wtypes.UnitWei
+	}
+	return unit, nil
+}
 
-	return transfers, nil
+// resolveAddress returns the address of a to_address, looked up by name in book unless it's
+// already a hex address. Without a book it's returned as is, to be validated later.
+func resolveAddress(toAddress string, book map[string]string) (string, error) {
+	if book == nil || common.IsHexAddress(toAddress) {
+		return toAddress, nil
+	}
+	address, ok := book[strings.ToLower(toAddress)]
+	if !ok {
+		return "", fmt.Errorf("unresolved recipient %q: not a hex address nor a name of the address book", toAddress)
+	}
+	return address, nil
 }
 
-func validateHeaders(actual, expected []string) bool {
-	if len(actual) != len(expected) {
-		return false
+// ParseValue converts a value in unit, wei, gwei or quai, to wei. It must be a non-negative
+// decimal that is a whole number of wei.
+func ParseValue(value string, unit string) (decimal.Decimal, error) {
+	exp, ok := wtypes.UnitExponent(strings.ToLower(unit))
+	if !ok {
+		return decimal.Zero, fmt.Errorf("invalid unit %q, must be %s, %s or %s", unit, wtypes.UnitWei, wtypes.UnitGwei, wtypes.UnitQuai)
+	}
+	amount, err := decimal.NewFromString(value)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("invalid value %q: %w", value, err)
+	}
+	if amount.IsNegative() {
+		return decimal.Zero, fmt.Errorf("invalid value %q: negative", value)
+	}
+	wei := amount.Shift(exp)
+	if !wei.IsInteger() {
+		return decimal.Zero, fmt.Errorf("invalid value %q %s: not a whole number of wei", value, unit)
 	}
+	return wei.Truncate(0), nil
+}
+
+// validateHeaders checks that all expected headers are present, in any order, and that every
+// other header is a known optional one. It returns the column index of each header.
+func validateHeaders(actual, expected, optional []string) (map[string]int, error) {
+	known := make(map[string]bool, len(expected)+len(optional))
+	for _, header := range append(append([]string{}, expected...), optional...) {
+		known[header] = true
+	}
+
+	columns := make(map[string]int, len(actual))
 	for i, header := range actual {
-		if strings.ToLower(header) != expected[i] {
-			return false
+		header = strings.ToLower(strings.TrimSpace(header))
+		if !known[header] {
+			return nil, fmt.Errorf("invalid CSV headers: unknown column %q, expected: %v, optional: %v", header, expected, optional)
+		}
+		if _, dup := columns[header]; dup {
+			return nil, fmt.Errorf("invalid CSV headers: duplicate column %q", header)
+		}
+		columns[header] = i
+	}
+
+	for _, header := range expected {
+		if _, ok := columns[header]; !ok {
+			return nil, fmt.Errorf("invalid CSV headers: missing column %q, expected: %v", header, expected)
 		}
 	}
-	return true
+	return columns, nil
+}
+
+// ColorEnabled reports whether output may carry ANSI colors and escape codes. It is false
+// when NO_COLOR is set, TERM is dumb, stdout isn't a terminal, or after DisableColor.
+func ColorEnabled() bool {
+	return !color.NoColor
+}
+
+// DisableColor turns colored output off for the rest of the process
+func DisableColor() {
+	color.NoColor = true
 }
 
 func Json(a ...any) {
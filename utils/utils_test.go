@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"testing"
+
+	wtypes "quai-transfer/types"
+)
+
+func TestRowUnit(t *testing.T) {
+	tests := []struct {
+		name     string
+		strict   bool
+		unit     string // unit column of the row
+		fileUnit string // --input-unit
+		want     string
+		wantErr  bool
+	}{
+		{name: "no unit defaults to wei", want: wtypes.UnitWei},
+		{name: "no unit, strict", strict: true, wantErr: true},
+		{name: "file unit", fileUnit: "QUAI", want: wtypes.UnitQuai},
+		{name: "file unit, strict", strict: true, fileUnit: "quai", want: wtypes.UnitQuai},
+		{name: "row unit", unit: "gwei", want: wtypes.UnitGwei},
+		{name: "row unit, strict", strict: true, unit: "Gwei", want: wtypes.UnitGwei},
+		{name: "row unit overrides file unit", unit: "gwei", fileUnit: "quai", want: wtypes.UnitGwei},
+		{name: "row unit contradicts file unit, strict", strict: true, unit: "gwei", fileUnit: "quai", wantErr: true},
+		{name: "row unit agrees with file unit, strict", strict: true, unit: "quai", fileUnit: "Quai", want: wtypes.UnitQuai},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rowUnit(tt.unit, CSVOptions{Unit: tt.fileUnit, Strict: tt.strict})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error %v, want error %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("unit %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
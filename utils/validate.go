@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+
+	wtypes "quai-transfer/types"
+)
+
+// DestinationValidator checks that an address can be paid, as wallet.Wallet does for its
+// location and protocol
+type DestinationValidator interface {
+	ValidateDestination(address string) error
+}
+
+// ValidateTransfers checks parsed entries before anything is sent: every ID must be unique,
+// since IDs deduplicate re-runs and a repeated one would be skipped as already processed,
+// every value must be positive, or not negative for a contract call with data, and every
+// destination valid for v. Destinations aren't checked
+// without v. It returns every problem found, not only the first.
+func ValidateTransfers(entries []*wtypes.TransferEntry, v DestinationValidator) error {
+	var problems []error
+	seen := make(map[int32]bool, len(entries))
+	for _, entry := range entries {
+		if seen[entry.ID] {
+			problems = append(problems, fmt.Errorf("entry %d: %w", entry.ID, wtypes.ErrDuplicateID))
+		}
+		seen[entry.ID] = true
+
+		if entry.Value.IsNegative() || (entry.Value.IsZero() && len(entry.Data) == 0) {
+			problems = append(problems, fmt.Errorf("entry %d: %w: %s wei, must be positive, or zero for a call with data", entry.ID, wtypes.ErrInvalidValue, entry.Value))
+		}
+		if v != nil {
+			if err := v.ValidateDestination(entry.ToAddress); err != nil {
+				problems = append(problems, fmt.Errorf("entry %d: %w", entry.ID, err))
+			}
+			if entry.TokenAddress != "" {
+				if err := v.ValidateDestination(entry.TokenAddress); err != nil {
+					problems = append(problems, fmt.Errorf("entry %d: token: %w", entry.ID, err))
+				}
+			}
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d problems in the transfer entries:\n%w", len(problems), errors.Join(problems...))
+}
@@ -0,0 +1,159 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	wtypes "quai-transfer/types"
+	"quai-transfer/utils"
+
+	"github.com/dominant-strategies/go-quai/quaiclient/ethclient"
+	"github.com/shopspring/decimal"
+)
+
+// entryTotals is the number and total value of a set of entries, all the balance checks need
+// to know about them
+type entryTotals struct {
+	count int
+	value decimal.Decimal
+
+	// What the entries overriding their gas add up to: the gas limits of those setting only a
+	// gas limit, the gas prices of those setting only a gas price, and the fees of those
+	// setting both. The other entries count as plain transfers.
+	gas    decimal.Decimal
+	prices decimal.Decimal
+	fees   decimal.Decimal
+	plain  int
+}
+
+func totalsOf(entries []*wtypes.TransferEntry) entryTotals {
+	totals := entryTotals{value: decimal.Zero}
+	for _, entry := range entries {
+		totals.add(entry)
+	}
+	return totals
+}
+
+func (t *entryTotals) add(entry *wtypes.TransferEntry) {
+	t.count++
+	t.value = t.value.Add(nativeValue(entry))
+	t.addGas(entry, 1)
+}
+
+func (t *entryTotals) remove(entry *wtypes.TransferEntry) {
+	t.count--
+	t.value = t.value.Sub(nativeValue(entry))
+	t.addGas(entry, -1)
+}
+
+// nativeValue returns the Quai an entry pays, nothing for a token payout
+func nativeValue(entry *wtypes.TransferEntry) decimal.Decimal {
+	if entry.TokenAddress != "" {
+		return decimal.Zero
+	}
+	return entry.Value
+}
+
+func (t *entryTotals) addGas(entry *wtypes.TransferEntry, sign int64) {
+	gasLimit := decimal.NewFromInt(sign).Mul(decimal.NewFromUint64(entry.GasLimit))
+	switch {
+	case entry.GasLimit > 0 && entry.GasPrice != nil:
+		t.fees = t.fees.Add(gasLimit.Mul(decimal.NewFromBigInt(entry.GasPrice, 0)))
+	case entry.GasLimit > 0:
+		t.gas = t.gas.Add(gasLimit)
+	case entry.GasPrice != nil:
+		t.prices = t.prices.Add(decimal.NewFromInt(sign).Mul(decimal.NewFromBigInt(entry.GasPrice, 0)))
+	default:
+		t.plain += int(sign)
+	}
+}
+
+// fee returns the fee allowance of the entries, at gasLimit and gasPrice for what they don't
+// override
+func (t *entryTotals) fee(gasLimit uint64, gasPrice decimal.Decimal) decimal.Decimal {
+	limit := decimal.NewFromUint64(gasLimit)
+	return limit.Mul(gasPrice).Mul(decimal.NewFromInt(int64(t.plain))).
+		Add(t.gas.Mul(gasPrice)).
+		Add(t.prices.Mul(limit)).
+		Add(t.fees)
+}
+
+// requiredBalance returns the value of the entries plus a generous fee allowance
+func (w *Wallet) requiredBalance(ctx context.Context, totals entryTotals) (decimal.Decimal, error) {
+	gasLimit, gasPrice, err := w.feeAllowance(ctx)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return totals.value.Add(totals.fee(gasLimit, gasPrice)), nil
+}
+
+// feeAllowance returns the gas limit and gas price the balance checks budget an entry for: the
+// transfer gas limit at ten times the suggested gas price. Entries overriding their gas limit
+// or gas price are budgeted with their own.
+func (w *Wallet) feeAllowance(ctx context.Context) (uint64, decimal.Decimal, error) {
+	gasPrice, err := w.SuggestGasPrice(ctx)
+	if err != nil {
+		return 0, decimal.Zero, fmt.Errorf("failed to get gas price: %w", err)
+	}
+	gasLimit, err := w.transferGasLimit(ctx)
+	if err != nil {
+		return 0, decimal.Zero, fmt.Errorf("failed to get gas limit: %w", err)
+	}
+
+	// to make sure we have enough balance, we multiply the gas price by 10
+	return gasLimit, decimal.NewFromBigInt(gasPrice, 0).Mul(decimal.NewFromInt(10)), nil
+}
+
+// awaitBalance re-checks the balance once balance_check_interval has elapsed since the last
+// check (or the start of the batch), and blocks while it can no longer cover the remaining entries. The pending balance
+// is used, so transactions already broadcast by this batch are accounted for. A prioritized batch
+// stops instead of blocking, leaving the lower priority entries for a later run.
+func (w *Wallet) awaitBalance(ctx context.Context, next *wtypes.TransferEntry, remaining entryTotals, sent int) error {
+	if w.config.BalanceCheckInterval <= 0 || remaining.count == 0 {
+		return nil
+	}
+	paused := false
+	for time.Since(w.balanceCheckedAt) >= w.config.BalanceCheckInterval {
+		w.balanceCheckedAt = time.Now()
+
+		balance, err := withRetry(ctx, w, "get pending balance", func(client *ethclient.Client) (*big.Int, error) {
+			return client.PendingBalanceAt(ctx, w.address.MixedcaseAddress())
+		})
+		if err != nil {
+			return fmt.Errorf("failed to re-check balance: %w", err)
+		}
+		required, err := w.requiredBalance(ctx, remaining)
+		if err != nil {
+			return err
+		}
+
+		have := decimal.NewFromBigInt(balance, 0)
+		if !have.LessThan(required) {
+			if paused {
+				log.Printf("▶️ BROADCAST RESUMED | Balance: %s Quai | Needed: %s Quai", utils.ToQuai(have.String()), utils.ToQuai(required.String()))
+			}
+			return nil
+		}
+
+		if w.config.Priority != "" {
+			log.Printf("✂️ BALANCE CUTOFF | Before entry ID %d after %d sent | Balance: %s Quai | Needed for %d remaining entries: %s Quai",
+				next.ID, sent, utils.ToQuai(have.String()), remaining.count, utils.ToQuai(required.String()))
+			return fmt.Errorf("%w: cut off before entry %d by %s priority", wtypes.ErrInsufficientBalance, next.ID, w.config.Priority)
+		}
+		if !paused {
+			paused = true
+			log.Printf("⚠️ BALANCE INSUFFICIENT | Before entry ID %d after %d sent | Balance: %s Quai | Needed for %d remaining entries: %s Quai | Pausing broadcast",
+				next.ID, sent, utils.ToQuai(have.String()), remaining.count, utils.ToQuai(required.String()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("balance stayed insufficient for entry %d: %w", next.ID, ctx.Err())
+		case <-time.After(w.config.BalanceCheckInterval):
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+
+	"quai-transfer/report"
+	wtypes "quai-transfer/types"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/shopspring/decimal"
+)
+
+// CancelTransaction evicts the pending transaction of the wallet at nonce by sending a
+// zero-value transfer to the wallet itself at the same nonce and a higher gas price. Once the
+// cancel transaction is mined, the later nonces that waited behind the stuck one can be mined
+// too. The original record is dead-lettered with the cancelled failure code and the hash of
+// the cancel transaction, so the entry can be looked into and requeued; its nonce is spent,
+// so a requeue signs it anew with a fresh one, see SpentNonceFailureCodes.
+func (w *Wallet) CancelTransaction(ctx context.Context, nonce uint64, gasPrice *big.Int) (*types.Transaction, error) {
+	if w.config.DryRun {
+		return nil, fmt.Errorf("%w: a cancel transaction must be broadcast", wtypes.ErrDryRun)
+	}
+	record, err := w.txDAL.GetPendingByNonce(ctx, w.address.Hex(), nonce)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, fmt.Errorf("no pending transaction of %s at nonce %d", w.address.Hex(), nonce)
+	}
+	mined, err := w.rpc().NonceAt(ctx, w.address.MixedcaseAddress(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mined nonce: %w", err)
+	}
+	if nonce < mined {
+		return nil, fmt.Errorf("nonce %d is already mined, the next nonce on chain is %d", nonce, mined)
+	}
+	if gasPrice == nil || decimal.NewFromBigInt(gasPrice, 0).LessThanOrEqual(record.GasPrice) {
+		return nil, fmt.Errorf("gas price %v must be higher than the original %s wei", gasPrice, record.GasPrice)
+	}
+
+	gas, err := w.transferGasLimit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gas limit: %w", err)
+	}
+	minerTip := w.minerTipFor(gasPrice)
+	if minerTip.Cmp(gasPrice) > 0 {
+		minerTip = new(big.Int).Set(gasPrice)
+	}
+	self := w.address
+	cancelTx, err := types.SignTx(buildTx(TxParams{
+		Type:     QuaiTxType,
+		ChainID:  w.chainID.Actual,
+		Nonce:    nonce,
+		GasPrice: gasPrice,
+		MinerTip: minerTip,
+		Gas:      gas,
+		To:       &self,
+		Value:    big.NewInt(0),
+	}), types.NewSigner(w.chainID.Actual, w.location), w.privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign cancel transaction: %v", err)
+	}
+
+	if err := w.BroadcastTransaction(ctx, cancelTx); err != nil && ClassifyRPCError(err) != RPCErrorKnown {
+		return nil, fmt.Errorf("failed to broadcast cancel transaction: %w", err)
+	}
+	w.metrics.Broadcast()
+	log.Printf("🚫 CANCELLED | ID: %d | Nonce: %d | Tx Hash: %s | Cancel Hash: %s | Gas Price: %s -> %s wei",
+		record.ID, nonce, record.TxHash, cancelTx.Hash().Hex(), record.GasPrice, gasPrice)
+
+	reason := fmt.Errorf("cancelled by transaction %s", cancelTx.Hash().Hex())
+	err = w.retryDBWrite(ctx, "dead-letter cancelled tx "+record.TxHash, func() error {
+		return w.txDAL.MarkDeadLetter(ctx, record.TxHash, FailureCancelled, reason.Error())
+	})
+	if err != nil {
+		log.Printf("🚨 RECORD OUT OF SYNC | Tx Hash: %s | The transaction was cancelled but its record is still pending, dead-letter it manually | Error: %v",
+			record.TxHash, err)
+	}
+
+	// The nonce now belongs to the cancel transaction and stays reserved
+	w.nonceMutex.Lock()
+	w.pendingNonces[nonce] = struct{}{}
+	w.maxLocalNonce = max(w.maxLocalNonce, nonce)
+	w.nonceMutex.Unlock()
+
+	originalHash := common.HexToHash(record.TxHash)
+	w.pendingTxMutex.Lock()
+	pendingTx, ok := w.pendingTxs[originalHash]
+	if ok {
+		delete(w.pendingTxs, originalHash)
+		w.abandoned++
+	}
+	w.pendingTxMutex.Unlock()
+	if ok {
+		w.recordFailure(pendingTx.Entry, report.StatusDeadLettered, reason)
+	}
+	return cancelTx, nil
+}
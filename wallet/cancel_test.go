@@ -0,0 +1,110 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"quai-transfer/config"
+	"quai-transfer/dal/models"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/crypto"
+	"github.com/shopspring/decimal"
+)
+
+// newCancelWallet returns a wallet holding the key of testRecord, whose recorded transaction of
+// entry 1 is pending at nonce 1 while the node has mined nonce 0
+func newCancelWallet(t *testing.T) (*Wallet, *fakeNode, *fakeDB, *models.Transaction) {
+	t.Helper()
+	key, err := crypto.HexToECDSA("13221fe46bde6a5de07d45248101760b6e32ccd6d36e97ae6950ba95298e4da6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := newFakeNode()
+	node.handle("quai_getTransactionCount", func(params []json.RawMessage) (any, error) {
+		if string(params[1]) == `"pending"` {
+			return "0x2", nil
+		}
+		return "0x1", nil
+	})
+	node.handle("quai_estimateGas", func([]json.RawMessage) (any, error) { return "0x5208", nil })
+	node.handle("quai_gasPrice", func([]json.RawMessage) (any, error) { return "0x1", nil })
+	node.handle("quai_sendRawTransaction", func([]json.RawMessage) (any, error) { return nil, nil })
+
+	record := testRecord(t, testEntry(1, testQuaiAddress), models.Generated)
+	db := &fakeDB{records: []*models.Transaction{record}}
+	w := newFakeWallet(t, &config.Config{MaxGasLimit: 1_000_000}, node, db)
+	w.privateKey = key
+	w.address = crypto.PubkeyToAddress(key.PublicKey, w.location)
+	record.Payer = w.address.Hex()
+	record.Nonce = 1
+	record.GasPrice = decimal.NewFromInt(1)
+	return w, node, db, record
+}
+
+func TestCancelRequeueResigns(t *testing.T) {
+	w, node, db, record := newCancelWallet(t)
+	entry := testEntry(1, testQuaiAddress)
+	ctx := context.Background()
+	originalHash := record.TxHash
+
+	cancelTx, err := w.CancelTransaction(ctx, 1, big.NewInt(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Status != models.DeadLetter || record.FailureCode != FailureCancelled {
+		t.Fatalf("cancelled record has status %s and failure code %q, want dead-lettered as cancelled", record.Status, record.FailureCode)
+	}
+
+	n, err := w.txDAL.RequeueDeadLetters(ctx, SpentNonceFailureCodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("%d entries requeued, want 1", n)
+	}
+	if len(db.records) != 0 {
+		t.Fatalf("record of the cancelled transaction kept: %+v", db.records[0])
+	}
+
+	// The rerun signs the entry anew at the next free nonce instead of resending the old one
+	if err := w.sendBatchEntry(ctx, entry, &BatchResult{Total: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if len(db.records) != 1 {
+		t.Fatalf("%d records after the rerun, want the re-signed one", len(db.records))
+	}
+	resigned := db.records[0]
+	if resigned.Nonce != 2 {
+		t.Errorf("re-signed at nonce %d, want 2 past the cancel transaction", resigned.Nonce)
+	}
+	if resigned.TxHash == originalHash || resigned.TxHash == cancelTx.Hash().Hex() {
+		t.Errorf("rerun sent %s again", resigned.TxHash)
+	}
+	if _, ok := w.pendingTxs[common.HexToHash(resigned.TxHash)]; !ok {
+		t.Errorf("re-signed transaction %s isn't in flight", resigned.TxHash)
+	}
+	if sends := node.count("quai_sendRawTransaction"); sends != 2 {
+		t.Errorf("%d broadcasts, want the cancel and the re-signed transaction", sends)
+	}
+}
+
+func TestRequeueKeepsUnspentNonce(t *testing.T) {
+	w, _, db, record := newCancelWallet(t)
+	ctx := context.Background()
+	record.Status = models.DeadLetter
+	record.FailureCode = FailureRetriesExhausted
+
+	n, err := w.txDAL.RequeueDeadLetters(ctx, SpentNonceFailureCodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || len(db.records) != 1 {
+		t.Fatalf("%d entries requeued, %d records, want the record requeued as is", n, len(db.records))
+	}
+	if record.Status != models.Generated || record.FailureCode != "" {
+		t.Errorf("requeued record has status %s and failure code %q, want pending", record.Status, record.FailureCode)
+	}
+}
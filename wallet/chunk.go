@@ -0,0 +1,143 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	wtypes "quai-transfer/types"
+	"quai-transfer/utils"
+)
+
+// ChunkFunc runs the batch of one chunk of entries, as ProcessBatchEntry does
+type ChunkFunc func(ctx context.Context, entries []*wtypes.TransferEntry) (*BatchResult, error)
+
+// chunkRun sequences the chunks of a batch and sums up their results
+type chunkRun struct {
+	process  ChunkFunc
+	total    int // chunks of the batch, 0 when streamed
+	done     int
+	combined BatchResult
+	start    time.Time
+	stopped  error // why the later chunks weren't started
+	dryRun   bool
+}
+
+// label names chunk n, with the chunk count when known
+func (c *chunkRun) label(n int) string {
+	if c.total == 0 {
+		return fmt.Sprintf("%d", n)
+	}
+	return fmt.Sprintf("%d/%d", n, c.total)
+}
+
+// run processes the next chunk. Once a chunk fails or leaves entries unsent or unconfirmed,
+// stopped is set and no later chunk may start.
+func (c *chunkRun) run(ctx context.Context, chunk []*wtypes.TransferEntry) error {
+	c.done++
+	n := c.label(c.done)
+	log.Printf("📦 CHUNK %s | Entries: %d | IDs: %d to %d", n, len(chunk), chunk[0].ID, chunk[len(chunk)-1].ID)
+
+	result, err := c.process(ctx, chunk)
+	c.combined.merge(result)
+	switch {
+	case err != nil:
+		c.stopped = fmt.Errorf("chunk %s failed: %w", n, err)
+		return err
+	case result != nil && (result.Unsent > 0 || result.Unprocessed > 0):
+		c.stopped = fmt.Errorf("chunk %s did not complete", n)
+		log.Printf("🛑 CHUNKS STOPPED | Chunk %s left %d entries unsent and %d unconfirmed | Run the CSV again to resume",
+			n, result.Unsent, result.Unprocessed)
+	}
+	return nil
+}
+
+// finish reports the entries of the chunks never started as unsent and logs the overall summary
+func (c *chunkRun) finish(unsentIDs []int32) *BatchResult {
+	if len(unsentIDs) > 0 {
+		c.combined.Total += len(unsentIDs)
+		c.combined.markUnsentIDs(unsentIDs, c.stopped)
+	}
+	c.combined.Duration = time.Since(c.start)
+	title := fmt.Sprintf("OVERALL TRANSFER SUMMARY (%d chunks)", c.done)
+	if c.dryRun {
+		title = "DRY RUN " + title
+	}
+	logBatchSummary(title, &c.combined)
+	return &c.combined
+}
+
+// ProcessInChunks runs entries through process in sequential chunks of at most size entries,
+// each broadcast and monitored to the end before the next starts, which bounds what is in
+// flight at once. Entries are put in the order of priority first, so chunks follow it too.
+// Once a chunk leaves entries unsent or unconfirmed the later chunks aren't started and
+// their entries are reported as unsent; running the same CSV again skips the confirmed
+// entries and resumes from there. A size of zero, or one covering every entry, runs a single
+// batch.
+func ProcessInChunks(ctx context.Context, entries []*wtypes.TransferEntry, size int, priority string, dryRun bool, process ChunkFunc) (*BatchResult, error) {
+	if size <= 0 || len(entries) <= size {
+		return process(ctx, entries)
+	}
+	if err := SortEntries(entries, priority); err != nil {
+		return nil, err
+	}
+
+	c := &chunkRun{process: process, total: (len(entries) + size - 1) / size, start: time.Now(), dryRun: dryRun}
+	var err error
+	next := 0
+	for next < len(entries) && c.stopped == nil {
+		chunk := entries[next:min(next+size, len(entries))]
+		next += len(chunk)
+		err = c.run(ctx, chunk)
+	}
+
+	var unsentIDs []int32
+	for _, entry := range entries[next:] {
+		unsentIDs = append(unsentIDs, entry.ID)
+	}
+	return c.finish(unsentIDs), err
+}
+
+// StreamInChunks runs a transfer CSV through process like ProcessInChunks, reading it one
+// chunk at a time so only size entries are ever in memory. The CSV order is kept. Once the
+// chunks stop, the rest of the file is only read to report its entries as unsent.
+func StreamInChunks(ctx context.Context, path string, opts utils.CSVOptions, size int, dryRun bool, process ChunkFunc) (*BatchResult, error) {
+	if size <= 0 {
+		return nil, errors.New("chunk size must be at least 1")
+	}
+	c := &chunkRun{process: process, start: time.Now(), dryRun: dryRun}
+	var (
+		chunk     = make([]*wtypes.TransferEntry, 0, size)
+		unsentIDs []int32
+		chunkErr  error
+	)
+	// The rest of the file is read to report it as unsent even once ctx is done
+	readErr := utils.StreamTransferCSV(context.WithoutCancel(ctx), path, opts, func(entry *wtypes.TransferEntry) error {
+		if c.stopped != nil {
+			unsentIDs = append(unsentIDs, entry.ID)
+			return nil
+		}
+		chunk = append(chunk, entry)
+		if len(chunk) < size {
+			return nil
+		}
+		chunkErr = c.run(ctx, chunk)
+		chunk = make([]*wtypes.TransferEntry, 0, size)
+		return nil
+	})
+	if readErr == nil && c.stopped == nil && len(chunk) > 0 {
+		chunkErr = c.run(ctx, chunk)
+	} else {
+		for _, entry := range chunk {
+			unsentIDs = append(unsentIDs, entry.ID)
+		}
+	}
+
+	result := c.finish(unsentIDs)
+	if readErr != nil {
+		return result, fmt.Errorf("failed to read CSV file after %d chunks: %w", c.done, readErr)
+	}
+	return result, chunkErr
+}
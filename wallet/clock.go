@@ -0,0 +1,41 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ClockSkew compares the local clock to the timestamp of the node's latest block. A positive
+// skew means the local clock is ahead. The latest block is always a few seconds old, so a
+// small positive skew is expected.
+func (w *Wallet) ClockSkew(ctx context.Context) (time.Duration, error) {
+	head, err := w.rpc().HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest block: %w", err)
+	}
+	blockTime := time.Unix(int64(head.Time()), 0)
+	return time.Since(blockTime), nil
+}
+
+// CheckClockSkew warns when the local clock and the latest block timestamp diverge by more
+// than max_clock_skew. Nonce waits, key file names and log timestamps all rely on the local
+// clock, so a skewed container clock makes them misleading. It returns the measured skew and
+// whether it is within the threshold; the check is disabled when the threshold is zero.
+func (w *Wallet) CheckClockSkew(ctx context.Context) (time.Duration, bool, error) {
+	skew, err := w.ClockSkew(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	if w.config.MaxClockSkew == 0 || skew.Abs() <= w.config.MaxClockSkew {
+		return skew, true, nil
+	}
+	direction := "ahead of"
+	if skew < 0 {
+		direction = "behind"
+	}
+	log.Printf("⚠️ CLOCK SKEW | Local clock is %s %s the latest block | Threshold: %s | Check the host's time sync",
+		skew.Abs().Round(time.Second), direction, w.config.MaxClockSkew)
+	return skew, false, nil
+}
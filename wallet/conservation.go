@@ -0,0 +1,136 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+
+	"quai-transfer/utils"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/quaiclient/ethclient"
+	"github.com/shopspring/decimal"
+)
+
+// BalanceSnapshot is the balance of a wallet at a block
+type BalanceSnapshot struct {
+	Address common.Address
+	Block   uint64
+	Balance decimal.Decimal
+}
+
+// Conservation is the outcome of checking that a run conserved value: the balances it started
+// with, less what it paid out and spent in fees, plus what it received, must be the balances
+// it ended with. Amounts are in wei, summed across the wallets of the run.
+type Conservation struct {
+	Start    decimal.Decimal
+	Sent     decimal.Decimal
+	Fees     decimal.Decimal
+	Incoming decimal.Decimal
+	End      decimal.Decimal
+	// Unexplained is the end balance less the expected one, negative for value that left the
+	// wallets without a record of this run
+	Unexplained decimal.Decimal
+}
+
+// Conserved reports whether the balances are fully explained
+func (c *Conservation) Conserved() bool {
+	return c.Unexplained.IsZero()
+}
+
+// SnapshotBalance reads the balance of the wallet at the latest block, pinned to its number
+// so the incoming transactions after it can be counted
+func (w *Wallet) SnapshotBalance(ctx context.Context) (*BalanceSnapshot, error) {
+	block, err := withRetry(ctx, w, "get block number", func(client *ethclient.Client) (uint64, error) {
+		return client.BlockNumber(ctx)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block number: %w", err)
+	}
+	balance, err := withRetry(ctx, w, "get balance", func(client *ethclient.Client) (*big.Int, error) {
+		return client.BalanceAt(ctx, w.address.MixedcaseAddress(), new(big.Int).SetUint64(block))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance at block %d: %w", block, err)
+	}
+	return &BalanceSnapshot{Address: w.address, Block: block, Balance: decimal.NewFromBigInt(balance, 0)}, nil
+}
+
+// SnapshotBalances snapshots the balance of every wallet, in order
+func SnapshotBalances(ctx context.Context, wallets []*Wallet) ([]*BalanceSnapshot, error) {
+	snapshots := make([]*BalanceSnapshot, len(wallets))
+	for i, w := range wallets {
+		snapshot, err := w.SnapshotBalance(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to snapshot balance of %s: %w", w.address.Hex(), err)
+		}
+		snapshots[i] = snapshot
+	}
+	return snapshots, nil
+}
+
+// incomingBetween sums the transactions paying the wallet in the blocks after from, up to and
+// including to
+func (w *Wallet) incomingBetween(ctx context.Context, from, to uint64) (decimal.Decimal, int, error) {
+	watched := map[common.AddressBytes]bool{w.address.Bytes20(): true}
+	out := make(chan IncomingTx)
+	scanErr := make(chan error, 1)
+	go func() {
+		defer close(out)
+		next := from + 1
+		scanErr <- w.scanIncoming(ctx, &next, to, watched, out)
+	}()
+
+	total := decimal.Zero
+	count := 0
+	for tx := range out {
+		total = total.Add(decimal.NewFromBigInt(tx.Value, 0))
+		count++
+	}
+	return total, count, <-scanErr
+}
+
+// VerifyConservation snapshots the balances of the wallets again once a run is done and checks
+// them against start, the snapshots taken before it, and result. Incoming transactions are
+// found by scanning the blocks between the snapshots. A delta no transaction of the run
+// explains, an untracked spend or a recording bug, is logged as a warning; the error is only
+// for a check that couldn't be done.
+func VerifyConservation(ctx context.Context, wallets []*Wallet, start []*BalanceSnapshot, result *BatchResult) (*Conservation, error) {
+	if result == nil {
+		return nil, fmt.Errorf("no batch result to check")
+	}
+	if result.Unprocessed > 0 {
+		// Their value and fees may leave the wallets at any time, before or after the end snapshot
+		return nil, fmt.Errorf("%d transactions are still unconfirmed", result.Unprocessed)
+	}
+
+	c := &Conservation{Sent: result.TotalValue, Fees: result.TotalFees}
+	for i, w := range wallets {
+		end, err := w.SnapshotBalance(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to snapshot balance of %s: %w", w.address.Hex(), err)
+		}
+		incoming, count, err := w.incomingBetween(ctx, start[i].Block, end.Block)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan incoming transactions of %s: %w", w.address.Hex(), err)
+		}
+		if count > 0 {
+			log.Printf("📥 INCOMING DURING RUN | Wallet: %s | Transactions: %d | Value: %s Quai | Blocks: %d-%d",
+				w.address.Hex(), count, utils.ToQuai(incoming.BigInt()), start[i].Block+1, end.Block)
+		}
+		c.Start = c.Start.Add(start[i].Balance)
+		c.End = c.End.Add(end.Balance)
+		c.Incoming = c.Incoming.Add(incoming)
+	}
+	c.Unexplained = c.End.Sub(c.Start.Sub(c.Sent).Sub(c.Fees).Add(c.Incoming))
+
+	if c.Conserved() {
+		log.Printf("⚖️ VALUE CONSERVED | Start: %s Quai | Sent: %s Quai | Fees: %s Quai | Incoming: %s Quai | End: %s Quai",
+			utils.ToQuai(c.Start.BigInt()), utils.ToQuai(c.Sent.BigInt()), utils.ToQuai(c.Fees.BigInt()), utils.ToQuai(c.Incoming.BigInt()), utils.ToQuai(c.End.BigInt()))
+	} else {
+		log.Printf("⚠️ VALUE NOT CONSERVED | Unexplained: %s wei | Start: %s Quai | Sent: %s Quai | Fees: %s Quai | Incoming: %s Quai | End: %s Quai | An untracked spend or a recording bug, check the wallet's transactions",
+			c.Unexplained, utils.ToQuai(c.Start.BigInt()), utils.ToQuai(c.Sent.BigInt()), utils.ToQuai(c.Fees.BigInt()), utils.ToQuai(c.Incoming.BigInt()), utils.ToQuai(c.End.BigInt()))
+	}
+	return c, nil
+}
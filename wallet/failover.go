@@ -0,0 +1,129 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/dominant-strategies/go-quai/quaiclient/ethclient"
+	"github.com/dominant-strategies/go-quai/rpc"
+)
+
+// dialTimeout bounds how long an RPC endpoint has to answer before the next one is tried
+const dialTimeout = 10 * time.Second
+
+// rpc returns the client of the RPC endpoint currently in use
+func (w *Wallet) rpc() *ethclient.Client {
+	w.clientMutex.RLock()
+	defer w.clientMutex.RUnlock()
+	return w.client
+}
+
+// rawRPC returns the connection of the client in use, for calls the client has no method for
+func (w *Wallet) rawRPC() *rpc.Client {
+	w.clientMutex.RLock()
+	defer w.clientMutex.RUnlock()
+	return w.rawClient
+}
+
+// rpcURL returns the RPC endpoint currently in use
+func (w *Wallet) rpcURL() string {
+	w.clientMutex.RLock()
+	defer w.clientMutex.RUnlock()
+	if len(w.rpcURLs) == 0 {
+		return ""
+	}
+	return w.rpcURLs[w.rpcIndex]
+}
+
+// dialEndpoints connects to the first endpoint that answers, trying them in order from start
+// and wrapping around. It returns the client, its underlying connection and the index of its
+// endpoint.
+func dialEndpoints(urls []string, start int) (*ethclient.Client, *rpc.Client, int, error) {
+	var errs []error
+	for i := range urls {
+		index := (start + i) % len(urls)
+		client, raw, err := dialEndpoint(urls[index])
+		if err == nil {
+			return client, raw, index, nil
+		}
+		if len(urls) > 1 {
+			log.Printf("⚠️ RPC UNREACHABLE | Endpoint: %s | %v", urls[index], err)
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", urls[index], err))
+	}
+	return nil, nil, 0, errors.Join(errs...)
+}
+
+// dialEndpoint connects to an endpoint and checks it answers, HTTP clients being lazy. The
+// connection is returned with the client, for the calls the client has no method for; closing
+// the client closes it.
+func dialEndpoint(url string) (*ethclient.Client, *rpc.Client, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
+
+	raw, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return nil, nil, err
+	}
+	client := ethclient.NewClient(raw)
+	if _, err := client.BlockNumber(ctx); err != nil {
+		client.Close()
+		return nil, nil, err
+	}
+	return client, raw, nil
+}
+
+// reconnect cycles to the next configured endpoint that answers, after failed, the client a
+// call failed on, lost its connection. When several calls fail together only the first one
+// switches, and the current client is kept when no endpoint answers.
+func (w *Wallet) reconnect(failed *ethclient.Client) {
+	w.clientMutex.Lock()
+	defer w.clientMutex.Unlock()
+	if w.client != failed || len(w.rpcURLs) == 0 {
+		return
+	}
+
+	from := w.rpcURLs[w.rpcIndex]
+	client, raw, index, err := dialEndpoints(w.rpcURLs, w.rpcIndex+1)
+	if err != nil {
+		log.Printf("🔌 RPC FAILOVER FAILED | Location: %s | No endpoint answers, keeping %s | %v", locationToString(w.location), from, err)
+		return
+	}
+	w.client, w.rawClient, w.rpcIndex = client, raw, index
+	failed.Close()
+	log.Printf("🔌 RPC FAILOVER | Location: %s | From: %s | To: %s", locationToString(w.location), from, w.rpcURLs[index])
+}
+
+// connectionErrors are the messages of errors where the node couldn't be reached, rather
+// than answering with an error
+var connectionErrors = []string{
+	"connection refused",
+	"connection reset",
+	"broken pipe",
+	"no such host",
+	"i/o timeout",
+	"502 bad gateway",
+	"503 service unavailable",
+	"504 gateway timeout",
+}
+
+// isConnectionError tells whether an RPC call failed because the endpoint is unreachable
+func isConnectionError(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, s := range connectionErrors {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,417 @@
+package wallet
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"quai-transfer/config"
+	"quai-transfer/dal"
+	"quai-transfer/dal/models"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/quaiclient/ethclient"
+	"github.com/dominant-strategies/go-quai/rpc"
+	"github.com/shopspring/decimal"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// fakeNode serves JSON-RPC calls from handlers by method. A method without a handler fails.
+type fakeNode struct {
+	mu       sync.Mutex
+	handlers map[string]func(params []json.RawMessage) (any, error)
+	calls    map[string]int
+}
+
+func newFakeNode() *fakeNode {
+	n := &fakeNode{handlers: make(map[string]func([]json.RawMessage) (any, error)), calls: make(map[string]int)}
+	n.handle("quai_blockNumber", func([]json.RawMessage) (any, error) { return "0x10", nil })
+	return n
+}
+
+// handle sets the handler of a method
+func (n *fakeNode) handle(method string, handler func(params []json.RawMessage) (any, error)) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.handlers[method] = handler
+}
+
+// count returns how many times a method was called
+func (n *fakeNode) count(method string) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.calls[method]
+}
+
+func (n *fakeNode) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	body, _ := io.ReadAll(r.Body)
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	n.mu.Lock()
+	n.calls[req.Method]++
+	handler := n.handlers[req.Method]
+	n.mu.Unlock()
+
+	resp := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+	if handler == nil {
+		resp["error"] = map[string]any{"code": rpcMethodNotFound, "message": "method " + req.Method + " not found"}
+	} else if result, err := handler(req.Params); err != nil {
+		resp["error"] = map[string]any{"code": -32000, "message": err.Error()}
+	} else {
+		resp["result"] = result
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(rw).Encode(resp)
+}
+
+// dial starts serving the node and connects a client to it
+func (n *fakeNode) dial(t *testing.T) (*ethclient.Client, *rpc.Client) {
+	t.Helper()
+	server := httptest.NewServer(n)
+	t.Cleanup(server.Close)
+	raw, err := rpc.DialContext(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := ethclient.NewClient(raw)
+	t.Cleanup(client.Close)
+	return client, raw
+}
+
+// fakeDB is a database/sql driver keeping transaction records in memory, enough for the DAL:
+// row lookups, updates and deletes by equality and IN conditions on the record columns, and
+// inserts, apply to the active table. Every statement is recorded.
+type fakeDB struct {
+	mu         sync.Mutex
+	records    []*models.Transaction
+	statements []string
+}
+
+// executed returns the statements run so far that contain s
+func (db *fakeDB) executed(s string) []string {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var matches []string
+	for _, statement := range db.statements {
+		if strings.Contains(statement, s) {
+			matches = append(matches, statement)
+		}
+	}
+	return matches
+}
+
+// open returns a DAL over the fake database
+func (db *fakeDB) open(t *testing.T) *dal.TransactionDAL {
+	t.Helper()
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(fakeConnector{db})}), &gorm.Config{
+		Logger:                 logger.Discard,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dal.NewTransactionDAL(gdb)
+}
+
+// Statements of the DAL on the active table, and the conditions of their WHERE clause
+var (
+	selectPattern = regexp.MustCompile(`^SELECT (?:\*|"?[a-z_]+"?(?:,"?[a-z_]+"?)*) FROM "?([a-z_.]+)"? WHERE (.+?)(?: ORDER BY .*| LIMIT .*)?$`)
+	updatePattern = regexp.MustCompile(`^UPDATE "?([a-z_.]+)"? SET (.+) WHERE (.+)$`)
+	deletePattern = regexp.MustCompile(`^DELETE FROM "?([a-z_.]+)"? WHERE (.+)$`)
+	insertPattern = regexp.MustCompile(`^INSERT INTO "?([a-z_.]+)"? \((.+?)\) VALUES`)
+	condPattern   = regexp.MustCompile(`^"?([a-z_]+)"? (?:= \$(\d+)|IN \(([$\d,]+)\))$`)
+	setPattern    = regexp.MustCompile(`"([a-z_]+)"=\$(\d+)`)
+)
+
+// recordColumns are the columns of the rows returned, as far as tests need them
+var recordColumns = []string{"id", "payer", "nonce", "tx_hash", "gas_price", "tx", "entry", "status", "failure_code"}
+
+// sqlString returns an argument or a record field the way conditions compare them
+func sqlString(v any) string {
+	if valuer, ok := v.(driver.Valuer); ok {
+		v, _ = valuer.Value()
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return ""
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return ""
+		}
+		return sqlString(rv.Elem().Interface())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10)
+	}
+	return fmt.Sprint(v)
+}
+
+// column returns the value of a column of a record
+func column(record *models.Transaction, name string) any {
+	switch name {
+	case "id":
+		return record.ID
+	case "payer":
+		return record.Payer
+	case "nonce":
+		return record.Nonce
+	case "tx_hash":
+		return record.TxHash
+	case "gas_price":
+		return record.GasPrice
+	case "tx":
+		return record.Tx
+	case "entry":
+		return record.Entry
+	case "status":
+		return record.Status
+	case "idempotency_key":
+		return record.IdempotencyKey
+	case "failure_code":
+		return record.FailureCode
+	case "failure_reason":
+		return record.FailureReason
+	case "retry_count":
+		return record.RetryCount
+	}
+	return nil
+}
+
+// setColumn sets a column of a record; columns tests don't look at are ignored
+func setColumn(record *models.Transaction, name string, v any) {
+	s := sqlString(v)
+	switch name {
+	case "id":
+		id, _ := strconv.ParseInt(s, 10, 32)
+		record.ID = int32(id)
+	case "payer":
+		record.Payer = s
+	case "nonce":
+		record.Nonce, _ = strconv.ParseUint(s, 10, 64)
+	case "tx_hash":
+		record.TxHash = s
+	case "gas_price":
+		record.GasPrice, _ = decimal.NewFromString(s)
+	case "tx":
+		record.Tx = s
+	case "entry":
+		record.Entry = s
+	case "status":
+		status, _ := strconv.ParseUint(s, 10, 64)
+		record.Status = models.TxStatus(status)
+	case "idempotency_key":
+		if s == "" {
+			record.IdempotencyKey = nil
+		} else {
+			record.IdempotencyKey = &s
+		}
+	case "failure_code":
+		record.FailureCode = s
+	case "failure_reason":
+		record.FailureReason = s
+	case "retry_count":
+		record.RetryCount, _ = strconv.Atoi(s)
+	}
+}
+
+// arg returns the argument of a $n placeholder
+func arg(args []driver.NamedValue, n string) any {
+	i, _ := strconv.Atoi(strings.TrimPrefix(n, "$"))
+	if i < 1 || i > len(args) {
+		return nil
+	}
+	return args[i-1].Value
+}
+
+// where returns whether a record meets the conditions of a WHERE clause. A condition it can't
+// read matches no record.
+func where(record *models.Transaction, clause string, args []driver.NamedValue) bool {
+	for _, cond := range strings.Split(clause, " AND ") {
+		m := condPattern.FindStringSubmatch(strings.TrimSpace(cond))
+		if m == nil {
+			return false
+		}
+		value := sqlString(column(record, m[1]))
+		if m[2] != "" {
+			if value != sqlString(arg(args, m[2])) {
+				return false
+			}
+			continue
+		}
+		in := false
+		for _, n := range strings.Split(m[3], ",") {
+			in = in || value == sqlString(arg(args, n))
+		}
+		if !in {
+			return false
+		}
+	}
+	return true
+}
+
+// activeTable reports whether table is the one of the records, not the archive
+func activeTable(table string) bool {
+	return table == (&models.Transaction{}).TableName()
+}
+
+// query answers the lookups of records in the active table, and records inserts
+func (db *fakeDB) query(query string, args []driver.NamedValue) (driver.Rows, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.statements = append(db.statements, query)
+
+	if m := insertPattern.FindStringSubmatch(query); m != nil && activeTable(m[1]) {
+		record := &models.Transaction{}
+		for i, name := range strings.Split(m[2], ",") {
+			setColumn(record, strings.Trim(name, `"`), arg(args, strconv.Itoa(i+1)))
+		}
+		db.records = append(db.records, record)
+		return &fakeRows{columns: []string{"id"}, values: [][]driver.Value{{int64(record.ID)}}}, nil
+	}
+
+	rows := &fakeRows{columns: recordColumns}
+	m := selectPattern.FindStringSubmatch(query)
+	if m == nil || !activeTable(m[1]) {
+		return rows, nil
+	}
+	for _, record := range db.records {
+		if !where(record, m[2], args) {
+			continue
+		}
+		values := make([]driver.Value, len(recordColumns))
+		for i, name := range recordColumns {
+			values[i] = sqlString(column(record, name))
+		}
+		rows.values = append(rows.values, values)
+	}
+	return rows, nil
+}
+
+// exec applies the updates and deletes of records in the active table
+func (db *fakeDB) exec(query string, args []driver.NamedValue) int64 {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.statements = append(db.statements, query)
+
+	var affected int64
+	if m := updatePattern.FindStringSubmatch(query); m != nil && activeTable(m[1]) {
+		for _, record := range db.records {
+			if !where(record, m[3], args) {
+				continue
+			}
+			for _, set := range setPattern.FindAllStringSubmatch(m[2], -1) {
+				setColumn(record, set[1], arg(args, set[2]))
+			}
+			affected++
+		}
+	}
+	if m := deletePattern.FindStringSubmatch(query); m != nil && activeTable(m[1]) {
+		kept := db.records[:0]
+		for _, record := range db.records {
+			if where(record, m[2], args) {
+				affected++
+				continue
+			}
+			kept = append(kept, record)
+		}
+		db.records = kept
+	}
+	return affected
+}
+
+type fakeConnector struct{ db *fakeDB }
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn(c), nil }
+func (c fakeConnector) Driver() driver.Driver                        { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return nil, errors.New("use the connector") }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.db, query}, nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+// CheckNamedValue passes every argument through as is
+func (c fakeConn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+func (c fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(c.db.exec(query, args)), nil
+}
+
+func (c fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.db.query(query, args)
+}
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+func (s fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(s.db.exec(s.query, nil)), nil
+}
+func (s fakeStmt) Query([]driver.Value) (driver.Rows, error) { return s.db.query(s.query, nil) }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// newFakeWallet returns a wallet of zone 0-0 connected to node, recording in db
+func newFakeWallet(t *testing.T, cfg *config.Config, node *fakeNode, db *fakeDB) *Wallet {
+	t.Helper()
+	w := newTestWallet(cfg)
+	w.client, w.rawClient = node.dial(t)
+	w.txDAL = db.open(t)
+	w.chainID = &ChainIDMapping{Expected: big.NewInt(9000), Actual: big.NewInt(9000)}
+	w.pendingTxs = make(map[common.Hash]*PendingTx)
+	w.pendingNonces = make(map[uint64]struct{})
+	w.minedTxs = make(map[uint64]*minedTx)
+	return w
+}
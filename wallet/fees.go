@@ -0,0 +1,171 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+
+	wtypes "quai-transfer/types"
+
+	quai "github.com/dominant-strategies/go-quai"
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/shopspring/decimal"
+)
+
+// baseFeeBlocks is how many recent blocks are sampled for the base price
+const baseFeeBlocks = 5
+
+// basePrice returns the highest base fee of the last few blocks, so a transaction still clears
+// after a small rise. It falls back to the node's suggested gas price when the blocks carry no
+// base fee or can't be read.
+func (w *Wallet) basePrice(ctx context.Context) (*big.Int, error) {
+	head, err := w.rpc().HeaderByNumber(ctx, nil)
+	if err != nil || head == nil || head.BaseFee() == nil || head.BaseFee().Sign() <= 0 {
+		return w.SuggestGasPrice(ctx)
+	}
+
+	base := new(big.Int).Set(head.BaseFee())
+	number := head.NumberU64(common.ZONE_CTX)
+	for i := uint64(1); i < baseFeeBlocks && i <= number; i++ {
+		block, err := w.rpc().HeaderByNumber(ctx, new(big.Int).SetUint64(number-i))
+		if err != nil || block == nil || block.BaseFee() == nil {
+			break
+		}
+		if block.BaseFee().Cmp(base) > 0 {
+			base.Set(block.BaseFee())
+		}
+	}
+	return base, nil
+}
+
+// minerTipFor returns miner_tip_percent of the base price, at least min_miner_tip
+func (w *Wallet) minerTipFor(base *big.Int) *big.Int {
+	tip := new(big.Int).Mul(base, big.NewInt(w.config.MinerTipPercent))
+	tip.Div(tip, big.NewInt(100))
+	if floor := new(big.Int).SetUint64(w.config.MinMinerTip); tip.Cmp(floor) < 0 {
+		tip = floor
+	}
+	return tip
+}
+
+// EstimateFees returns the gas price and miner tip for the next transaction. The base price is
+// the cached batch price while gas price tracking is active, otherwise the recent base fee. The
+// tip is a percentage of the base price, and the gas price covers the base price plus the tip
+// so the tip is paid in full, within the bounds set relative to the node's suggested price.
+func (w *Wallet) EstimateFees(ctx context.Context) (gasPrice, minerTip *big.Int, err error) {
+	base, err := w.currentGasPrice(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get base price: %w", err)
+	}
+
+	minerTip = w.minerTipFor(base)
+	gasPrice = new(big.Int).Add(base, minerTip)
+	if gasPrice, err = w.boundGasPrice(ctx, gasPrice); err != nil {
+		return nil, nil, err
+	}
+	if minerTip.Cmp(gasPrice) > 0 {
+		minerTip = new(big.Int).Set(gasPrice)
+	}
+
+	if w.config.Debug {
+		log.Printf("Fees: base %s wei, miner tip %s wei (%d%%, min %d), gas price %s wei",
+			base, minerTip, w.config.MinerTipPercent, w.config.MinMinerTip, gasPrice)
+	}
+	return gasPrice, minerTip, nil
+}
+
+// boundGasPrice clamps a gas price to gas_price_min_multiplier and gas_price_max_multiplier
+// times the node's suggested gas price, each bound disabled when zero
+func (w *Wallet) boundGasPrice(ctx context.Context, gasPrice *big.Int) (*big.Int, error) {
+	minMul, maxMul := w.config.GasPriceMinMultiplier, w.config.GasPriceMaxMultiplier
+	if minMul == 0 && maxMul == 0 {
+		return gasPrice, nil
+	}
+
+	suggested, err := w.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get suggested gas price: %w", err)
+	}
+	scaled := func(m float64) *big.Int {
+		return decimal.NewFromBigInt(suggested, 0).Mul(decimal.NewFromFloat(m)).Ceil().BigInt()
+	}
+
+	if minMul > 0 {
+		if floor := scaled(minMul); gasPrice.Cmp(floor) < 0 {
+			log.Printf("⚠️ GAS PRICE RAISED | Estimate: %s wei | Floor: %s wei (%gx suggested %s wei)", gasPrice, floor, minMul, suggested)
+			return floor, nil
+		}
+	}
+	if maxMul > 0 {
+		if ceiling := scaled(maxMul); gasPrice.Cmp(ceiling) > 0 {
+			log.Printf("⚠️ GAS PRICE CAPPED | Estimate: %s wei | Cap: %s wei (%gx suggested %s wei)", gasPrice, ceiling, maxMul, suggested)
+			return ceiling, nil
+		}
+	}
+	return gasPrice, nil
+}
+
+// EstimateGas returns the gas limit for a transaction. With gas_estimate_multiplier set it is the
+// node's estimate times the multiplier, falling back to gas_limit when the node can't estimate;
+// otherwise it is gas_limit.
+func (w *Wallet) EstimateGas(ctx context.Context, to common.Address, amount *big.Int, data []byte) (uint64, error) {
+	gas, _, err := w.estimateGas(ctx, to, amount, data)
+	return gas, err
+}
+
+// estimateGas returns the gas limit for a transaction, as EstimateGas does, and the node's
+// estimate it was derived from, zero when gas_limit is used
+func (w *Wallet) estimateGas(ctx context.Context, to common.Address, amount *big.Int, data []byte) (gas, estimate uint64, err error) {
+	if w.config.GasEstimateMultiplier == 0 {
+		return w.config.GasLimit, 0, nil
+	}
+
+	estimate, err = w.rpc().EstimateGas(ctx, quai.CallMsg{From: w.address, To: &to, Value: amount, Data: data})
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, 0, ctx.Err()
+		}
+		log.Printf("⚠️ GAS ESTIMATE FAILED | To: %s | Using gas_limit %d | Error: %v", to.Hex(), w.config.GasLimit, err)
+		return w.config.GasLimit, 0, nil
+	}
+
+	gas = uint64(math.Ceil(float64(estimate) * w.config.GasEstimateMultiplier))
+	if w.config.Debug {
+		log.Printf("Gas: estimate %d x %.2f = %d", estimate, w.config.GasEstimateMultiplier, gas)
+	}
+	return gas, estimate, nil
+}
+
+// entryGas returns the gas limit of the transaction of an entry, with the node's estimate it was
+// derived from or 0: its gas_limit column when set, else as for any transfer
+func (w *Wallet) entryGas(ctx context.Context, entry *wtypes.TransferEntry) (gas, estimate uint64, err error) {
+	if entry.GasLimit > 0 {
+		return entry.GasLimit, 0, nil
+	}
+	to, value, data, err := w.entryCall(entry)
+	if err != nil {
+		return 0, 0, err
+	}
+	return w.estimateGas(ctx, to, value, data)
+}
+
+// entryFees returns the gas price and miner tip of the transaction of an entry. Its gas_price
+// column replaces the estimated gas price when set, capping the miner tip.
+func (w *Wallet) entryFees(ctx context.Context, entry *wtypes.TransferEntry) (gasPrice, minerTip *big.Int, err error) {
+	gasPrice, minerTip, err = w.EstimateFees(ctx)
+	if err != nil || entry.GasPrice == nil {
+		return gasPrice, minerTip, err
+	}
+	gasPrice = new(big.Int).Set(entry.GasPrice)
+	if minerTip.Cmp(gasPrice) > 0 {
+		minerTip = new(big.Int).Set(gasPrice)
+	}
+	return gasPrice, minerTip, nil
+}
+
+// transferGasLimit is the gas limit of a plain transfer, used to budget fees in balance checks
+func (w *Wallet) transferGasLimit(ctx context.Context) (uint64, error) {
+	return w.EstimateGas(ctx, w.address, new(big.Int), nil)
+}
@@ -0,0 +1,53 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"quai-transfer/config"
+
+	"github.com/dominant-strategies/go-quai/common/hexutil"
+)
+
+func TestBoundGasPrice(t *testing.T) {
+	tests := []struct {
+		name      string
+		suggested int64
+		min, max  float64 // gas_price_min_multiplier, gas_price_max_multiplier
+		estimate  int64
+		want      int64
+	}{
+		{name: "no bounds", suggested: 100, estimate: 1000, want: 1000},
+		{name: "within bounds", suggested: 100, min: 0.5, max: 2, estimate: 150, want: 150},
+		{name: "raised to floor", suggested: 100, min: 0.5, max: 2, estimate: 20, want: 50},
+		{name: "capped at ceiling", suggested: 100, min: 0.5, max: 2, estimate: 500, want: 200},
+		{name: "at floor", suggested: 100, min: 0.5, max: 2, estimate: 50, want: 50},
+		{name: "at ceiling", suggested: 100, min: 0.5, max: 2, estimate: 200, want: 200},
+		{name: "floor only", suggested: 100, min: 1, estimate: 1_000_000, want: 1_000_000},
+		{name: "ceiling only", suggested: 100, max: 1.5, estimate: 1, want: 1},
+		{name: "fractional bound rounded up", suggested: 3, min: 1.5, estimate: 1, want: 5},
+		{name: "ceiling rounded up", suggested: 3, max: 1.5, estimate: 10, want: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := newFakeNode()
+			node.handle("quai_gasPrice", func([]json.RawMessage) (any, error) {
+				return hexutil.EncodeBig(big.NewInt(tt.suggested)), nil
+			})
+			w := newFakeWallet(t, &config.Config{GasPriceMinMultiplier: tt.min, GasPriceMaxMultiplier: tt.max}, node, &fakeDB{})
+
+			got, err := w.boundGasPrice(context.Background(), big.NewInt(tt.estimate))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Cmp(big.NewInt(tt.want)) != 0 {
+				t.Errorf("gas price %s, want %d", got, tt.want)
+			}
+			if tt.min == 0 && tt.max == 0 && node.count("quai_gasPrice") > 0 {
+				t.Error("suggested price fetched with no bound set")
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"quai-transfer/config"
+	wtypes "quai-transfer/types"
+
+	quai "github.com/dominant-strategies/go-quai"
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/rpc"
+)
+
+// errAwaitingConfirmations is returned for a mined transaction whose block isn't
+// confirmations deep yet. It stays pending and isn't subject to the confirmation timeout.
+var errAwaitingConfirmations = errors.New("awaiting confirmations")
+
+// errFinalityUnavailable is returned when the node doesn't report a finalized block
+var errFinalityUnavailable = errors.New("finalized block unavailable")
+
+// checkConfirmations returns errAwaitingConfirmations until the transaction of receipt is
+// confirmed under confirmation_mode: at once for receipt, once the head is confirmations blocks
+// past its block for depth, and once its block is finalized for finalized, or confirmations
+// deep when the node can't tell.
+func (w *Wallet) checkConfirmations(ctx context.Context, tx *types.Transaction, receipt *types.Receipt) error {
+	confirmations := uint64(max(w.config.Confirmations, 0))
+	if w.config.ConfirmationMode == config.ConfirmationModeReceipt || receipt.BlockNumber == nil {
+		return nil
+	}
+	if w.config.ConfirmationMode == config.ConfirmationModeFinalized {
+		err := w.checkFinalized(ctx, tx, receipt)
+		if !errors.Is(err, errFinalityUnavailable) {
+			return err
+		}
+	}
+	if confirmations == 0 {
+		return nil
+	}
+	head, err := w.rpc().BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get head block for confirmations: %w", err)
+	}
+	block := receipt.BlockNumber.Uint64()
+	if head >= block+confirmations {
+		return nil
+	}
+	depth := head - min(head, block)
+	log.Printf("⏳ AWAITING CONFIRMATIONS | Tx Hash: %s | Block: %d | Confirmations: %d of %d", tx.Hash().Hex(), block, depth, confirmations)
+	return fmt.Errorf("%w: %d of %d", errAwaitingConfirmations, depth, confirmations)
+}
+
+// checkFinalized returns errAwaitingConfirmations until the node's finalized block reaches the
+// block of receipt, or errFinalityUnavailable for a node that doesn't report one
+func (w *Wallet) checkFinalized(ctx context.Context, tx *types.Transaction, receipt *types.Receipt) error {
+	if w.finalityUnavailable.Load() {
+		return errFinalityUnavailable
+	}
+	finalized, err := w.finalizedBlock(ctx)
+	if errors.Is(err, errFinalityUnavailable) {
+		if w.finalityUnavailable.CompareAndSwap(false, true) {
+			log.Printf("⚠️ FINALITY UNAVAILABLE | Endpoint: %s | The node doesn't report finalized blocks, transactions are confirmed %d blocks deep instead | %v",
+				w.rpcURL(), w.config.Confirmations, err)
+		}
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get finalized block: %w", err)
+	}
+	block := receipt.BlockNumber.Uint64()
+	if finalized >= block {
+		return nil
+	}
+	log.Printf("⏳ AWAITING FINALITY | Tx Hash: %s | Block: %d | Finalized: %d", tx.Hash().Hex(), block, finalized)
+	return fmt.Errorf("%w: block %d, finalized %d", errAwaitingConfirmations, block, finalized)
+}
+
+// finalizedBlock returns the number of the node's latest finalized zone block. The client has
+// no call for it, so the block is asked for by tag on its connection. A node that rejects the
+// tag, or has no such block, makes it errFinalityUnavailable.
+func (w *Wallet) finalizedBlock(ctx context.Context) (uint64, error) {
+	var head *types.WorkObject
+	err := w.rawRPC().CallContext(ctx, &head, "quai_getBlockByNumber", "finalized", false)
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		return 0, fmt.Errorf("%w: %v", errFinalityUnavailable, err)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if head == nil {
+		return 0, errFinalityUnavailable
+	}
+	return head.NumberU64(common.ZONE_CTX), nil
+}
+
+// minedTx is a mined transaction whose nonce stays reserved until its block is final
+type minedTx struct {
+	Tx    *types.Transaction
+	Entry *wtypes.TransferEntry // nil for single sends, which aren't monitored again
+	Block uint64                // block that included the transaction
+}
+
+// trackMinedNonce keeps the nonce of a mined transaction reserved until its block is
+// nonce_release_depth deep. Until then a reorg may drop the transaction and the node would
+// hand out its nonce again, so it's released at the first receipt only when the depth is zero.
+func (w *Wallet) trackMinedNonce(tx *types.Transaction, receipt *types.Receipt) {
+	var entry *wtypes.TransferEntry
+	w.pendingTxMutex.RLock()
+	if pendingTx, ok := w.pendingTxs[tx.Hash()]; ok {
+		entry = pendingTx.Entry
+	}
+	w.pendingTxMutex.RUnlock()
+
+	w.nonceMutex.Lock()
+	defer w.nonceMutex.Unlock()
+	if w.config.NonceReleaseDepth == 0 || receipt.BlockNumber == nil {
+		w.cleanupConfirmedNonces(tx.Nonce())
+		return
+	}
+	w.minedTxs[tx.Nonce()] = &minedTx{Tx: tx, Entry: entry, Block: receipt.BlockNumber.Uint64()}
+}
+
+// reconcileMinedNonces checks the mined transactions whose nonce is still reserved. A nonce is
+// released once its transaction is nonce_release_depth blocks deep. A transaction whose receipt
+// is gone was reorged out: its record goes back to pending and, for a batch entry, it is
+// monitored again, while its nonce stays reserved so it's never reused for another payment.
+func (w *Wallet) reconcileMinedNonces(ctx context.Context) {
+	w.nonceMutex.Lock()
+	mined := make([]*minedTx, 0, len(w.minedTxs))
+	for _, m := range w.minedTxs {
+		mined = append(mined, m)
+	}
+	w.nonceMutex.Unlock()
+	if len(mined) == 0 {
+		return
+	}
+
+	head, err := w.rpc().BlockNumber(ctx)
+	if err != nil {
+		log.Printf("failed to get head block to release nonces: %v", err)
+		return
+	}
+
+	for _, m := range mined {
+		receipt, err := w.rpc().TransactionReceipt(ctx, m.Tx.Hash())
+		if errors.Is(err, quai.NotFound) {
+			w.reinstateReorged(ctx, m)
+			continue
+		}
+		if err != nil || receipt.BlockNumber == nil {
+			continue
+		}
+
+		w.nonceMutex.Lock()
+		if block := receipt.BlockNumber.Uint64(); block != m.Block {
+			// Mined again in another block after a reorg, the depth counts from there
+			m.Block = block
+		} else if head >= m.Block+w.config.NonceReleaseDepth {
+			w.cleanupConfirmedNonces(m.Tx.Nonce())
+		}
+		w.nonceMutex.Unlock()
+	}
+}
+
+// reinstateReorged returns a transaction reorged out of the chain to pending
+func (w *Wallet) reinstateReorged(ctx context.Context, m *minedTx) {
+	txHash := m.Tx.Hash().Hex()
+	log.Printf("🔄 REORGED OUT | Tx Hash: %s | Nonce: %d | Block: %d | The transaction is no longer on chain, its nonce stays reserved and it is pending again",
+		txHash, m.Tx.Nonce(), m.Block)
+
+	err := w.retryDBWrite(ctx, "mark tx "+txHash+" pending", func() error {
+		return w.txDAL.MarkPending(ctx, txHash)
+	})
+	if err != nil {
+		log.Printf("🚨 RECORD OUT OF SYNC | Tx Hash: %s | The transaction was reorged out but its record could not be set back to pending, reconcile it manually | Error: %v",
+			txHash, err)
+	}
+
+	w.nonceMutex.Lock()
+	delete(w.minedTxs, m.Tx.Nonce())
+	w.nonceMutex.Unlock()
+
+	if m.Entry != nil {
+		w.pendingTxMutex.Lock()
+		w.pendingTxs[m.Tx.Hash()] = &PendingTx{Tx: m.Tx, Entry: m.Entry}
+		w.pendingTxMutex.Unlock()
+	}
+}
@@ -0,0 +1,102 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"quai-transfer/config"
+)
+
+// startGasPriceTracking records the starting gas price of a batch, used as the baseline
+// for spike detection. It does nothing unless gas_price_refresh_interval is configured.
+func (w *Wallet) startGasPriceTracking(ctx context.Context) error {
+	if w.config.GasPriceRefreshInterval <= 0 {
+		return nil
+	}
+
+	gasPrice, err := w.basePrice(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get starting gas price: %w", err)
+	}
+
+	w.gasPriceMutex.Lock()
+	defer w.gasPriceMutex.Unlock()
+	w.startGasPrice = gasPrice
+	w.cachedGasPrice = gasPrice
+	w.gasPriceUpdatedAt = time.Now()
+
+	log.Printf("Batch starting gas price: %s wei, refreshing every %s", gasPrice, w.config.GasPriceRefreshInterval)
+	return nil
+}
+
+// stopGasPriceTracking drops the cached batch gas price
+func (w *Wallet) stopGasPriceTracking() {
+	w.gasPriceMutex.Lock()
+	defer w.gasPriceMutex.Unlock()
+	w.startGasPrice = nil
+	w.cachedGasPrice = nil
+}
+
+// currentGasPrice returns the base price for the next transaction: the cached batch price
+// while tracking is active, otherwise the recent base fee
+func (w *Wallet) currentGasPrice(ctx context.Context) (*big.Int, error) {
+	w.gasPriceMutex.Lock()
+	cached := w.cachedGasPrice
+	w.gasPriceMutex.Unlock()
+
+	if cached == nil {
+		return w.basePrice(ctx)
+	}
+	return new(big.Int).Set(cached), nil
+}
+
+// awaitGasPrice refreshes the cached gas price once the refresh interval has elapsed. If the
+// price rose above the spike threshold it either adopts the new price or blocks until the
+// price settles back under the threshold, depending on gas_spike_action.
+func (w *Wallet) awaitGasPrice(ctx context.Context) error {
+	for {
+		w.gasPriceMutex.Lock()
+		startGasPrice := w.startGasPrice
+		due := time.Since(w.gasPriceUpdatedAt) >= w.config.GasPriceRefreshInterval
+		w.gasPriceMutex.Unlock()
+
+		if startGasPrice == nil || !due {
+			return nil
+		}
+
+		gasPrice, err := w.basePrice(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to refresh gas price: %w", err)
+		}
+
+		limit := new(big.Int).Mul(startGasPrice, big.NewInt(100+w.config.GasSpikeThresholdPercent))
+		limit.Div(limit, big.NewInt(100))
+		spiked := gasPrice.Cmp(limit) > 0
+
+		if spiked {
+			log.Printf("⚠️ GAS PRICE SPIKE | Current: %s wei | Start: %s wei | Limit: %s wei (+%d%%)",
+				gasPrice, startGasPrice, limit, w.config.GasSpikeThresholdPercent)
+		}
+
+		w.gasPriceMutex.Lock()
+		w.gasPriceUpdatedAt = time.Now()
+		if !spiked || w.config.GasSpikeAction == config.GasSpikeActionAdjust {
+			w.cachedGasPrice = gasPrice
+		}
+		w.gasPriceMutex.Unlock()
+
+		if !spiked || w.config.GasSpikeAction == config.GasSpikeActionAdjust {
+			return nil
+		}
+
+		log.Printf("Pausing broadcasts for %s until gas price settles", w.config.GasPriceRefreshInterval)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(w.config.GasPriceRefreshInterval):
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package wallet
+
+import (
+	"log"
+
+	"github.com/dominant-strategies/go-quai/core/types"
+)
+
+// gasNearLimit is the share of its gas limit above which a transaction is flagged, since it
+// came close to running out of gas
+const gasNearLimit = 0.95
+
+// GasStats compares the gas limits of mined transactions with the gas they used, to tune
+// gas_limit and gas_estimate_multiplier
+type GasStats struct {
+	Count          int
+	LimitTotal     uint64
+	UsedTotal      uint64
+	MaxUtilization float64 // highest share of its limit a transaction used
+	MinHeadroom    uint64  // smallest gas limit left unused by a transaction
+	NearLimit      int     // transactions that used more than gasNearLimit of their limit
+}
+
+// AverageUtilization returns the share of the summed gas limits that was used
+func (s *GasStats) AverageUtilization() float64 {
+	if s.LimitTotal == 0 {
+		return 0
+	}
+	return float64(s.UsedTotal) / float64(s.LimitTotal)
+}
+
+func (s *GasStats) add(limit, used uint64) {
+	utilization := float64(used) / float64(limit)
+	headroom := uint64(0)
+	if used < limit {
+		headroom = limit - used
+	}
+	if s.Count == 0 || headroom < s.MinHeadroom {
+		s.MinHeadroom = headroom
+	}
+	s.MaxUtilization = max(s.MaxUtilization, utilization)
+	if utilization > gasNearLimit {
+		s.NearLimit++
+	}
+	s.Count++
+	s.LimitTotal += limit
+	s.UsedTotal += used
+}
+
+func (s *GasStats) merge(other *GasStats) {
+	if other.Count == 0 {
+		return
+	}
+	if s.Count == 0 || other.MinHeadroom < s.MinHeadroom {
+		s.MinHeadroom = other.MinHeadroom
+	}
+	s.MaxUtilization = max(s.MaxUtilization, other.MaxUtilization)
+	s.NearLimit += other.NearLimit
+	s.Count += other.Count
+	s.LimitTotal += other.LimitTotal
+	s.UsedTotal += other.UsedTotal
+}
+
+// recordGasUsage logs the gas a mined transaction used against its limit and adds it to the
+// batch's stats, with gas_report
+func (w *Wallet) recordGasUsage(entryID int32, tx *types.Transaction, receipt *types.Receipt) {
+	if !w.config.GasReport || tx.Gas() == 0 {
+		return
+	}
+	limit, used := tx.Gas(), receipt.GasUsed
+	utilization := float64(used) / float64(limit) * 100
+	if float64(used) > gasNearLimit*float64(limit) {
+		log.Printf("⚠️ GAS NEAR LIMIT | ID: %d | Tx Hash: %s | Limit: %d | Used: %d (%.1f%%) | Raise gas_limit or gas_estimate_multiplier",
+			entryID, tx.Hash().Hex(), limit, used, utilization)
+	} else {
+		log.Printf("⛽ GAS USAGE | ID: %d | Tx Hash: %s | Limit: %d | Used: %d (%.1f%%)", entryID, tx.Hash().Hex(), limit, used, utilization)
+	}
+
+	w.pendingTxMutex.Lock()
+	w.batchGas.add(limit, used)
+	w.pendingTxMutex.Unlock()
+}
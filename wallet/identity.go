@@ -0,0 +1,37 @@
+package wallet
+
+import (
+	"crypto/ecdsa"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/crypto"
+)
+
+// KeyScope shows how one private key maps onto the Quai and Qi ledgers. The Quai address is
+// derived from the ECDSA public key and the Qi address from the Schnorr public key; both are
+// the same secp256k1 point, so they are always the same address. That address falls in
+// exactly one ledger, which is the only one the key can send from.
+type KeyScope struct {
+	QuaiAddress common.Address
+	QiAddress   common.Address
+	Location    common.Location
+	Ledger      string
+}
+
+// ScopeOfKey derives the Quai and Qi addresses of a private key and the ledger they belong to
+func ScopeOfKey(privateKey *ecdsa.PrivateKey) KeyScope {
+	quaiBytes := crypto.PubkeyToAddress(privateKey.PublicKey, common.Location{}).Bytes()
+	location := common.LocationFromAddressBytes(quaiBytes)
+
+	schnorrKey, _ := btcec.PrivKeyFromBytes(crypto.FromECDSA(privateKey))
+	qiBytes := crypto.PubkeyBytesToAddress(schnorrKey.PubKey().SerializeUncompressed(), location).Bytes()
+
+	quaiAddress := common.BytesToAddress(quaiBytes, location)
+	return KeyScope{
+		QuaiAddress: quaiAddress,
+		QiAddress:   common.BytesToAddress(qiBytes, location),
+		Location:    location,
+		Ledger:      LedgerOf(quaiAddress.Hex()),
+	}
+}
@@ -0,0 +1,127 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"quai-transfer/dal/models"
+	wtypes "quai-transfer/types"
+
+	quai "github.com/dominant-strategies/go-quai"
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/quaiclient/ethclient"
+	"github.com/shopspring/decimal"
+)
+
+// ImportTransaction records a transaction of the wallet sent outside this tool, by explorer or
+// another wallet, as the payment of entry ID id. It must be mined: its record is created from
+// the node's transaction and receipt, confirmed or failed as the receipt says, so reports
+// include it and batch runs skip the entry as already processed. minerAccount is recorded
+// with the entry, for a later CSV row to match it.
+func (w *Wallet) ImportTransaction(ctx context.Context, txHash common.Hash, id int32, minerAccount string) (*models.Transaction, error) {
+	type fetched struct {
+		tx      *types.Transaction
+		pending bool
+	}
+	result, err := withRetry(ctx, w, "get transaction "+txHash.Hex(), func(client *ethclient.Client) (fetched, error) {
+		tx, pending, err := client.TransactionByHash(ctx, txHash)
+		return fetched{tx, pending}, err
+	})
+	if errors.Is(err, quai.NotFound) {
+		return nil, fmt.Errorf("transaction %s not found on the node", txHash.Hex())
+	}
+	if err != nil {
+		return nil, err
+	}
+	if result.pending {
+		return nil, fmt.Errorf("transaction %s is not mined yet, import it once it is", txHash.Hex())
+	}
+	tx := result.tx
+
+	from, err := types.Sender(types.NewSigner(w.chainID.Actual, w.location), tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recover transaction sender: %v", err)
+	}
+	if !from.Equal(w.address) {
+		return nil, fmt.Errorf("transaction %s is signed by %s, not by the wallet %s", txHash.Hex(), from.Hex(), w.address.Hex())
+	}
+	if tx.To() == nil {
+		return nil, fmt.Errorf("transaction %s has no recipient, only transfers can be imported", txHash.Hex())
+	}
+
+	receipt, err := w.GetTransactionReceipt(ctx, txHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receipt of %s: %w", txHash.Hex(), err)
+	}
+
+	if existing, err := w.txDAL.GetTransactionByHash(ctx, txHash.Hex()); err != nil {
+		return nil, err
+	} else if existing != nil {
+		return nil, fmt.Errorf("transaction %s is already recorded for entry %d", txHash.Hex(), existing.ID)
+	}
+	if existing, err := w.txDAL.GetTransactionByID(ctx, id); err != nil {
+		return nil, err
+	} else if existing != nil {
+		return nil, fmt.Errorf("entry %d is already recorded with transaction %s (%s)", id, existing.TxHash, existing.Status)
+	}
+
+	entry := &wtypes.TransferEntry{
+		ID:           id,
+		MinerAccount: minerAccount,
+		Value:        decimal.NewFromBigInt(tx.Value(), 0),
+		ToAddress:    tx.To().Hex(),
+		Data:         tx.Data(),
+	}
+	txJSON, err := json.Marshal(tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize transaction: %v", err)
+	}
+	entryJSON, err := json.Marshal(entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize entry: %v", err)
+	}
+
+	status := models.Confirmed
+	if receipt.Status == types.ReceiptStatusFailed {
+		status = models.Failed
+	}
+	now := time.Now()
+	txRecord := &models.Transaction{
+		ID:           id,
+		MinerAccount: minerAccount,
+		Payer:        from.Hex(),
+		ToAddress:    entry.ToAddress,
+		TxHash:       txHash.Hex(),
+		Nonce:        tx.Nonce(),
+		Value:        entry.Value,
+		GasLimit:     decimal.NewFromInt(int64(tx.Gas())),
+		GasPrice:     decimal.NewFromBigInt(tx.GasPrice(), 0),
+		Status:       status,
+		CreatedAt:    now,
+		ConfirmedAt:  &now,
+		Tx:           string(txJSON),
+		Entry:        string(entryJSON),
+
+		Gas:               decimal.NewFromInt(int64(receipt.GasUsed)).Mul(decimal.NewFromBigInt(tx.GasPrice(), 0)),
+		GasUsed:           decimal.NewFromInt(int64(receipt.GasUsed)),
+		CumulativeGasUsed: decimal.NewFromInt(int64(receipt.CumulativeGasUsed)),
+	}
+	if receipt.BlockNumber != nil {
+		txRecord.BlockNumber = receipt.BlockNumber.Uint64()
+	}
+	if blockTime := w.blockTime(ctx, receipt); !blockTime.IsZero() {
+		txRecord.BlockTime = &blockTime
+	}
+	if err := w.txDAL.CreateTransaction(ctx, txRecord); err != nil {
+		return nil, fmt.Errorf("failed to create transaction record: %v", err)
+	}
+
+	log.Printf("📥 TRANSACTION IMPORTED | ID: %d | Tx Hash: %s | Nonce: %d | Status: %s | Block: %s",
+		id, txHash.Hex(), tx.Nonce(), txRecord.Status, receipt.BlockNumber)
+	return txRecord, nil
+}
@@ -0,0 +1,51 @@
+package wallet
+
+import (
+	"context"
+	"log"
+	"log/slog"
+
+	"quai-transfer/config"
+	wtypes "quai-transfer/types"
+)
+
+// Lifecycle events of a transfer entry, the status field of its JSON log lines
+const (
+	EntryQueued       = "queued"
+	EntryBroadcast    = "broadcast"
+	EntryConfirmed    = "confirmed"
+	EntrySkipped      = "skipped"
+	EntryFailed       = "failed"
+	EntryDeadLettered = "dead_lettered"
+	EntryInvalid      = "invalid"
+)
+
+// logEntry logs a lifecycle event of an entry. With log_format json it's a JSON object with
+// the entry's fields, otherwise the text line of format and args. txHash and err may be empty.
+func logEntry(cfg *config.Config, status string, entry *wtypes.TransferEntry, txHash string, err error, format string, args ...any) {
+	if cfg == nil || cfg.LogFormat != config.LogFormatJSON {
+		log.Printf(format, args...)
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.Int("entry_id", int(entry.ID)),
+		slog.String("miner_account", entry.MinerAccount),
+		slog.String("to_address", entry.ToAddress),
+		slog.String("amount_wei", entry.Value.String()),
+		slog.String("status", status),
+	}
+	if txHash != "" {
+		attrs = append(attrs, slog.String("tx_hash", txHash))
+	}
+	level := slog.LevelInfo
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+		level = slog.LevelError
+	}
+	if status == EntryInvalid {
+		level = slog.LevelWarn
+	}
+	// Written to the log package's output, so JSON and text lines go to the same place
+	slog.New(slog.NewJSONHandler(log.Writer(), nil)).LogAttrs(context.Background(), level, "transfer "+status, attrs...)
+}
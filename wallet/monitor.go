@@ -0,0 +1,178 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"quai-transfer/config"
+
+	"github.com/dominant-strategies/go-quai/common"
+)
+
+// NewWatchWallet creates a watch-only wallet for an address. It holds no key and no database
+// handle, so it only reads chain state: balances, nonces and incoming transactions. It must
+// not be used to sign or send.
+func NewWatchWallet(address common.Address, cfg *config.Config) (*Wallet, error) {
+	wallet := &Wallet{
+		address: common.BytesToAddress(address.Bytes(), common.LocationFromAddressBytes(address.Bytes())),
+		config:  cfg,
+	}
+
+	if err := wallet.initClient(); err != nil {
+		return nil, err
+	}
+
+	if err := wallet.verifyChainID(context.Background()); err != nil {
+		wallet.Close()
+		return nil, err
+	}
+
+	return wallet, nil
+}
+
+// AddressStatus is the state of a monitored address
+type AddressStatus struct {
+	Address  common.Address
+	Balance  *big.Int
+	Nonce    uint64   // transactions mined
+	Pending  uint64   // transactions sent but not mined yet
+	Incoming int      // incoming transactions seen since monitoring started
+	Received *big.Int // total value of those transactions
+	Err      error    // set when the address could not be read; the other fields are then zero
+}
+
+// AddressMonitor follows a list of addresses spread over any number of locations. It keeps
+// one watch-only wallet per location, so each block is scanned once for all of its addresses.
+type AddressMonitor struct {
+	addresses []common.Address
+	wallets   map[string]*Wallet // by location
+
+	mu       sync.Mutex
+	incoming map[common.AddressBytes]int
+	received map[common.AddressBytes]*big.Int
+}
+
+// NewAddressMonitor connects to the node of every location the addresses belong to
+func NewAddressMonitor(addresses []common.Address, cfg *config.Config) (*AddressMonitor, error) {
+	m := &AddressMonitor{
+		addresses: addresses,
+		wallets:   make(map[string]*Wallet),
+		incoming:  make(map[common.AddressBytes]int),
+		received:  make(map[common.AddressBytes]*big.Int),
+	}
+	for _, address := range addresses {
+		loc := locationToString(common.LocationFromAddressBytes(address.Bytes()))
+		if _, ok := m.wallets[loc]; ok {
+			continue
+		}
+		w, err := NewWatchWallet(address, cfg)
+		if err != nil {
+			m.Close()
+			return nil, fmt.Errorf("failed to watch location %s: %w", loc, err)
+		}
+		m.wallets[loc] = w
+	}
+	return m, nil
+}
+
+// Watch reports incoming transactions to any monitored address from the latest block on, and
+// counts them in later snapshots. The channel is closed when ctx is done; it must be drained.
+func (m *AddressMonitor) Watch(ctx context.Context) (<-chan IncomingTx, error) {
+	byLocation := make(map[string][]common.Address, len(m.wallets))
+	for _, address := range m.addresses {
+		loc := locationToString(common.LocationFromAddressBytes(address.Bytes()))
+		byLocation[loc] = append(byLocation[loc], address)
+	}
+
+	out := make(chan IncomingTx)
+	var wg sync.WaitGroup
+	for loc, w := range m.wallets {
+		incoming, err := w.WatchAddresses(ctx, nil, byLocation[loc])
+		if err != nil {
+			return nil, fmt.Errorf("failed to watch location %s: %w", loc, err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for tx := range incoming {
+				m.recordIncoming(tx)
+				select {
+				case out <- tx:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out, nil
+}
+
+// recordIncoming adds an incoming transaction to its address totals
+func (m *AddressMonitor) recordIncoming(tx IncomingTx) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := tx.To.Bytes20()
+	m.incoming[key]++
+	if m.received[key] == nil {
+		m.received[key] = new(big.Int)
+	}
+	m.received[key].Add(m.received[key], tx.Value)
+}
+
+// Snapshot reads the current state of every monitored address, in the order they were given.
+// An address that can't be read carries the error instead of failing the whole snapshot.
+func (m *AddressMonitor) Snapshot(ctx context.Context) []AddressStatus {
+	statuses := make([]AddressStatus, len(m.addresses))
+	for i, address := range m.addresses {
+		statuses[i] = m.status(ctx, address)
+	}
+	return statuses
+}
+
+// status reads the balance and nonces of an address
+func (m *AddressMonitor) status(ctx context.Context, address common.Address) AddressStatus {
+	status := AddressStatus{Address: address, Balance: new(big.Int), Received: new(big.Int)}
+	w := m.wallets[locationToString(common.LocationFromAddressBytes(address.Bytes()))]
+
+	balance, err := w.rpc().BalanceAt(ctx, address.MixedcaseAddress(), nil)
+	if err != nil {
+		status.Err = fmt.Errorf("failed to get balance: %w", err)
+		return status
+	}
+	nonce, err := w.rpc().NonceAt(ctx, address.MixedcaseAddress(), nil)
+	if err != nil {
+		status.Err = fmt.Errorf("failed to get nonce: %w", err)
+		return status
+	}
+	pendingNonce, err := w.rpc().PendingNonceAt(ctx, address.MixedcaseAddress())
+	if err != nil {
+		status.Err = fmt.Errorf("failed to get pending nonce: %w", err)
+		return status
+	}
+
+	status.Balance = balance
+	status.Nonce = nonce
+	if pendingNonce > nonce {
+		status.Pending = pendingNonce - nonce
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	status.Incoming = m.incoming[address.Bytes20()]
+	if received := m.received[address.Bytes20()]; received != nil {
+		status.Received.Set(received)
+	}
+	return status
+}
+
+// Close disconnects from every node
+func (m *AddressMonitor) Close() {
+	for _, w := range m.wallets {
+		w.Close()
+	}
+}
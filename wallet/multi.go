@@ -0,0 +1,150 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	wtypes "quai-transfer/types"
+
+	"github.com/dominant-strategies/go-quai/common"
+)
+
+// RouteEntries groups entries by the wallet serving their destination location.
+// Entries whose location has no wallet are returned as unroutable.
+func RouteEntries(wallets []*Wallet, entries []*wtypes.TransferEntry) (map[*Wallet][]*wtypes.TransferEntry, []*wtypes.TransferEntry) {
+	byLocation := make(map[string]*Wallet, len(wallets))
+	for _, w := range wallets {
+		byLocation[locationToString(w.location)] = w
+	}
+
+	routes := make(map[*Wallet][]*wtypes.TransferEntry, len(wallets))
+	var unroutable []*wtypes.TransferEntry
+	for _, entry := range entries {
+		w, ok := byLocation[destinationLocation(entry.ToAddress)]
+		if !ok {
+			unroutable = append(unroutable, entry)
+			continue
+		}
+		routes[w] = append(routes[w], entry)
+	}
+	return routes, unroutable
+}
+
+// destinationLocation returns the location an address belongs to, or "" if it can't be decoded
+func destinationLocation(address string) string {
+	b := common.FromHex(address)
+	if len(b) == 0 {
+		return ""
+	}
+	return locationToString(common.LocationFromAddressBytes(b))
+}
+
+// Destinations validates each destination with the wallet of its location, for the checks of
+// a batch spread over several wallets
+type Destinations []*Wallet
+
+// ValidateDestination validates address with the wallet of its location
+func (ws Destinations) ValidateDestination(address string) error {
+	location := destinationLocation(address)
+	for _, w := range ws {
+		if locationToString(w.location) == location {
+			return w.ValidateDestination(address)
+		}
+	}
+	return fmt.Errorf("%w: no wallet for the location of %s", wtypes.ErrInvalidAddress, address)
+}
+
+// ProcessMultiLocationBatch routes entries to the wallet of their destination location and
+// runs every wallet's batch concurrently. Each wallet keeps its own nonces, gas tracking and
+// pending transactions, so the batches never interfere; the returned result merges them all.
+func ProcessMultiLocationBatch(ctx context.Context, wallets []*Wallet, entries []*wtypes.TransferEntry) (*BatchResult, error) {
+	if len(wallets) == 0 {
+		return nil, errors.New("no wallets to process the batch")
+	}
+	cfg := wallets[0].config
+
+	seen := make(map[string]bool, len(wallets))
+	for _, w := range wallets {
+		loc := locationToString(w.location)
+		if seen[loc] {
+			return nil, fmt.Errorf("more than one key for location %s", loc)
+		}
+		seen[loc] = true
+	}
+
+	routes, unroutable := RouteEntries(wallets, entries)
+	combined := &BatchResult{Total: len(unroutable), Invalid: len(unroutable)}
+	for _, entry := range unroutable {
+		logEntry(cfg, EntryInvalid, entry, "", fmt.Errorf("no wallet for the location of %s", entry.ToAddress),
+			"⚠️ TRANSFER INVALID | Miner: %s | ID: %d | no wallet for the location of %s", entry.MinerAccount, entry.ID, entry.ToAddress)
+	}
+
+	now := time.Now()
+	defer func() {
+		combined.Duration = time.Since(now)
+		title := "COMBINED TRANSFER SUMMARY"
+		if cfg.DryRun {
+			title = "DRY RUN " + title
+		}
+		logBatchSummary(title, combined)
+	}()
+
+	// Strict validation covers the whole invocation, so check every location before any of them broadcasts
+	if cfg.StrictValidation {
+		invalid := len(unroutable)
+		for w, batch := range routes {
+			for _, entry := range batch {
+				if err := w.validateEntry(entry); err != nil {
+					invalid++
+				}
+			}
+		}
+		if invalid > 0 {
+			combined.Total, combined.Invalid = len(entries), invalid
+			return combined, fmt.Errorf("%w: %d of %d entries are invalid, strict validation aborted the batch before broadcasting",
+				wtypes.ErrInvalidEntries, invalid, len(entries))
+		}
+	}
+
+	for _, w := range wallets {
+		// A prioritized batch cuts off what the balance can't cover instead
+		if batch := routes[w]; len(batch) > 0 && cfg.Priority == "" {
+			if err := CheckBalance(ctx, w, batch); err != nil {
+				return combined, fmt.Errorf("location %s: %w", locationToString(w.location), err)
+			}
+		}
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, w := range wallets {
+		batch := routes[w]
+		if len(batch) == 0 {
+			continue
+		}
+		log.Printf("🚀 BATCH STARTED | Location: %s | Entries: %d", locationToString(w.location), len(batch))
+
+		wg.Add(1)
+		go func(w *Wallet, batch []*wtypes.TransferEntry) {
+			defer wg.Done()
+			result, err := w.ProcessBatchEntry(ctx, batch)
+
+			mu.Lock()
+			defer mu.Unlock()
+			combined.merge(result)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("location %s: %w", locationToString(w.location), err))
+			}
+		}(w, batch)
+	}
+	wg.Wait()
+
+	return combined, errors.Join(errs...)
+}
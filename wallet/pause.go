@@ -0,0 +1,69 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Pause holds back the broadcasts of the wallets it's set on. While paused no new entry is
+// dispatched, but the transactions already broadcast keep being checked for receipts.
+type Pause struct {
+	mu      sync.Mutex
+	resumed chan struct{} // closed on resume, nil while not paused
+}
+
+// NewPause returns a Pause that isn't paused
+func NewPause() *Pause {
+	return &Pause{}
+}
+
+// Toggle pauses the broadcasts if they run and resumes them if paused. It reports whether
+// they are paused afterwards.
+func (p *Pause) Toggle() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.resumed != nil {
+		close(p.resumed)
+		p.resumed = nil
+		return false
+	}
+	p.resumed = make(chan struct{})
+	return true
+}
+
+// waiting returns a channel closed on resume, or nil when not paused. A nil Pause never pauses.
+func (p *Pause) waiting() <-chan struct{} {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.resumed
+}
+
+// SetPause sets the pause that holds back the wallet's broadcasts; wallets of one run share it
+func (w *Wallet) SetPause(pause *Pause) {
+	w.pause = pause
+}
+
+// awaitResume blocks while the broadcasts are paused, checking the receipts of the
+// transactions in flight every ReceiptWaitTime meanwhile
+func (w *Wallet) awaitResume(ctx context.Context) error {
+	for {
+		resumed := w.pause.waiting()
+		if resumed == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("batch paused: %w", ctx.Err())
+		case <-resumed:
+			return nil
+		case <-time.After(ReceiptWaitTime):
+		}
+		w.checkPendingTransactions()
+		w.saveProgress(false)
+	}
+}
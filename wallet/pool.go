@@ -0,0 +1,135 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	wtypes "quai-transfer/types"
+)
+
+// batchPool sends the entries of a batch on up to concurrency workers. Entries are still
+// created one at a time under nonceMutex, so nonces stay gapless and in order, while their
+// broadcasts and retries overlap. With a concurrency of one an entry is dispatched only once
+// the previous one is done, which is the plain serial loop.
+type batchPool struct {
+	w     *Wallet
+	slots chan struct{}
+	wg    sync.WaitGroup
+
+	mu     sync.Mutex
+	tally  BatchResult // outcomes counted by the workers, merged into the result by wait
+	failed error       // set at the first failed entry under fail fast
+
+	// receiptWait is how often awaitInFlight checks the receipts of the transactions in flight
+	receiptWait time.Duration
+}
+
+func (w *Wallet) newBatchPool() *batchPool {
+	return &batchPool{w: w, slots: make(chan struct{}, max(w.config.Concurrency, 1)), receiptWait: ReceiptWaitTime}
+}
+
+// acquire waits for a free worker, and for the broadcasts to be resumed if paused. Once an
+// entry failed under fail fast, or was dead-lettered leaving a nonce gap, it returns the
+// reason instead, and nothing more may be sent.
+func (p *batchPool) acquire(ctx context.Context) error {
+	if err := p.w.awaitResume(ctx); err != nil {
+		return err
+	}
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.failed != nil {
+		<-p.slots
+		return p.failed
+	}
+	return nil
+}
+
+// awaitInFlight blocks while max_in_flight transactions are broadcast and unconfirmed, checking
+// their receipts every receiptWait to free slots. Entries still on another worker count as
+// in flight, so the cap holds whatever the concurrency.
+func (p *batchPool) awaitInFlight(ctx context.Context) error {
+	w := p.w
+	if w.config.MaxInFlight <= 0 {
+		return nil
+	}
+	paused := false
+	for {
+		// The slot of the entry about to be sent isn't counted
+		inFlight := w.inFlightCount() + len(p.slots) - 1
+		if inFlight < w.config.MaxInFlight {
+			if paused {
+				log.Printf("▶️ BROADCAST RESUMED | In flight: %d of %d", inFlight, w.config.MaxInFlight)
+			}
+			return nil
+		}
+		if !paused {
+			paused = true
+			log.Printf("⏸️ IN-FLIGHT CAP | In flight: %d of %d | Pausing broadcast until transactions confirm", inFlight, w.config.MaxInFlight)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("in-flight transactions stayed at max_in_flight: %w", ctx.Err())
+		case <-time.After(p.receiptWait):
+		}
+		w.checkPendingTransactions()
+	}
+}
+
+// inFlightCount returns how many transactions were handed to the node and aren't confirmed yet
+func (w *Wallet) inFlightCount() int {
+	w.pendingTxMutex.RLock()
+	defer w.pendingTxMutex.RUnlock()
+	return len(w.pendingTxs)
+}
+
+// release gives back a worker acquired for an entry that won't be sent
+func (p *batchPool) release() {
+	<-p.slots
+}
+
+// send sends an entry on the worker acquired for it. An entry sent is seen through even if
+// ctx is canceled meanwhile, so its transaction isn't left half broadcast and untracked.
+func (p *batchPool) send(ctx context.Context, entry *wtypes.TransferEntry) {
+	ctx = context.WithoutCancel(ctx)
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer p.release()
+
+		var tally BatchResult
+		err := p.w.sendBatchEntry(ctx, entry, &tally)
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.tally.merge(&tally)
+		if err == nil || p.failed != nil {
+			return
+		}
+		if p.w.config.FailFast {
+			p.failed = fmt.Errorf("fail-fast after entry %d failed", entry.ID)
+		} else if errors.Is(err, wtypes.ErrNonceGap) {
+			// Every later nonce would wait on the dead letter, so nothing more is sent
+			p.failed = fmt.Errorf("entry %d dead-lettered at broadcast: %w", entry.ID, wtypes.ErrNonceGap)
+		}
+	}()
+}
+
+// wait waits for every entry sent to be broadcast and adds their outcomes to result
+func (p *batchPool) wait(result *BatchResult) {
+	p.wg.Wait()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	result.merge(&p.tally)
+	p.tally = BatchResult{}
+}
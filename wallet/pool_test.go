@@ -0,0 +1,104 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"quai-transfer/config"
+	"quai-transfer/dal/models"
+	wtypes "quai-transfer/types"
+)
+
+func TestPoolInFlightCap(t *testing.T) {
+	const (
+		entries     = 12
+		concurrency = 4
+		maxInFlight = 3
+	)
+
+	db := &fakeDB{}
+	batch := make([]*wtypes.TransferEntry, entries)
+	for i := range batch {
+		batch[i] = testEntry(int32(i+1), testQuaiAddress)
+		db.records = append(db.records, testRecord(t, batch[i], models.Generated))
+	}
+
+	var (
+		mu                   sync.Mutex
+		w                    *Wallet
+		sending, peakSending int
+		peakInFlight         int
+	)
+	node := newFakeNode()
+	node.handle("quai_sendRawTransaction", func([]json.RawMessage) (any, error) {
+		mu.Lock()
+		sending++
+		peakSending = max(peakSending, sending)
+		// The transaction being sent is already tracked as in flight
+		peakInFlight = max(peakInFlight, w.inFlightCount())
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		sending--
+		mu.Unlock()
+		return nil, nil
+	})
+	w = newFakeWallet(t, &config.Config{Concurrency: concurrency, MaxInFlight: maxInFlight}, node, db)
+
+	// Stands in for the monitor: a transaction in flight confirms now and then
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+			mu.Lock()
+			peakInFlight = max(peakInFlight, w.inFlightCount())
+			mu.Unlock()
+			w.pendingTxMutex.Lock()
+			for hash := range w.pendingTxs {
+				delete(w.pendingTxs, hash)
+				break
+			}
+			w.pendingTxMutex.Unlock()
+		}
+	}()
+
+	pool := w.newBatchPool()
+	pool.receiptWait = time.Millisecond
+	result := &BatchResult{Total: entries}
+	for _, entry := range batch {
+		if err := pool.acquire(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if err := pool.awaitInFlight(ctx); err != nil {
+			t.Fatal(err)
+		}
+		pool.send(ctx, entry)
+	}
+	pool.wait(result)
+
+	if sends := node.count("quai_sendRawTransaction"); sends != entries {
+		t.Fatalf("%d broadcasts, want %d", sends, entries)
+	}
+	if result.Failed > 0 || result.DeadLettered > 0 {
+		t.Fatalf("entries failed: %+v", result)
+	}
+	if peakInFlight > maxInFlight {
+		t.Errorf("peak of %d transactions in flight, over the cap of %d", peakInFlight, maxInFlight)
+	}
+	if peakSending > concurrency {
+		t.Errorf("peak of %d concurrent broadcasts, over the concurrency of %d", peakSending, concurrency)
+	}
+	if peakInFlight < maxInFlight {
+		t.Errorf("peak of %d transactions in flight never reached the cap of %d, the test didn't exercise it", peakInFlight, maxInFlight)
+	}
+}
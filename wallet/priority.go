@@ -0,0 +1,97 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	wtypes "quai-transfer/types"
+	"quai-transfer/utils"
+
+	"github.com/shopspring/decimal"
+)
+
+// Orders in which a batch can process its entries, config.Config.Priority
+const (
+	PriorityValueDesc = "value-desc"
+	PriorityValueAsc  = "value-asc"
+	PriorityID        = "id"
+)
+
+// priorityOrders holds the order of each priority, by whether entry a goes before entry b
+var priorityOrders = map[string]func(a, b *wtypes.TransferEntry) bool{
+	PriorityValueDesc: func(a, b *wtypes.TransferEntry) bool { return a.Value.GreaterThan(b.Value) },
+	PriorityValueAsc:  func(a, b *wtypes.TransferEntry) bool { return a.Value.LessThan(b.Value) },
+	PriorityID:        func(a, b *wtypes.TransferEntry) bool { return a.ID < b.ID },
+}
+
+// ValidatePriority checks that priority is one of the orders SortEntries knows, or empty
+func ValidatePriority(priority string) error {
+	if _, ok := priorityOrders[priority]; !ok && priority != "" {
+		return fmt.Errorf("invalid priority %q, must be %q, %q or %q", priority, PriorityValueDesc, PriorityValueAsc, PriorityID)
+	}
+	return nil
+}
+
+// SortEntries orders entries by priority, keeping the CSV order between equal entries. An
+// empty priority keeps the CSV order.
+func SortEntries(entries []*wtypes.TransferEntry, priority string) error {
+	if err := ValidatePriority(priority); err != nil {
+		return err
+	}
+	less, ok := priorityOrders[priority]
+	if !ok {
+		return nil
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return less(entries[i], entries[j]) })
+	return nil
+}
+
+// affordableEntries returns how many entries, in order, the balance covers with the same
+// fee allowance as the balance check
+func (w *Wallet) affordableEntries(ctx context.Context, entries []*wtypes.TransferEntry) (int, error) {
+	balance, err := w.GetBalance(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get balance: %w", err)
+	}
+	gasLimit, gasPrice, err := w.feeAllowance(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	have := decimal.NewFromBigInt(balance, 0)
+	required := decimal.Zero
+	for i, entry := range entries {
+		totals := totalsOf([]*wtypes.TransferEntry{entry})
+		required = required.Add(nativeValue(entry)).Add(totals.fee(gasLimit, gasPrice))
+		if have.LessThan(required) {
+			return i, nil
+		}
+	}
+	return len(entries), nil
+}
+
+// cutOffUnaffordable trims a prioritized batch to the entries the balance covers and marks
+// the rest unsent, so they are left for a later run instead of failing the whole batch
+func (w *Wallet) cutOffUnaffordable(ctx context.Context, entries []*wtypes.TransferEntry, result *BatchResult) ([]*wtypes.TransferEntry, error) {
+	n, err := w.affordableEntries(ctx, entries)
+	if err != nil {
+		return nil, err
+	}
+	if n == len(entries) {
+		return entries, nil
+	}
+
+	rest := totalsOf(entries[n:])
+	if n > 0 {
+		last := entries[n-1]
+		log.Printf("✂️ BALANCE CUTOFF | Order: %s | Sending %d of %d entries, up to ID %d (%s Quai) | Left for a later run: %d entries, %s Quai",
+			w.config.Priority, n, len(entries), last.ID, utils.ToQuai(last.Value.String()), rest.count, utils.ToQuai(rest.value.String()))
+	} else {
+		log.Printf("✂️ BALANCE CUTOFF | Order: %s | The balance covers none of the %d entries, %s Quai",
+			w.config.Priority, len(entries), utils.ToQuai(rest.value.String()))
+	}
+	result.markUnsent(entries[n:], fmt.Errorf("%w: cut off by %s priority", wtypes.ErrInsufficientBalance, w.config.Priority))
+	return entries[:n], nil
+}
@@ -0,0 +1,59 @@
+package wallet
+
+import (
+	"reflect"
+	"testing"
+
+	wtypes "quai-transfer/types"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestSortEntries(t *testing.T) {
+	entry := func(id int32, value int64) *wtypes.TransferEntry {
+		return &wtypes.TransferEntry{ID: id, Value: decimal.NewFromInt(value)}
+	}
+	tests := []struct {
+		priority string
+		want     []int32 // IDs in order
+	}{
+		{priority: "", want: []int32{3, 1, 4, 2}},
+		{priority: PriorityValueDesc, want: []int32{4, 1, 2, 3}},
+		{priority: PriorityValueAsc, want: []int32{3, 1, 2, 4}},
+		{priority: PriorityID, want: []int32{1, 2, 3, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.priority, func(t *testing.T) {
+			// 1 and 2 are of equal value and keep their CSV order
+			entries := []*wtypes.TransferEntry{entry(3, 5), entry(1, 20), entry(4, 30), entry(2, 20)}
+			if err := ValidatePriority(tt.priority); err != nil {
+				t.Fatal(err)
+			}
+			if err := SortEntries(entries, tt.priority); err != nil {
+				t.Fatal(err)
+			}
+			var got []int32
+			for _, e := range entries {
+				got = append(got, e.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("order %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePriority(t *testing.T) {
+	for _, priority := range []string{"value", "VALUE-DESC", "fee-desc"} {
+		if err := ValidatePriority(priority); err == nil {
+			t.Errorf("priority %q accepted", priority)
+		}
+		entries := []*wtypes.TransferEntry{{ID: 2}, {ID: 1}}
+		if err := SortEntries(entries, priority); err == nil {
+			t.Errorf("entries sorted by priority %q", priority)
+		}
+		if entries[0].ID != 2 {
+			t.Errorf("entries reordered by invalid priority %q", priority)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"quai-transfer/dal"
+	"quai-transfer/dal/models"
+)
+
+// progressInterval is the least time between two progress writes while a batch broadcasts;
+// monitor ticks and the end of a batch always write
+const progressInterval = 5 * time.Second
+
+// batchProgress counts the outcomes of the running batch and writes them to the batch_runs
+// table. A nil batchProgress counts and writes nothing.
+type batchProgress struct {
+	runs      *dal.BatchRunDAL
+	mu        sync.Mutex
+	run       models.BatchRun
+	writtenAt time.Time
+}
+
+// SetRunID identifies the transfer run the wallet's batches belong to, and turns on the
+// progress records of its batches in batch_runs
+func (w *Wallet) SetRunID(runID string) {
+	if runID == "" || dal.InterDB == nil {
+		w.progress = nil
+		return
+	}
+	w.progress = &batchProgress{
+		runs: dal.NewBatchRunDAL(dal.InterDB),
+		run: models.BatchRun{
+			RunID:    runID,
+			Payer:    w.address.Hex(),
+			Location: locationToString(w.location),
+		},
+	}
+}
+
+// startProgress resets the counters for a batch of total entries and records it as running
+func (w *Wallet) startProgress(total int) {
+	p := w.progress
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.run = models.BatchRun{
+		RunID:     p.run.RunID,
+		Payer:     p.run.Payer,
+		Location:  p.run.Location,
+		Status:    models.BatchRunning,
+		Total:     total,
+		StartedAt: time.Now(),
+	}
+	p.mu.Unlock()
+	w.saveProgress(true)
+}
+
+// count adds to the counters of the running batch
+func (p *batchProgress) count(broadcast, confirmed, failed int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.run.Broadcast += broadcast
+	p.run.Confirmed += confirmed
+	p.run.Failed += failed
+}
+
+// saveProgress writes the counters of the running batch, at most every progressInterval
+// unless force is set. Callers must not hold pendingTxMutex.
+func (w *Wallet) saveProgress(force bool) {
+	p := w.progress
+	if p == nil {
+		return
+	}
+	queueDepth := w.inFlightCount()
+
+	p.mu.Lock()
+	if !force && time.Since(p.writtenAt) < progressInterval {
+		p.mu.Unlock()
+		return
+	}
+	p.writtenAt = time.Now()
+	p.run.QueueDepth = queueDepth
+	p.run.UpdatedAt = p.writtenAt
+	run := p.run
+	p.mu.Unlock()
+
+	p.write(&run)
+}
+
+// finishProgress finalizes the record of the batch with its result
+func (w *Wallet) finishProgress(result *BatchResult) {
+	p := w.progress
+	if p == nil {
+		return
+	}
+	var summary *string
+	if encoded, err := json.Marshal(result); err != nil {
+		log.Printf("failed to encode the summary of run %s: %v", p.run.RunID, err)
+	} else {
+		s := string(encoded)
+		summary = &s
+	}
+
+	p.mu.Lock()
+	now := time.Now()
+	p.writtenAt = now
+	p.run.Status = models.BatchFinished
+	p.run.Confirmed = result.Success
+	p.run.Failed = result.Failed + result.Invalid + result.DeadLettered
+	p.run.QueueDepth = result.Unprocessed
+	p.run.Summary = summary
+	p.run.UpdatedAt = now
+	p.run.FinishedAt = &now
+	run := p.run
+	p.mu.Unlock()
+
+	p.write(&run)
+}
+
+// write saves a progress record. Progress is only informative, so a failed write is logged
+// and the batch carries on.
+func (p *batchProgress) write(run *models.BatchRun) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := p.runs.SaveBatchRun(ctx, run); err != nil {
+		log.Printf("failed to save the progress of run %s: %v", run.RunID, err)
+	}
+}
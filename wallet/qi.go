@@ -0,0 +1,168 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/types"
+)
+
+// GetUTXOs returns the unlocked outpoints owned by the wallet's Qi address
+func (w *Wallet) GetUTXOs(ctx context.Context) ([]*types.OutpointAndDenomination, error) {
+	outpoints, err := w.rpc().GetOutpointsByAddress(ctx, w.QiAddress().MixedcaseAddress())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get outpoints: %w", err)
+	}
+	latest, err := w.rpc().BlockNumber(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block number: %w", err)
+	}
+
+	unlocked := outpoints[:0]
+	for _, outpoint := range outpoints {
+		if outpoint == nil || outpoint.Denomination > types.MaxDenomination {
+			continue
+		}
+		if outpoint.Lock != nil && outpoint.Lock.Cmp(new(big.Int).SetUint64(latest)) > 0 {
+			continue
+		}
+		unlocked = append(unlocked, outpoint)
+	}
+	return unlocked, nil
+}
+
+// qiValue returns the value of a denomination in qits
+func qiValue(denomination uint8) *big.Int {
+	return types.Denominations[denomination]
+}
+
+// changeDenominations breaks change into the fewest denominations the inputs can pay. The node
+// lets a larger input be split into smaller outputs but never smaller inputs combine into a
+// larger output, so it walks the denominations from the largest down the way the node checks
+// them, carrying whatever is left at each one into the next smaller one. The first input backs
+// the payment and only what it has left over is available.
+func changeDenominations(inputs []uint8, payment uint8, change *big.Int) []uint8 {
+	available := make([]uint64, types.MaxDenomination+1)
+	for _, d := range inputs[1:] {
+		available[d]++
+	}
+	surplus := new(big.Int).Sub(qiValue(inputs[0]), qiValue(payment))
+	for d := int(inputs[0]) - 1; d >= 0; d-- {
+		for surplus.Cmp(qiValue(uint8(d))) >= 0 {
+			available[d]++
+			surplus.Sub(surplus, qiValue(uint8(d)))
+		}
+	}
+
+	var denominations []uint8
+	rest := new(big.Int).Set(change)
+	for d := int(types.MaxDenomination); d >= 0; d-- {
+		value := qiValue(uint8(d))
+		for available[d] > 0 && rest.Cmp(value) >= 0 {
+			denominations = append(denominations, uint8(d))
+			rest.Sub(rest, value)
+			available[d]--
+		}
+		if d > 0 {
+			ratio := new(big.Int).Div(value, qiValue(uint8(d-1))).Uint64()
+			available[d-1] += available[d] * ratio
+		}
+	}
+	return denominations
+}
+
+// qiChangeOutputs pays change to qi_change_addresses, one denomination per address since the
+// node rejects a transaction that pays an address twice or pays back one of its inputs
+func (w *Wallet) qiChangeOutputs(denominations []uint8) (types.TxOuts, error) {
+	if len(denominations) > len(w.config.QiChangeAddresses) {
+		return nil, fmt.Errorf("change needs %d change addresses, qi_change_addresses has %d; add more or spend an outpoint closer to the amount",
+			len(denominations), len(w.config.QiChangeAddresses))
+	}
+
+	outs := make(types.TxOuts, 0, len(denominations))
+	for i, d := range denominations {
+		address := common.HexToAddress(w.config.QiChangeAddresses[i], w.location)
+		if !address.Location().Equal(w.location) {
+			return nil, fmt.Errorf("qi change address %s is not in location %s", address.Hex(), locationToString(w.location))
+		}
+		outs = append(outs, *types.NewTxOut(d, address.Bytes(), big.NewInt(0)))
+	}
+	return outs, nil
+}
+
+// buildQiTx selects the wallet outpoints paying one output of the given denomination plus the
+// fee, and returns the unsigned transaction parameters with change outputs. The payment has to
+// be backed by an outpoint of at least its denomination, as the node doesn't let smaller
+// denominations combine into a larger one; further outpoints are added, smallest first, until
+// the fee estimated by the node is covered.
+func (w *Wallet) buildQiTx(ctx context.Context, to common.Address, denomination uint8) (TxParams, error) {
+	utxos, err := w.GetUTXOs(ctx)
+	if err != nil {
+		return TxParams{}, err
+	}
+	sort.Slice(utxos, func(i, j int) bool { return utxos[i].Denomination < utxos[j].Denomination })
+
+	// The smallest outpoint that can back the payment goes first
+	first := -1
+	for i, utxo := range utxos {
+		if utxo.Denomination >= denomination {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return TxParams{}, fmt.Errorf("no unlocked outpoint of denomination %d or more to pay %s qits", denomination, qiValue(denomination))
+	}
+	utxos = append([]*types.OutpointAndDenomination{utxos[first]}, append(utxos[:first:first], utxos[first+1:]...)...)
+
+	pubKey := w.schnorrKey().PubKey().SerializeUncompressed()
+	params := TxParams{
+		Type:    QiTxType,
+		ChainID: w.chainID.Actual,
+		TxOut:   types.TxOuts{*types.NewTxOut(denomination, to.Bytes(), big.NewInt(0))},
+	}
+	payment := qiValue(denomination)
+	total := new(big.Int)
+	var inputs []uint8
+	for _, utxo := range utxos {
+		params.TxIn = append(params.TxIn, *types.NewTxIn(types.NewOutPoint(&utxo.TxHash, utxo.Index), pubKey, nil))
+		inputs = append(inputs, utxo.Denomination)
+		total.Add(total, qiValue(utxo.Denomination))
+		if total.Cmp(payment) <= 0 {
+			continue
+		}
+
+		// Estimate with a change output per change denomination in place, as the fee grows with the outputs
+		draft := params
+		draft.TxOut = append(types.TxOuts{}, params.TxOut...)
+		for _, d := range changeDenominations(inputs, denomination, new(big.Int).Sub(total, payment)) {
+			draft.TxOut = append(draft.TxOut, *types.NewTxOut(d, w.QiAddress().Bytes(), big.NewInt(0)))
+		}
+		fee, err := w.rpc().EstimateFeeForQi(ctx, buildTx(draft))
+		if err != nil {
+			return TxParams{}, fmt.Errorf("failed to estimate qi fee: %w", err)
+		}
+
+		change := new(big.Int).Sub(total, payment)
+		change.Sub(change, fee)
+		if change.Sign() < 0 {
+			continue
+		}
+		changeOuts, err := w.qiChangeOutputs(changeDenominations(inputs, denomination, change))
+		if err != nil {
+			return TxParams{}, err
+		}
+		params.TxOut = append(params.TxOut, changeOuts...)
+		if w.config.Debug {
+			log.Printf("Qi inputs: %d worth %s qits, payment %s qits, fee %s qits, change %s qits in %d outputs",
+				len(params.TxIn), total, payment, fee, change, len(changeOuts))
+		}
+		return params, nil
+	}
+	return TxParams{}, errors.New("insufficient unlocked qi to cover the payment and fee")
+}
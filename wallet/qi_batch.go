@@ -0,0 +1,344 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"quai-transfer/dal/models"
+	"quai-transfer/eventlog"
+	"quai-transfer/report"
+	wtypes "quai-transfer/types"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/common/hexutil"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/shopspring/decimal"
+)
+
+// qiDenomination returns the denomination worth exactly value qits
+func qiDenomination(value decimal.Decimal) (uint8, bool) {
+	for d := uint8(0); d <= types.MaxDenomination; d++ {
+		if value.Equal(decimal.NewFromBigInt(qiValue(d), 0)) {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// qiDenominationValues lists the values of the Qi denominations, for error messages
+func qiDenominationValues() string {
+	values := make([]string, 0, types.MaxDenomination+1)
+	for d := uint8(0); d <= types.MaxDenomination; d++ {
+		values = append(values, qiValue(d).String())
+	}
+	return strings.Join(values, ", ")
+}
+
+// validateQiEntry checks an entry of a Qi batch: a Qi ledger destination in the wallet's
+// location, as for protocol qi ValidateDestination requires, and a value of exactly one
+// denomination, which it returns. Calldata and tokens are Quai only.
+func (w *Wallet) validateQiEntry(entry *wtypes.TransferEntry) (uint8, error) {
+	if !w.IsValidQiAddress(entry.ToAddress) {
+		if err := w.ValidateDestination(entry.ToAddress); err != nil {
+			return 0, err
+		}
+		return 0, fmt.Errorf("%w: %s is not a Qi address", wtypes.ErrLedgerMismatch, entry.ToAddress)
+	}
+	if len(entry.Data) > 0 || entry.TokenAddress != "" {
+		return 0, errors.New("qi payouts can't carry data or pay a token")
+	}
+	if entry.GasLimit > 0 || entry.GasPrice != nil {
+		return 0, errors.New("qi payouts have no gas, leave gas_limit and gas_price empty")
+	}
+	denomination, ok := qiDenomination(entry.Value)
+	if !ok {
+		return 0, fmt.Errorf("%w: %s qits is not a Qi denomination, a Qi payout pays exactly one of %s",
+			wtypes.ErrInvalidValue, entry.Value, qiDenominationValues())
+	}
+	return denomination, nil
+}
+
+// QiBalance returns the value of the wallet's unlocked outpoints, in qits
+func (w *Wallet) QiBalance(ctx context.Context) (*big.Int, error) {
+	utxos, err := w.GetUTXOs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	total := new(big.Int)
+	for _, utxo := range utxos {
+		total.Add(total, qiValue(utxo.Denomination))
+	}
+	return total, nil
+}
+
+// CheckQiBalance fails when the wallet's unlocked Qi can't cover the total value of entries.
+// Fees come on top, and the outpoints must also break down into the denominations paid, so
+// passing doesn't guarantee every payment can be built.
+func CheckQiBalance(ctx context.Context, w *Wallet, entries []*wtypes.TransferEntry) error {
+	balance, err := w.QiBalance(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get qi balance: %w", err)
+	}
+	required := decimal.Zero
+	for _, entry := range entries {
+		required = required.Add(entry.Value)
+	}
+	have := decimal.NewFromBigInt(balance, 0)
+	if have.LessThan(required) {
+		return fmt.Errorf("%w for qi transfers: have %s qits, need %s qits", wtypes.ErrInsufficientBalance, have, required)
+	}
+	log.Printf("qi balance check passed, have %s qits, need at least %s qits plus fees", have, required)
+	return nil
+}
+
+// ProcessQiBatch pays entries in Qi, one after the other: a payment spends unlocked outpoints
+// of the wallet, which the next one can only choose from once it's mined. Each entry is one
+// output, so its value must be exactly one Qi denomination, in qits, with the change paid to
+// qi_change_addresses. Payments are recorded like Quai ones, so a re-run skips the entries
+// paid and rebroadcasts one left pending. Qi transactions have no receipt: they're confirmed
+// once mined. The batch stops at a payment that isn't mined in time, as the outpoints it
+// spends would be chosen again.
+func (w *Wallet) ProcessQiBatch(ctx context.Context, entries []*wtypes.TransferEntry) (*BatchResult, error) {
+	if qiAddress := w.QiAddress(); !IsInQiLedgerScope(qiAddress.Hex()) {
+		return nil, fmt.Errorf("%w: wallet address %s is not in the qi ledger", wtypes.ErrLedgerMismatch, qiAddress.Hex())
+	}
+
+	result := &BatchResult{Total: len(entries)}
+	defer w.finishBatch(result, time.Now())
+	w.startProgress(result.Total)
+
+	validEntries := make([]*wtypes.TransferEntry, 0, len(entries))
+	denominations := make(map[int32]uint8, len(entries))
+	for _, entry := range entries {
+		denomination, err := w.validateQiEntry(entry)
+		if err != nil {
+			result.Invalid++
+			logEntry(w.config, EntryInvalid, entry, "", err, "⚠️ TRANSFER INVALID | Miner: %s | ID: %d | %v", entry.MinerAccount, entry.ID, err)
+			w.recordFailure(entry, report.StatusInvalid, err)
+			continue
+		}
+		validEntries = append(validEntries, entry)
+		denominations[entry.ID] = denomination
+	}
+
+	if result.Invalid > 0 && w.config.StrictValidation {
+		return result, fmt.Errorf("%w: %d of %d entries are invalid, strict validation aborted the batch before broadcasting",
+			wtypes.ErrInvalidEntries, result.Invalid, result.Total)
+	}
+
+	w.resetBatchState()
+	for i, entry := range validEntries {
+		if err := ctx.Err(); err != nil {
+			result.markUnsent(validEntries[i:], err)
+			break
+		}
+		failed, err := w.payQiEntry(ctx, entry, denominations[entry.ID], result)
+		if err == nil && failed && w.config.FailFast {
+			err = fmt.Errorf("fail-fast after entry %d failed", entry.ID)
+		}
+		if err != nil {
+			if i+1 < len(validEntries) {
+				result.markUnsent(validEntries[i+1:], err)
+			}
+			break
+		}
+	}
+
+	result.Success = result.Total - result.Invalid - result.Failed - result.Processed - result.Unprocessed - result.Unsent - result.DeadLettered
+	return result, nil
+}
+
+// payQiEntry pays one entry of a Qi batch and waits for it to be mined, counting its outcome.
+// It reports whether the entry failed, for fail fast, and returns an error when the batch
+// must stop.
+func (w *Wallet) payQiEntry(ctx context.Context, entry *wtypes.TransferEntry, denomination uint8, result *BatchResult) (bool, error) {
+	fail := func(err error) (bool, error) {
+		result.Failed++
+		logEntry(w.config, EntryFailed, entry, "", err, "❌ TRANSFER FAILED | Miner: %s | ID: %d | Error: %v", entry.MinerAccount, entry.ID, err)
+		w.recordFailure(entry, report.StatusFailed, err)
+		return true, nil
+	}
+
+	tx, err := w.getStoredTransaction(ctx, entry)
+	if errors.Is(err, wtypes.ErrAlreadyProcessed) {
+		result.Processed++
+		logEntry(w.config, EntrySkipped, entry, "", nil, "⏭️ TRANSFER SKIPPED | Miner: %s | ID: %d | Already processed", entry.MinerAccount, entry.ID)
+		return false, nil
+	}
+	if errors.Is(err, wtypes.ErrDeadLettered) {
+		result.DeadLettered++
+		logEntry(w.config, EntryDeadLettered, entry, "", err, "🪦 TRANSFER DEAD-LETTERED | Miner: %s | ID: %d | Error: %v", entry.MinerAccount, entry.ID, err)
+		w.recordFailure(entry, report.StatusDeadLettered, err)
+		return true, nil
+	}
+	if err != nil {
+		return fail(err)
+	}
+
+	if tx != nil {
+		if tx.Type() != types.QiTxType {
+			return fail(fmt.Errorf("entry %d is recorded with Quai transaction %s, not a Qi payment", entry.ID, tx.Hash().Hex()))
+		}
+		// A pending payment may have been mined since, its outpoints are spent then
+		block, err := w.qiTxBlock(ctx, tx.Hash())
+		if err != nil {
+			return false, fmt.Errorf("failed to look up pending qi transaction %s: %w", tx.Hash().Hex(), err)
+		}
+		if block > 0 {
+			w.confirmQiPayment(ctx, entry, tx, block, time.Time{})
+			return false, nil
+		}
+		log.Printf("Entry ID %d: rebroadcasting pending qi transaction %s\n", entry.ID, tx.Hash().Hex())
+	} else if tx, err = w.createQiTransaction(ctx, entry, denomination); err != nil {
+		return fail(err)
+	}
+
+	// Once signed and recorded, the payment is seen through even if ctx is canceled meanwhile.
+	// Transient errors are retried, and a payment already known to the node counts as sent.
+	if err := w.broadcastWithRetry(context.WithoutCancel(ctx), entry, tx); err != nil {
+		if !errors.Is(err, wtypes.ErrDeadLettered) {
+			return fail(err)
+		}
+		result.DeadLettered++
+		logEntry(w.config, EntryDeadLettered, entry, tx.Hash().Hex(), err, "🪦 TRANSFER DEAD-LETTERED | Miner: %s | ID: %d | Error: %v", entry.MinerAccount, entry.ID, err)
+		w.recordFailure(entry, report.StatusDeadLettered, err)
+		return true, nil
+	}
+	if w.config.DryRun {
+		return false, nil
+	}
+	broadcastAt := time.Now()
+	logEntry(w.config, EntryBroadcast, entry, tx.Hash().Hex(), nil,
+		"📤 QI TRANSFER SENT | Miner: %s | ID: %d | Amount: %s qits | Tx Hash: %s", entry.MinerAccount, entry.ID, entry.Value, tx.Hash().Hex())
+
+	block, err := w.awaitQiTx(ctx, tx.Hash())
+	if err != nil {
+		result.Unprocessed++
+		log.Printf("😓 QI TRANSFER UNCONFIRMED | ID: %d | Tx Hash: %s | Error: %v", entry.ID, tx.Hash().Hex(), err)
+		return false, fmt.Errorf("qi transaction %s of entry %d not mined: %w", tx.Hash().Hex(), entry.ID, err)
+	}
+	w.confirmQiPayment(ctx, entry, tx, block, broadcastAt)
+	return false, nil
+}
+
+// createQiTransaction builds and signs the Qi payment of an entry and records it
+func (w *Wallet) createQiTransaction(ctx context.Context, entry *wtypes.TransferEntry, denomination uint8) (*types.Transaction, error) {
+	to := common.HexToAddress(entry.ToAddress, w.GetLocation())
+	params, err := w.buildQiTx(ctx, to, denomination)
+	if err != nil {
+		return nil, err
+	}
+	signedTx, err := w.signQiTx(params)
+	if err != nil {
+		return nil, err
+	}
+	w.events.Append(eventlog.Event{
+		Type:    eventlog.TxSigned,
+		EntryID: entry.ID,
+		TxHash:  signedTx.Hash().Hex(),
+		Data:    map[string]any{"to": to.Hex(), "value": entry.Value.String(), "inputs": len(params.TxIn), "outputs": len(params.TxOut)},
+	})
+
+	txJSON, err := json.Marshal(signedTx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize transaction: %v", err)
+	}
+	entryJSON, err := json.Marshal(entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize entry: %v", err)
+	}
+	txRecord := &models.Transaction{
+		ID:           entry.ID,
+		MinerAccount: entry.MinerAccount,
+		Payer:        w.QiAddress().Hex(),
+		ToAddress:    to.Hex(),
+		TxHash:       signedTx.Hash().Hex(),
+		Value:        entry.Value,
+		AggregateIds: entry.AggregateIds,
+		Status:       models.Generated,
+		CreatedAt:    time.Now(),
+		Tx:           string(txJSON),
+		Entry:        string(entryJSON),
+	}
+	if entry.IdempotencyKey != "" {
+		txRecord.IdempotencyKey = &entry.IdempotencyKey
+	}
+	if err := w.txDAL.CreateTransaction(ctx, txRecord); err != nil {
+		return nil, fmt.Errorf("failed to create transaction record: %v", err)
+	}
+	log.Printf("Created qi transaction record: %d, hash: %s, inputs: %d, outputs: %d\n", txRecord.ID, txRecord.TxHash, len(params.TxIn), len(params.TxOut))
+	return signedTx, nil
+}
+
+// qiTxBlock returns the number of the block that included a transaction, or 0 while it's
+// pending or unknown. The transaction is read as raw JSON, since the client can't decode the
+// Schnorr-signed transactions of Qi.
+func (w *Wallet) qiTxBlock(ctx context.Context, txHash common.Hash) (uint64, error) {
+	var tx *struct {
+		BlockNumber *hexutil.Big `json:"blockNumber"`
+	}
+	if err := w.rawRPC().CallContext(ctx, &tx, "quai_getTransactionByHash", txHash); err != nil {
+		return 0, err
+	}
+	if tx == nil || tx.BlockNumber == nil {
+		return 0, nil
+	}
+	return tx.BlockNumber.ToInt().Uint64(), nil
+}
+
+// awaitQiTx polls every ReceiptWaitTime until a Qi transaction is mined, for up to
+// MonitorTimeout, and returns its block number
+func (w *Wallet) awaitQiTx(ctx context.Context, txHash common.Hash) (uint64, error) {
+	ctx, cancel := context.WithTimeout(ctx, MonitorTimeout)
+	defer cancel()
+	for {
+		block, err := w.qiTxBlock(ctx, txHash)
+		if err != nil {
+			log.Printf("failed to look up qi transaction %s: %v", txHash.Hex(), err)
+		} else if block > 0 {
+			return block, nil
+		}
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(ReceiptWaitTime):
+		}
+	}
+}
+
+// confirmQiPayment records a Qi payment mined in block and reports it like a confirmed Quai
+// transfer. broadcastAt is zero for a payment found mined before it was rebroadcast.
+func (w *Wallet) confirmQiPayment(ctx context.Context, entry *wtypes.TransferEntry, tx *types.Transaction, block uint64, broadcastAt time.Time) {
+	blockTime := w.blockTimeAt(ctx, new(big.Int).SetUint64(block))
+	err := w.retryDBWrite(ctx, "confirm qi tx "+tx.Hash().Hex(), func() error {
+		return w.txDAL.MarkConfirmed(ctx, tx.Hash().Hex(), block, blockTime)
+	})
+	if err != nil {
+		log.Printf("🚨 RECORD OUT OF SYNC | Tx Hash: %s | Block: %d | The qi transaction is mined but its record could not be updated, reconcile it manually | Error: %v",
+			tx.Hash().Hex(), block, err)
+	}
+
+	var latency time.Duration
+	if !broadcastAt.IsZero() {
+		latency = time.Since(broadcastAt)
+	}
+	w.metrics.Confirmed(latency)
+	w.progress.count(0, 1, 0)
+	w.events.Append(eventlog.Event{Type: eventlog.TxConfirmed, EntryID: entry.ID, TxHash: tx.Hash().Hex(), Data: map[string]any{"block_number": block}})
+	w.writeResult(report.Row{
+		ID:          entry.ID,
+		TxHash:      tx.Hash().Hex(),
+		Status:      report.StatusConfirmed,
+		Value:       entry.Value,
+		BlockNumber: block,
+		BlockTime:   blockTime,
+	})
+	logEntry(w.config, EntryConfirmed, entry, tx.Hash().Hex(), nil,
+		"✅ QI TRANSFER CONFIRMED | Miner: %s | ID: %d | Amount: %s qits | Tx Hash: %s | Block: %d", entry.MinerAccount, entry.ID, entry.Value, tx.Hash().Hex(), block)
+}
@@ -0,0 +1,167 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"quai-transfer/config"
+	"quai-transfer/dal/models"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/crypto"
+)
+
+// testQiKey is the Keccak hash of "quai-transfer qi key 1486", whose address in zone 0-0 is in
+// the Qi ledger; never use it for funds
+const testQiKey = "5d07b13427dae1bea979be99b9002e8d1bc9b6882912b596b657f99ceb07d6a0"
+
+// newTestQiWallet returns a wallet of zone 0-0 holding testQiKey
+func newTestQiWallet(t *testing.T) *Wallet {
+	t.Helper()
+	key, err := crypto.HexToECDSA(testQiKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := newTestWallet(&config.Config{Protocol: "qi"})
+	w.privateKey = key
+	return w
+}
+
+// testQiParams pays one denomination from an outpoint owned by pubKey
+func testQiParams(pubKey []byte, chainID *big.Int) TxParams {
+	prevTx := common.HexToHash("0x00b4000000000000000000000000000000000000000000000000000000000001")
+	to := common.HexToAddress(testQiAddress, common.Location{0, 0})
+	return TxParams{
+		Type:    QiTxType,
+		ChainID: chainID,
+		TxIn:    types.TxIns{*types.NewTxIn(types.NewOutPoint(&prevTx, 0), pubKey, nil)},
+		TxOut:   types.TxOuts{*types.NewTxOut(1, to.Bytes(), big.NewInt(0))},
+	}
+}
+
+func TestSignQiTx(t *testing.T) {
+	w := newTestQiWallet(t)
+	qiAddress := w.QiAddress()
+	if !IsInQiLedgerScope(qiAddress.Hex()) {
+		t.Fatalf("test key address %s is not in the qi ledger", qiAddress.Hex())
+	}
+
+	chainID := big.NewInt(9000)
+	signed, err := w.signQiTx(testQiParams(w.schnorrKey().PubKey().SerializeUncompressed(), chainID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// As the node checks it: the signature over the signer hash verifies against the public
+	// key of every input, and that key is the one of the wallet's Qi address
+	sig := signed.GetSchnorrSignature()
+	if sig == nil {
+		t.Fatal("signed transaction has no signature")
+	}
+	digest := types.NewSigner(chainID, w.location).Hash(signed)
+	for i, in := range signed.TxIn() {
+		pubKey, err := btcec.ParsePubKey(in.PubKey)
+		if err != nil {
+			t.Fatalf("input %d: %v", i, err)
+		}
+		if !sig.Verify(digest[:], pubKey) {
+			t.Errorf("input %d: signature does not verify", i)
+		}
+		if owner := crypto.PubkeyBytesToAddress(in.PubKey, w.location); !owner.Equal(qiAddress) {
+			t.Errorf("input %d is owned by %s, want the wallet Qi address %s", i, owner.Hex(), qiAddress.Hex())
+		}
+	}
+}
+
+func TestSignQiTxForeignInput(t *testing.T) {
+	w := newTestQiWallet(t)
+	other, err := crypto.HexToECDSA("13221fe46bde6a5de07d45248101760b6e32ccd6d36e97ae6950ba95298e4da6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx, err := w.signQiTx(testQiParams(crypto.FromECDSAPub(&other.PublicKey), big.NewInt(9000))); err == nil {
+		t.Fatalf("signed %s spending an outpoint of another key", tx.Hash().Hex())
+	}
+}
+
+func TestPayQiEntryBroadcast(t *testing.T) {
+	tests := []struct {
+		name         string
+		errs         []string // errors of the successive broadcasts, then success
+		wantSends    int
+		deadLettered bool
+	}{
+		{name: "already known counts as sent", errs: []string{"already known"}, wantSends: 1},
+		{name: "transient error retried", errs: []string{"connection reset by peer"}, wantSends: 2},
+		{name: "permanent error not retried", errs: []string{"insufficient funds for gas * price + value"}, wantSends: 1, deadLettered: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer := newTestQiWallet(t)
+			signed, err := signer.signQiTx(testQiParams(signer.schnorrKey().PubKey().SerializeUncompressed(), big.NewInt(9000)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			entry := testEntry(1, testQiAddress)
+			txJSON, err := json.Marshal(signed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			entryJSON, err := json.Marshal(entry)
+			if err != nil {
+				t.Fatal(err)
+			}
+			db := &fakeDB{records: []*models.Transaction{{ID: entry.ID, TxHash: signed.Hash().Hex(), Status: models.Generated, Tx: string(txJSON), Entry: string(entryJSON)}}}
+
+			// The payment is mined once the node has accepted it
+			var (
+				sends    int
+				accepted bool
+			)
+			node := newFakeNode()
+			node.handle("quai_sendRawTransaction", func([]json.RawMessage) (any, error) {
+				sends++
+				if sends > len(tt.errs) {
+					accepted = true
+					return nil, nil
+				}
+				err := tt.errs[sends-1]
+				accepted = ClassifyRPCError(errors.New(err)) == RPCErrorKnown
+				return nil, errors.New(err)
+			})
+			node.handle("quai_getTransactionByHash", func([]json.RawMessage) (any, error) {
+				if !accepted {
+					return nil, nil
+				}
+				return map[string]string{"blockNumber": "0x11"}, nil
+			})
+			w := newFakeWallet(t, &config.Config{Protocol: "qi", MaxRetries: 2, RetryBackoff: time.Millisecond}, node, db)
+			w.privateKey = signer.privateKey
+
+			result := &BatchResult{Total: 1}
+			failed, err := w.payQiEntry(context.Background(), entry, 0, result)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sends != tt.wantSends {
+				t.Errorf("%d broadcasts, want %d", sends, tt.wantSends)
+			}
+			if failed != tt.deadLettered {
+				t.Errorf("entry failed: %v, want %v", failed, tt.deadLettered)
+			}
+			deadLetters := db.executed("failure_code")
+			if tt.deadLettered && (result.DeadLettered != 1 || len(deadLetters) == 0) {
+				t.Errorf("entry not dead-lettered: %+v", result)
+			}
+			if !tt.deadLettered && (result.DeadLettered > 0 || len(deadLetters) > 0) {
+				t.Errorf("entry dead-lettered: %v", deadLetters)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package wallet
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/shopspring/decimal"
+)
+
+// Database writes made after a transaction reached the node are retried, as failing them
+// would leave the records behind the chain
+const (
+	dbWriteAttempts = 5
+	dbWriteBackoff  = 500 * time.Millisecond
+)
+
+// retryDBWrite runs a database write until it succeeds, up to dbWriteAttempts times with
+// exponential backoff, and returns the last error
+func (w *Wallet) retryDBWrite(ctx context.Context, what string, write func() error) error {
+	backoff := dbWriteBackoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = write(); err == nil || attempt >= dbWriteAttempts {
+			return err
+		}
+		log.Printf("🔁 DB RETRY | %s | Attempt: %d/%d | Backoff: %s | %v", what, attempt, dbWriteAttempts, backoff, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// recordReceipt stores the receipt of a mined transaction and reports its outcome. The
+// transaction is on chain whatever happens to the database, so a write that still fails after
+// its retries is logged with everything needed to reconcile the record by hand, and the
+// transfer is reported as the receipt says rather than as failed.
+func (w *Wallet) recordReceipt(ctx context.Context, tx *types.Transaction, receipt *types.Receipt) {
+	gasUsedAmount := decimal.NewFromInt(int64(receipt.GasUsed)).Mul(decimal.NewFromBigInt(tx.GasPrice(), 0))
+	blockTime := w.blockTime(ctx, receipt)
+
+	err := w.retryDBWrite(ctx, "update status of tx "+tx.Hash().Hex(), func() error {
+		return w.txDAL.UpdateTransactionStatus(ctx, tx.Hash().Hex(), gasUsedAmount, receipt, blockTime)
+	})
+	if err != nil {
+		log.Printf("🚨 RECORD OUT OF SYNC | Tx Hash: %s | Nonce: %d | Status: %d | Block: %s | Gas Used: %d | "+
+			"Fee: %s wei | The transaction is mined but its record could not be updated, reconcile it manually | Error: %v",
+			tx.Hash().Hex(), tx.Nonce(), receipt.Status, receipt.BlockNumber, receipt.GasUsed, gasUsedAmount, err)
+	}
+	w.recordConfirmation(tx, receipt, blockTime)
+	w.trackMinedNonce(tx, receipt)
+}
@@ -0,0 +1,80 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/dominant-strategies/go-quai/common"
+)
+
+// ResumePending picks up the transactions a previous run signed and recorded but never saw
+// confirmed, for instance because it was killed mid-batch. Each one is broadcast again, which
+// is harmless for a transaction the node already has or has mined, its nonce is reserved so
+// no new entry reuses it, and all of them are monitored until they confirm. Transactions
+// still unconfirmed when monitoring times out stay pending and are monitored with the next
+// batch.
+func (w *Wallet) ResumePending(ctx context.Context) error {
+	records, err := w.txDAL.ListPendingByPayer(ctx, w.address.Hex())
+	if err != nil {
+		return err
+	}
+	resumed := make(map[common.Hash]*PendingTx, len(records))
+	for _, record := range records {
+		if record.Tx == "" || record.Entry == "" {
+			// Single sends keep no signed transaction to resume from
+			log.Printf("⏭️ RESUME SKIPPED | ID: %d | Tx Hash: %s | No stored transaction", record.ID, record.TxHash)
+			continue
+		}
+		tx, entry, _, err := decodeTransactionRecord(record)
+		if err != nil {
+			return fmt.Errorf("failed to decode pending transaction %d: %w", record.ID, err)
+		}
+		resumed[tx.Hash()] = &PendingTx{Tx: tx, Entry: entry}
+	}
+	if len(resumed) == 0 {
+		log.Printf("No pending transactions to resume for %s", w.address.Hex())
+		return nil
+	}
+
+	w.nonceMutex.Lock()
+	for _, pendingTx := range resumed {
+		nonce := pendingTx.Tx.Nonce()
+		w.pendingNonces[nonce] = struct{}{}
+		w.maxLocalNonce = max(w.maxLocalNonce, nonce)
+	}
+	w.nonceMutex.Unlock()
+
+	w.pendingTxMutex.Lock()
+	for hash, pendingTx := range resumed {
+		w.pendingTxs[hash] = pendingTx
+	}
+	w.pendingTxMutex.Unlock()
+
+	for _, pendingTx := range resumed {
+		tx := pendingTx.Tx
+		err := w.BroadcastTransaction(ctx, tx)
+		switch class := ClassifyRPCError(err); class {
+		case RPCErrorNone, RPCErrorKnown:
+			w.markBroadcast(tx)
+			w.recordBroadcastHeight(ctx, tx)
+		case RPCErrorNonceUsed:
+			// Most likely mined already, the receipt tells
+		default:
+			log.Printf("⚠️ RESUME BROADCAST FAILED | ID: %d | Tx Hash: %s | Nonce: %d | %s error: %v",
+				pendingTx.Entry.ID, tx.Hash().Hex(), tx.Nonce(), class, err)
+		}
+		log.Printf("♻️ TRANSFER RESUMED | ID: %d | Tx Hash: %s | Nonce: %d", pendingTx.Entry.ID, tx.Hash().Hex(), tx.Nonce())
+	}
+
+	monitorCtx, cancel := context.WithTimeout(ctx, MonitorTimeout)
+	defer cancel()
+	unprocessed, err := w.MonitorAllTransactions(monitorCtx)
+	if err != nil {
+		log.Printf("⚠️ RESUME INCOMPLETE | %d of %d resumed transactions still unconfirmed, they stay pending: %v",
+			unprocessed, len(resumed), err)
+		return nil
+	}
+	log.Printf("♻️ RESUME COMPLETE | %d pending transactions confirmed", len(resumed))
+	return nil
+}
@@ -0,0 +1,121 @@
+package wallet
+
+import (
+	"strings"
+)
+
+// RPCErrorClass tells how a node error on broadcast should be handled
+type RPCErrorClass int
+
+const (
+	// RPCErrorNone means there was no error
+	RPCErrorNone RPCErrorClass = iota
+	// RPCErrorKnown means the node already has the transaction in its mempool, so the
+	// submission succeeded
+	RPCErrorKnown
+	// RPCErrorNonceUsed means the nonce was already used, by this transaction if it was mined
+	RPCErrorNonceUsed
+	// RPCErrorTransient covers network and node hiccups that are worth retrying
+	RPCErrorTransient
+	// RPCErrorPermanent means the node rejected the transaction itself; resending it won't help
+	RPCErrorPermanent
+)
+
+func (c RPCErrorClass) String() string {
+	switch c {
+	case RPCErrorNone:
+		return "none"
+	case RPCErrorKnown:
+		return "known"
+	case RPCErrorNonceUsed:
+		return "nonce used"
+	case RPCErrorTransient:
+		return "transient"
+	case RPCErrorPermanent:
+		return "permanent"
+	default:
+		return "unknown"
+	}
+}
+
+var knownTxErrors = []string{
+	"already known",
+}
+
+var nonceUsedTxErrors = []string{
+	"nonce too low",
+}
+
+// Failure codes stored with a dead-lettered entry, so failures can be counted by cause
+const (
+	FailureInsufficientFunds   = "insufficient_funds"
+	FailureInvalidSender       = "invalid_sender"
+	FailureInvalidChainID      = "invalid_chain_id"
+	FailureGasLimit            = "gas_limit"
+	FailureFeeCap              = "fee_cap"
+	FailureOversizedData       = "oversized_data"
+	FailureNegativeValue       = "negative_value"
+	FailureRetriesExhausted    = "retries_exhausted"    // transient errors until max_retries
+	FailureConfirmationTimeout = "confirmation_timeout" // abandoned unmined after confirmation_timeout_blocks
+	FailureCancelled           = "cancelled"            // evicted by CancelTransaction
+)
+
+// SpentNonceFailureCodes are the failure codes of dead letters whose nonce another transaction
+// took. Their signed transaction can never be mined, so requeuing one signs its entry anew.
+var SpentNonceFailureCodes = []string{FailureCancelled}
+
+// permanentTxErrors maps the errors of a node rejecting a transaction to their failure code
+var permanentTxErrors = []struct {
+	match string
+	code  string
+}{
+	{"insufficient funds", FailureInsufficientFunds},
+	{"invalid sender", FailureInvalidSender},
+	{"invalid signature", FailureInvalidSender},
+	{"invalid chain id", FailureInvalidChainID},
+	{"intrinsic gas too low", FailureGasLimit},
+	{"exceeds block gas limit", FailureGasLimit},
+	{"gas limit reached", FailureGasLimit},
+	{"tx fee", FailureFeeCap},
+	{"oversized data", FailureOversizedData},
+	{"negative value", FailureNegativeValue},
+}
+
+// ClassifyRPCError classifies an error returned by the node when broadcasting.
+// Errors that aren't recognized are treated as transient: a signed transaction can
+// always be re-sent safely, so retrying is the cautious choice.
+func ClassifyRPCError(err error) RPCErrorClass {
+	if err == nil {
+		return RPCErrorNone
+	}
+	msg := strings.ToLower(err.Error())
+	for _, s := range knownTxErrors {
+		if strings.Contains(msg, s) {
+			return RPCErrorKnown
+		}
+	}
+	for _, s := range nonceUsedTxErrors {
+		if strings.Contains(msg, s) {
+			return RPCErrorNonceUsed
+		}
+	}
+	for _, e := range permanentTxErrors {
+		if strings.Contains(msg, e.match) {
+			return RPCErrorPermanent
+		}
+	}
+	return RPCErrorTransient
+}
+
+// FailureCode returns the failure code of a broadcast error that dead-letters an entry: the
+// cause of a permanent rejection, or FailureRetriesExhausted for errors retried until
+// max_retries
+func FailureCode(err error) string {
+	msg := strings.ToLower(err.Error())
+	for _, e := range permanentTxErrors {
+		if strings.Contains(msg, e.match) {
+			return e.code
+		}
+	}
+	return FailureRetriesExhausted
+}
@@ -0,0 +1,40 @@
+package wallet
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/dominant-strategies/go-quai/quaiclient/ethclient"
+)
+
+// rpcRetryBackoff is the wait before the first retry of an RPC call, doubled after each one
+const rpcRetryBackoff = 500 * time.Millisecond
+
+// withRetry runs an RPC call until it succeeds or fails with anything but a connection
+// error, up to rpc_max_attempts times with exponential backoff, and returns the last result.
+// Errors the node answers with, such as insufficient funds or nonce too low, are returned at
+// once. A failed attempt switches to the next endpoint that answers, so the retry goes there.
+func withRetry[T any](ctx context.Context, w *Wallet, what string, call func(client *ethclient.Client) (T, error)) (T, error) {
+	attempts := max(w.config.RPCMaxAttempts, 1)
+	backoff := rpcRetryBackoff
+	for attempt := 1; ; attempt++ {
+		client := w.rpc()
+		result, err := call(client)
+		if err == nil || !isConnectionError(err) {
+			return result, err
+		}
+		w.reconnect(client)
+		if attempt >= attempts || ctx.Err() != nil {
+			return result, err
+		}
+
+		log.Printf("🔁 RPC RETRY | %s | Attempt: %d/%d | Backoff: %s | %v", what, attempt, attempts, backoff, err)
+		select {
+		case <-ctx.Done():
+			return result, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
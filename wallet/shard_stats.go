@@ -0,0 +1,75 @@
+package wallet
+
+import (
+	"sort"
+
+	"quai-transfer/config"
+	wtypes "quai-transfer/types"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/shopspring/decimal"
+)
+
+// InvalidShard buckets the entries whose address a run would reject as invalid
+const InvalidShard = "invalid"
+
+// ShardStat is the number and total value of the entries of a payout file paying one shard
+type ShardStat struct {
+	Location   string          `json:"location"` // region-zone, or InvalidShard
+	Count      int             `json:"count"`
+	Value      decimal.Decimal `json:"value"`                 // wei
+	HasRPC     bool            `json:"has_rpc"`               // the network configures an RPC URL for the location
+	InvalidIDs []int32         `json:"invalid_ids,omitempty"` // entries of the InvalidShard bucket
+}
+
+// ShardStats groups the entries of a payout file by the location of their destination, the
+// shard whose wallet pays them in a multi-location run. Each address is validated as that
+// wallet would validate it; those it would reject go to the InvalidShard bucket. Nothing is
+// read from the chain. The shards are sorted by location with the invalid bucket last.
+func ShardStats(cfg *config.Config, entries []*wtypes.TransferEntry) []*ShardStat {
+	byLocation := make(map[string]*ShardStat)
+	probes := make(map[string]*Wallet)
+	rpcURLs := cfg.Networks[cfg.Network].RPCURLs
+
+	for _, entry := range entries {
+		location := destinationLocation(entry.ToAddress)
+		var err error
+		if location == "" {
+			err = wtypes.ErrInvalidAddress
+		} else {
+			probe, ok := probes[location]
+			if !ok {
+				// A wallet of the destination's location without a client, only to validate
+				probe = &Wallet{config: cfg, network: cfg.Network, location: common.LocationFromAddressBytes(common.FromHex(entry.ToAddress))}
+				probes[location] = probe
+			}
+			err = probe.ValidateDestination(entry.ToAddress)
+		}
+		if err != nil {
+			location = InvalidShard
+		}
+
+		stat, ok := byLocation[location]
+		if !ok {
+			stat = &ShardStat{Location: location, Value: decimal.Zero, HasRPC: len(rpcURLs[location]) > 0}
+			byLocation[location] = stat
+		}
+		stat.Count++
+		stat.Value = stat.Value.Add(entry.Value)
+		if err != nil {
+			stat.InvalidIDs = append(stat.InvalidIDs, entry.ID)
+		}
+	}
+
+	stats := make([]*ShardStat, 0, len(byLocation))
+	for _, stat := range byLocation {
+		stats = append(stats, stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if (stats[i].Location == InvalidShard) != (stats[j].Location == InvalidShard) {
+			return stats[j].Location == InvalidShard
+		}
+		return stats[i].Location < stats[j].Location
+	})
+	return stats
+}
@@ -0,0 +1,59 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/types"
+)
+
+// CheckSigning signs a throwaway transaction with the wallet's key, the way a batch signs an
+// entry, and checks that the sender recovers to the wallet's address in the chain scope of its
+// location and that the signature doesn't verify for another chain ID. Nothing is sent.
+func (w *Wallet) CheckSigning(ctx context.Context) error {
+	chainID, err := w.GetChainID(ctx)
+	if err != nil {
+		return err
+	}
+	to := w.address
+	tx := buildTx(TxParams{
+		Type:     QuaiTxType,
+		ChainID:  chainID,
+		GasPrice: big.NewInt(1),
+		MinerTip: big.NewInt(1),
+		Gas:      21000,
+		To:       &to,
+		Value:    new(big.Int),
+	})
+	signed, err := types.SignTx(tx, types.NewSigner(chainID, w.location), w.privateKey)
+	if err != nil {
+		return fmt.Errorf("failed to sign: %w", err)
+	}
+	return checkSigned(signed, chainID, w.location, w.address)
+}
+
+// checkSigned checks that a transaction signed for chainID recovers to address, in the chain
+// scope of location, and only for that chain ID
+func checkSigned(signed *types.Transaction, chainID *big.Int, location common.Location, address common.Address) error {
+	sender, err := types.Sender(types.NewSigner(chainID, location), signed)
+	if err != nil {
+		return fmt.Errorf("failed to recover sender: %w", err)
+	}
+	if !sender.Equal(address) {
+		return fmt.Errorf("recovered sender %s, want %s", sender.Hex(), address.Hex())
+	}
+
+	// The signature is bound to its chain ID, which prevents replays on other networks. The
+	// location isn't part of the signature, the node instead only accepts senders in its scope.
+	otherChainID := new(big.Int).Add(chainID, big.NewInt(1))
+	if other, err := types.Sender(types.NewSigner(otherChainID, location), signed); err == nil && other.Equal(address) {
+		return errors.New("signature also verifies for another chain ID")
+	}
+	if !common.IsInChainScope(sender.Bytes(), location) {
+		return fmt.Errorf("recovered sender %s is not in location %s", sender.Hex(), locationToString(location))
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package wallet
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/crypto"
+)
+
+// TestSigning signs fixed transactions the way a batch signs an entry and pins their hashes.
+// Signing is deterministic (RFC 6979), so the same key and fields always give the same hash; a
+// different hash means the signing scheme changed, for instance after a go-quai upgrade. The
+// keys are the Keccak hashes of "quai-transfer signing vector <n>"; never use them for funds.
+func TestSigning(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      string // hex private key, in the chain scope of location
+		location common.Location
+		chainID  int64
+		nonce    uint64
+		to       string
+		value    int64
+		hash     string
+	}{
+		{
+			name:     "zone 0-0, first nonce",
+			key:      "13221fe46bde6a5de07d45248101760b6e32ccd6d36e97ae6950ba95298e4da6",
+			location: common.Location{0, 0},
+			chainID:  9000,
+			nonce:    0,
+			to:       "0x0063983e573E5AB68efF7057C7ccE0902256e029",
+			value:    1_000_000_000_000_000_000,
+			hash:     "0x004b001b5ee1c01fa86826ec83745274e65d10cde49e6fccd293a1928e106f1e",
+		},
+		{
+			name:     "zone 0-0, high nonce on a local chain",
+			key:      "13221fe46bde6a5de07d45248101760b6e32ccd6d36e97ae6950ba95298e4da6",
+			location: common.Location{0, 0},
+			chainID:  1337,
+			nonce:    1 << 32,
+			to:       "0x0063983e573E5AB68efF7057C7ccE0902256e029",
+			value:    1,
+			hash:     "0x0051000611cbb874f8f07fa71cee59ed7678d3935c8d3eac6fe5cc70b0e4e911",
+		},
+		{
+			name:     "zone 1-0",
+			key:      "516346c6d4fe331bc637621c85ada1789aa187498961f5c39b6f27f86265c4dd",
+			location: common.Location{1, 0},
+			chainID:  15000,
+			nonce:    7,
+			to:       "0x1076b67a0cfc64cfe3798670427215d759494167",
+			value:    42,
+			hash:     "0x105f1063d1593ce7db1387c1b6222e0b2924a56bc808550b850c715e20d42e52",
+		},
+		{
+			name:     "cross-location, zone 0-0 to zone 2-2",
+			key:      "13221fe46bde6a5de07d45248101760b6e32ccd6d36e97ae6950ba95298e4da6",
+			location: common.Location{0, 0},
+			chainID:  9000,
+			nonce:    3,
+			to:       "0x226f97bdceaf512e8ea06a3a27b4d23be1352c0b",
+			value:    5_000_000_000,
+			hash:     "0x0038007e38ef399739cdecef46dbbea3fd954c8ec1d277c6290f4eb1080582b5",
+		},
+		{
+			name:     "cross-location, zone 2-2 to zone 0-0",
+			key:      "1a2ca9b6992ea3bebf5e06cc0bb5101117d203edb0bb7e8f5c778bbb4bcd0956",
+			location: common.Location{2, 2},
+			chainID:  9000,
+			nonce:    0,
+			to:       "0x001c985ac09f71218bf38b60d52cedf2dfbb72dd",
+			value:    5_000_000_000,
+			hash:     "0x227a227ac5740e7a68730df1596222d2f950589f6e299849a862de7686356940",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := crypto.HexToECDSA(tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			address := crypto.PubkeyToAddress(key.PublicKey, tt.location)
+			if !common.IsInChainScope(address.Bytes(), tt.location) {
+				t.Fatalf("key address %s is not in location %s", address.Hex(), locationToString(tt.location))
+			}
+
+			chainID := big.NewInt(tt.chainID)
+			to := common.HexToAddress(tt.to, tt.location)
+			tx := buildTx(TxParams{
+				Type:     QuaiTxType,
+				ChainID:  chainID,
+				Nonce:    tt.nonce,
+				GasPrice: big.NewInt(1_000_000_000),
+				MinerTip: big.NewInt(1),
+				Gas:      21000,
+				To:       &to,
+				Value:    big.NewInt(tt.value),
+			})
+			signed, err := types.SignTx(tx, types.NewSigner(chainID, tt.location), key)
+			if err != nil {
+				t.Fatalf("failed to sign: %v", err)
+			}
+			if err := checkSigned(signed, chainID, tt.location, address); err != nil {
+				t.Fatal(err)
+			}
+			if hash := signed.Hash().Hex(); hash != tt.hash {
+				t.Errorf("signed hash %s, want %s", hash, tt.hash)
+			}
+		})
+	}
+}
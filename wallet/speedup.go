@@ -0,0 +1,133 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+
+	"quai-transfer/dal/models"
+	wtypes "quai-transfer/types"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/shopspring/decimal"
+)
+
+// SpeedUpTransaction replaces a pending transaction of the wallet with the same transfer at a
+// higher gas price, for one stuck while gas prices are up. The replacement reuses the nonce,
+// so only one of the two can ever be mined. Its record is pointed at the replacement before
+// it's broadcast, and back at the original if the node rejects it.
+func (w *Wallet) SpeedUpTransaction(ctx context.Context, txHash common.Hash, newGasPrice *big.Int) (*types.Transaction, error) {
+	if w.config.DryRun {
+		return nil, fmt.Errorf("%w: a replacement must be broadcast", wtypes.ErrDryRun)
+	}
+	original, entry, err := w.pendingTransaction(ctx, txHash)
+	if err != nil {
+		return nil, err
+	}
+	if newGasPrice == nil || newGasPrice.Cmp(original.GasPrice()) <= 0 {
+		return nil, fmt.Errorf("new gas price %v must be higher than the original %s wei", newGasPrice, original.GasPrice())
+	}
+
+	minerTip := original.MinerTip()
+	if minerTip.Cmp(newGasPrice) > 0 {
+		minerTip = new(big.Int).Set(newGasPrice)
+	}
+	replacement, err := types.SignTx(buildTx(TxParams{
+		Type:     QuaiTxType,
+		ChainID:  w.chainID.Actual,
+		Nonce:    original.Nonce(),
+		GasPrice: newGasPrice,
+		MinerTip: minerTip,
+		Gas:      original.Gas(),
+		To:       original.To(),
+		Value:    original.Value(),
+		Data:     original.Data(),
+	}), types.NewSigner(w.chainID.Actual, w.location), w.privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign replacement transaction: %v", err)
+	}
+
+	if err := w.replaceRecord(ctx, original, replacement); err != nil {
+		return nil, err
+	}
+	if err := w.BroadcastTransaction(ctx, replacement); err != nil && ClassifyRPCError(err) != RPCErrorKnown {
+		if dbErr := w.replaceRecord(ctx, replacement, original); dbErr != nil {
+			log.Printf("🚨 RECORD OUT OF SYNC | Tx Hash: %s | The replacement was not broadcast but the record still points at it, reconcile it manually | Error: %v",
+				replacement.Hash().Hex(), dbErr)
+		}
+		return nil, fmt.Errorf("failed to broadcast replacement transaction: %w", err)
+	}
+
+	log.Printf("⏩ SPED UP | Nonce: %d | Old Hash: %s | New Hash: %s | Gas Price: %s -> %s wei",
+		original.Nonce(), txHash.Hex(), replacement.Hash().Hex(), original.GasPrice(), newGasPrice)
+
+	w.pendingTxMutex.Lock()
+	if pendingTx, ok := w.pendingTxs[txHash]; ok {
+		delete(w.pendingTxs, txHash)
+		w.pendingTxs[replacement.Hash()] = &PendingTx{Tx: replacement, Entry: pendingTx.Entry}
+	} else if entry != nil {
+		w.pendingTxs[replacement.Hash()] = &PendingTx{Tx: replacement, Entry: entry}
+	}
+	w.pendingTxMutex.Unlock()
+	w.markBroadcast(replacement)
+	w.recordBroadcastHeight(ctx, replacement)
+	return replacement, nil
+}
+
+// pendingTransaction returns a pending transaction of the wallet, from those being monitored
+// or else from its record, with its entry when it has one
+func (w *Wallet) pendingTransaction(ctx context.Context, txHash common.Hash) (*types.Transaction, *wtypes.TransferEntry, error) {
+	w.pendingTxMutex.RLock()
+	pendingTx, ok := w.pendingTxs[txHash]
+	w.pendingTxMutex.RUnlock()
+	if ok {
+		return pendingTx.Tx, pendingTx.Entry, nil
+	}
+
+	txRecord, err := w.txDAL.GetTransactionByHash(ctx, txHash.Hex())
+	if err != nil {
+		return nil, nil, err
+	}
+	if txRecord == nil {
+		return nil, nil, fmt.Errorf("transaction %s not found", txHash.Hex())
+	}
+	if txRecord.Status != models.Generated {
+		return nil, nil, fmt.Errorf("transaction %s is %s, only pending transactions can be sped up", txHash.Hex(), txRecord.Status)
+	}
+	if txRecord.Tx == "" {
+		return nil, nil, fmt.Errorf("transaction %s has no stored signed transaction to replace", txHash.Hex())
+	}
+	tx, err := DecodeStoredTransaction(txRecord)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	from, err := types.Sender(types.NewSigner(w.chainID.Actual, w.location), tx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to recover transaction sender: %v", err)
+	}
+	if !from.Equal(w.address) {
+		return nil, nil, fmt.Errorf("transaction %s is signed by %s, not by the wallet %s", txHash.Hex(), from.Hex(), w.address.Hex())
+	}
+
+	var entry *wtypes.TransferEntry
+	if txRecord.Entry != "" {
+		entry = &wtypes.TransferEntry{}
+		if err := json.Unmarshal([]byte(txRecord.Entry), entry); err != nil {
+			return nil, nil, fmt.Errorf("failed to deserialize entry: %v", err)
+		}
+	}
+	return tx, entry, nil
+}
+
+// replaceRecord points the record of a pending transaction at another of the same nonce
+func (w *Wallet) replaceRecord(ctx context.Context, from, to *types.Transaction) error {
+	txJSON, err := json.Marshal(to)
+	if err != nil {
+		return fmt.Errorf("failed to serialize transaction: %v", err)
+	}
+	return w.txDAL.ReplaceTransaction(ctx, from.Hash().Hex(), to.Hash().Hex(), decimal.NewFromBigInt(to.GasPrice(), 0), string(txJSON))
+}
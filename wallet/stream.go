@@ -0,0 +1,123 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"quai-transfer/report"
+	wtypes "quai-transfer/types"
+	"quai-transfer/utils"
+)
+
+// ProcessBatchStream runs the batch of a transfer CSV like ProcessBatchEntry, without holding
+// its entries in memory, for files too large to load. The file is read twice: the first pass
+// validates the entries, applies strict validation and checks the balance against their
+// total, and the second broadcasts them. Confirmations are also checked every ReceiptWaitTime
+// while broadcasting, so only the unconfirmed transactions are kept.
+func (w *Wallet) ProcessBatchStream(ctx context.Context, path string) (*BatchResult, error) {
+	totals := entryTotals{}
+	invalid := 0
+	err := streamTransferFile(ctx, path, w.csvOptions(), func(entry *wtypes.TransferEntry) {
+		if w.validateEntry(entry) != nil {
+			invalid++
+			return
+		}
+		totals.add(entry)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV file: %w", err)
+	}
+
+	result := &BatchResult{Total: totals.count + invalid}
+	defer w.finishBatch(result, time.Now())
+	w.startProgress(result.Total)
+
+	if invalid > 0 && w.config.StrictValidation {
+		result.Invalid = invalid
+		return result, fmt.Errorf("%w: %d of %d entries are invalid, strict validation aborted the batch before broadcasting",
+			wtypes.ErrInvalidEntries, invalid, result.Total)
+	}
+	if err := w.checkBalance(ctx, totals); err != nil {
+		return result, err
+	}
+
+	if err := w.startGasPriceTracking(ctx); err != nil {
+		return result, err
+	}
+	defer w.stopGasPriceTracking()
+	w.resetBatchState()
+
+	// Broadcast phase: once stopped, the rest of the file is only read to report it as unsent
+	var (
+		stopped   error
+		unsentIDs []int32
+		sent      int
+		sweptAt   = time.Now()
+	)
+	pool := w.newBatchPool()
+	remaining := totals
+	err = streamTransferFile(ctx, path, w.csvOptions(), func(entry *wtypes.TransferEntry) {
+		if err := w.validateEntry(entry); err != nil {
+			result.Invalid++
+			logEntry(w.config, EntryInvalid, entry, "", err, "⚠️ TRANSFER INVALID | Miner: %s | ID: %d | %v", entry.MinerAccount, entry.ID, err)
+			w.recordFailure(entry, report.StatusInvalid, err)
+			return
+		}
+		if stopped == nil {
+			if err := pool.acquire(ctx); err != nil {
+				stopped = err
+			} else if err := w.awaitGasPrice(ctx); err != nil {
+				pool.release()
+				stopped = err
+			} else if err := w.awaitBalance(ctx, entry, remaining, sent); err != nil {
+				pool.release()
+				stopped = err
+			} else if err := pool.awaitInFlight(ctx); err != nil {
+				pool.release()
+				stopped = err
+			}
+		}
+		if stopped != nil {
+			unsentIDs = append(unsentIDs, entry.ID)
+			return
+		}
+		remaining.remove(entry)
+		sent++
+
+		pool.send(ctx, entry)
+		if time.Since(sweptAt) >= ReceiptWaitTime {
+			w.checkPendingTransactions()
+			w.saveProgress(true)
+			sweptAt = time.Now()
+		}
+	})
+	pool.wait(result)
+	if len(unsentIDs) > 0 {
+		result.markUnsentIDs(unsentIDs, stopped)
+	}
+
+	// Whatever was broadcast before a read error is still monitored
+	w.monitorBatch(ctx, result)
+	if err != nil {
+		return result, fmt.Errorf("failed to read CSV file after %d entries: %w", sent, err)
+	}
+	return result, nil
+}
+
+// streamTransferFile calls fn with each entry of a transfer CSV as it is parsed, until ctx is done
+func streamTransferFile(ctx context.Context, path string, opts utils.CSVOptions, fn func(entry *wtypes.TransferEntry)) error {
+	return utils.StreamTransferCSV(ctx, path, opts, func(entry *wtypes.TransferEntry) error {
+		// Entries parsed ahead are dropped once ctx is done
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		fn(entry)
+		return nil
+	})
+}
+
+// csvOptions returns how the wallet's configuration reads transfer CSVs
+func (w *Wallet) csvOptions() utils.CSVOptions {
+	return utils.CSVOptions{Unit: w.config.InputUnit, Strict: w.config.StrictValidation, AddressBook: w.config.AddressBook}
+}
@@ -0,0 +1,40 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	wtypes "quai-transfer/types"
+)
+
+// CheckNodeSync makes sure the node has caught up with the chain, as a lagging node hands out
+// stale nonces and balances. It fails with ErrNodeNotSynced when the node reports a sync in
+// progress, or when its latest block is older than max_head_age, which also catches a node
+// that stopped receiving blocks without noticing. A node that can't report its sync status is
+// judged on its latest block alone. It returns the age of the latest block.
+func (w *Wallet) CheckNodeSync(ctx context.Context) (time.Duration, error) {
+	progress, err := w.rpc().SyncProgress(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		if w.config.Debug {
+			log.Printf("Node sync status unavailable, checking the latest block only: %v", err)
+		}
+	} else if progress != nil {
+		return 0, fmt.Errorf("%w: syncing, at block %d of %d", wtypes.ErrNodeNotSynced, progress.CurrentBlock, progress.HighestBlock)
+	}
+
+	// The age of the latest block is what the clock skew check measures, seen from the node
+	age, err := w.ClockSkew(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if w.config.MaxHeadAge > 0 && age > w.config.MaxHeadAge {
+		return age, fmt.Errorf("%w: latest block is %s old, over max_head_age %s (or the local clock is ahead)",
+			wtypes.ErrNodeNotSynced, age.Round(time.Second), w.config.MaxHeadAge)
+	}
+	return age, nil
+}
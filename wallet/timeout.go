@@ -0,0 +1,114 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"quai-transfer/config"
+	"quai-transfer/eventlog"
+	"quai-transfer/report"
+
+	"github.com/dominant-strategies/go-quai/core/types"
+)
+
+// recordBroadcastHeight stores the current head as the broadcast height of a transaction,
+// both on its pending entry and in its record. Failures only disable the block-count
+// timeout for this transaction, so they are logged rather than returned.
+func (w *Wallet) recordBroadcastHeight(ctx context.Context, tx *types.Transaction) {
+	height, err := w.rpc().BlockNumber(ctx)
+	if err != nil {
+		log.Printf("failed to get broadcast height of tx %s: %v", tx.Hash().Hex(), err)
+		return
+	}
+
+	w.pendingTxMutex.Lock()
+	if pendingTx, ok := w.pendingTxs[tx.Hash()]; ok {
+		pendingTx.BroadcastHeight = height
+	}
+	w.pendingTxMutex.Unlock()
+
+	err = w.retryDBWrite(ctx, "record broadcast height of tx "+tx.Hash().Hex(), func() error {
+		return w.txDAL.RecordBroadcastHeight(ctx, tx.Hash().Hex(), height)
+	})
+	if err != nil {
+		log.Printf("failed to record broadcast height of tx %s: %v", tx.Hash().Hex(), err)
+	}
+}
+
+// checkBlockTimeouts handles the unconfirmed transactions that have stayed unmined for
+// confirmation_timeout_blocks past their broadcast height. Counting blocks rather than wall
+// time keeps the timeout meaningful however the block time varies.
+func (w *Wallet) checkBlockTimeouts(ctx context.Context, unconfirmed []*PendingTx) {
+	limit := w.config.ConfirmationTimeoutBlocks
+	if limit == 0 || len(unconfirmed) == 0 {
+		return
+	}
+
+	head, err := w.rpc().BlockNumber(ctx)
+	if err != nil {
+		log.Printf("failed to get head block for the confirmation timeout: %v", err)
+		return
+	}
+
+	for _, pendingTx := range unconfirmed {
+		w.pendingTxMutex.RLock()
+		broadcastHeight := pendingTx.BroadcastHeight
+		w.pendingTxMutex.RUnlock()
+		if broadcastHeight == 0 || head < broadcastHeight+limit {
+			continue
+		}
+
+		if w.config.ConfirmationTimeoutAction == config.ConfirmationTimeoutAbandon {
+			w.abandonTransaction(ctx, pendingTx, head-broadcastHeight)
+		} else {
+			w.rebroadcastTransaction(ctx, pendingTx, head-broadcastHeight)
+		}
+	}
+}
+
+// rebroadcastTransaction re-sends the same signed transaction, which restarts its block count
+func (w *Wallet) rebroadcastTransaction(ctx context.Context, pendingTx *PendingTx, blocks uint64) {
+	log.Printf("🔁 REBROADCAST | ID: %d | Tx Hash: %s | Unmined for %d blocks", pendingTx.Entry.ID, pendingTx.Tx.Hash().Hex(), blocks)
+
+	err := w.BroadcastTransaction(ctx, pendingTx.Tx)
+	w.recordBroadcast(pendingTx.Entry, pendingTx.Tx, err)
+	if class := ClassifyRPCError(err); class != RPCErrorNone && class != RPCErrorKnown && class != RPCErrorNonceUsed {
+		// Keep the old height so the next check tries again
+		log.Printf("failed to rebroadcast tx %s of entry %d: %v", pendingTx.Tx.Hash().Hex(), pendingTx.Entry.ID, err)
+		return
+	}
+	w.recordBroadcastHeight(ctx, pendingTx.Tx)
+}
+
+// abandonTransaction stops waiting for a transaction and dead-letters its entry. The signed
+// transaction may still be mined later, and the later nonces of the wallet can't be mined
+// before it is, so requeueing the entry re-sends the same transaction.
+func (w *Wallet) abandonTransaction(ctx context.Context, pendingTx *PendingTx, blocks uint64) {
+	txHash := pendingTx.Tx.Hash()
+	err := fmt.Errorf("not mined within %d blocks of its broadcast", blocks)
+	logEntry(w.config, EntryDeadLettered, pendingTx.Entry, txHash.Hex(), err,
+		"🪦 TRANSFER ABANDONED | Miner: %s | ID: %d | Tx Hash: %s | %v", pendingTx.Entry.MinerAccount, pendingTx.Entry.ID, txHash.Hex(), err)
+
+	if dbErr := w.txDAL.RecordBroadcastFailure(ctx, txHash.Hex(), err.Error()); dbErr != nil {
+		log.Printf("failed to record the abandonment of entry %d: %v", pendingTx.Entry.ID, dbErr)
+	}
+	if dbErr := w.txDAL.MarkDeadLetter(ctx, txHash.Hex(), FailureConfirmationTimeout, err.Error()); dbErr != nil {
+		log.Printf("failed to dead-letter entry %d: %v", pendingTx.Entry.ID, dbErr)
+	}
+
+	nonce := pendingTx.Tx.Nonce()
+	w.events.Append(eventlog.Event{
+		Type:    eventlog.TxAbandoned,
+		EntryID: pendingTx.Entry.ID,
+		TxHash:  txHash.Hex(),
+		Nonce:   &nonce,
+		Error:   err.Error(),
+	})
+	w.recordFailure(pendingTx.Entry, report.StatusDeadLettered, err)
+
+	w.pendingTxMutex.Lock()
+	delete(w.pendingTxs, txHash)
+	w.abandoned++
+	w.pendingTxMutex.Unlock()
+}
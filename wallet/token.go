@@ -0,0 +1,119 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+
+	wtypes "quai-transfer/types"
+
+	quai "github.com/dominant-strategies/go-quai"
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/crypto"
+	"github.com/dominant-strategies/go-quai/quaiclient/ethclient"
+	"github.com/shopspring/decimal"
+)
+
+// Selectors of the ERC20 methods token payouts use
+var (
+	tokenTransferSelector  = crypto.Keccak256([]byte("transfer(address,uint256)"))[:4]
+	tokenBalanceOfSelector = crypto.Keccak256([]byte("balanceOf(address)"))[:4]
+)
+
+// maxTokenAmount is the largest amount a uint256 argument holds
+var maxTokenAmount = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+// encodeTokenTransfer ABI-encodes the call of transfer(to, amount)
+func encodeTokenTransfer(to common.Address, amount *big.Int) ([]byte, error) {
+	if amount.Sign() < 0 || amount.Cmp(maxTokenAmount) > 0 {
+		return nil, fmt.Errorf("token amount %s out of uint256 range", amount)
+	}
+	data := make([]byte, 0, 4+2*32)
+	data = append(data, tokenTransferSelector...)
+	data = append(data, common.LeftPadBytes(to.Bytes(), 32)...)
+	return append(data, common.LeftPadBytes(amount.Bytes(), 32)...), nil
+}
+
+// SendToken sends amount of the ERC20 token at token to the address to, as a transfer call
+// of the token contract carrying no value. Like SendQuai it's recorded and monitored; amount
+// is in the token's smallest unit.
+func (w *Wallet) SendToken(ctx context.Context, token, to common.Address, amount *big.Int) (*types.Transaction, error) {
+	data, err := encodeTokenTransfer(to, amount)
+	if err != nil {
+		return nil, err
+	}
+	return w.SendContractCall(ctx, token, new(big.Int), data)
+}
+
+// TokenBalanceOf returns the balance of owner in the ERC20 token at token, in its smallest unit
+func (w *Wallet) TokenBalanceOf(ctx context.Context, token, owner common.Address) (*big.Int, error) {
+	data := append(append([]byte{}, tokenBalanceOfSelector...), common.LeftPadBytes(owner.Bytes(), 32)...)
+	out, err := withRetry(ctx, w, "get token balance", func(client *ethclient.Client) ([]byte, error) {
+		return client.CallContract(ctx, quai.CallMsg{From: w.address, To: &token, Data: data}, nil)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to call balanceOf of token %s: %w", token.Hex(), err)
+	}
+	if len(out) != 32 {
+		return nil, fmt.Errorf("token %s returned %d bytes for balanceOf, is it an ERC20 contract?", token.Hex(), len(out))
+	}
+	return new(big.Int).SetBytes(out), nil
+}
+
+// entryCall returns what the transaction of an entry calls: the entry's destination with its
+// value and calldata, or for a token payout, the token contract transferring the entry's
+// value to its destination
+func (w *Wallet) entryCall(entry *wtypes.TransferEntry) (common.Address, *big.Int, []byte, error) {
+	if entry.TokenAddress == "" {
+		return w.entryDestination(entry), entry.Value.BigInt(), entry.Data, nil
+	}
+	data, err := encodeTokenTransfer(w.entryDestination(entry), entry.Value.BigInt())
+	if err != nil {
+		return common.Address{}, nil, nil, fmt.Errorf("entry %d: %w", entry.ID, err)
+	}
+	return common.HexToAddress(entry.TokenAddress, w.GetLocation()), new(big.Int), data, nil
+}
+
+// validateEntry checks the addresses of an entry: its destination and, for a token payout,
+// the token contract, which must be in the wallet's location as well
+func (w *Wallet) validateEntry(entry *wtypes.TransferEntry) error {
+	if err := w.ValidateDestination(entry.ToAddress); err != nil {
+		return err
+	}
+	if entry.TokenAddress != "" {
+		if err := w.ValidateDestination(entry.TokenAddress); err != nil {
+			return fmt.Errorf("token: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkTokenBalances fails when the wallet holds less of a token than the entries paying it
+// add up to
+func (w *Wallet) checkTokenBalances(ctx context.Context, entries []*wtypes.TransferEntry) error {
+	required := make(map[string]decimal.Decimal)
+	var tokens []string
+	for _, entry := range entries {
+		if entry.TokenAddress == "" {
+			continue
+		}
+		if _, ok := required[entry.TokenAddress]; !ok {
+			tokens = append(tokens, entry.TokenAddress)
+		}
+		required[entry.TokenAddress] = required[entry.TokenAddress].Add(entry.Value)
+	}
+	for _, token := range tokens {
+		balance, err := w.TokenBalanceOf(ctx, common.HexToAddress(token, w.GetLocation()), w.address)
+		if err != nil {
+			return err
+		}
+		have := decimal.NewFromBigInt(balance, 0)
+		if have.LessThan(required[token]) {
+			return fmt.Errorf("%w for token %s transfers: have %s, need %s", wtypes.ErrInsufficientBalance, token, have, required[token])
+		}
+		log.Printf("token balance check passed, %s: have %s, need at least %s", token, have, required[token])
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package wallet
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/types"
+)
+
+// TxType selects the concrete transaction type built by buildTx
+type TxType byte
+
+const (
+	QuaiTxType TxType = types.QuaiTxType
+	QiTxType   TxType = types.QiTxType
+)
+
+func (t TxType) String() string {
+	switch t {
+	case QuaiTxType:
+		return "quai"
+	case QiTxType:
+		return "qi"
+	default:
+		return fmt.Sprintf("unknown(%d)", byte(t))
+	}
+}
+
+// TxParams holds the fields of a transaction to build. Fields that don't apply to the
+// selected type are ignored: a Qi transaction only uses ChainID, TxIn, TxOut and Signature.
+type TxParams struct {
+	Type    TxType
+	ChainID *big.Int
+
+	// Quai transactions
+	Nonce    uint64
+	GasPrice *big.Int
+	MinerTip *big.Int
+	Gas      uint64
+	To       *common.Address
+	Value    *big.Int
+	Data     []byte
+
+	// Qi transactions
+	TxIn      types.TxIns
+	TxOut     types.TxOuts
+	Signature *schnorr.Signature
+}
+
+// buildTx builds an unsigned transaction of the type selected by params.Type. Every
+// transaction is constructed here, so supporting a new type only takes a new case.
+func buildTx(params TxParams) *types.Transaction {
+	switch params.Type {
+	case QuaiTxType:
+		return types.NewTx(&types.QuaiTx{
+			ChainID:    params.ChainID,
+			Nonce:      params.Nonce,
+			GasPrice:   params.GasPrice,
+			MinerTip:   params.MinerTip,
+			Gas:        params.Gas,
+			To:         params.To,
+			Value:      params.Value,
+			Data:       params.Data,
+			AccessList: types.AccessList{},
+		})
+	case QiTxType:
+		return types.NewTx(&types.QiTx{
+			ChainID:   params.ChainID,
+			TxIn:      params.TxIn,
+			TxOut:     params.TxOut,
+			Signature: params.Signature,
+		})
+	default:
+		panic(fmt.Sprintf("unsupported transaction type %s", params.Type))
+	}
+}
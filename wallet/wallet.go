@@ -1,6 +1,7 @@
 package wallet
 
 import (
+	"bytes"
 	"context"
 	"crypto/ecdsa"
 	"encoding/json"
@@ -12,13 +13,17 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/btcsuite/btcd/btcec/v2"
 	"quai-transfer/config"
 	"quai-transfer/dal"
 	"quai-transfer/dal/models"
+	"quai-transfer/eventlog"
 	"quai-transfer/keystore"
+	"quai-transfer/metrics"
+	"quai-transfer/report"
 	wtypes "quai-transfer/types"
 	"quai-transfer/utils"
 
@@ -30,6 +35,7 @@ import (
 	"github.com/dominant-strategies/go-quai/core/types"
 	"github.com/dominant-strategies/go-quai/crypto"
 	"github.com/dominant-strategies/go-quai/quaiclient/ethclient"
+	"github.com/dominant-strategies/go-quai/rpc"
 	"github.com/shopspring/decimal"
 )
 
@@ -37,11 +43,9 @@ import (
 var _ WalletFunc = (*Wallet)(nil)
 
 const (
-	GasLimit          = 420000
-	MinerTip          = 1000
 	ReceiptMaxRetries = 30 // Wait for about 5 minutes (30 * 10 seconds)
-	NonceWaitTime     = 2 * time.Second
 	ReceiptWaitTime   = 15 * time.Second
+	MonitorTimeout    = 10 * time.Minute // how long broadcast transactions are monitored for
 )
 
 // ChainIDMapping holds the expected and actual chain IDs
@@ -53,12 +57,20 @@ type ChainIDMapping struct {
 type PendingTx struct {
 	Tx    *types.Transaction
 	Entry *wtypes.TransferEntry
+	// BroadcastHeight is the head block number at the last broadcast, zero until known
+	BroadcastHeight uint64
+	// BroadcastAt is when the node accepted the transaction, zero until then
+	BroadcastAt time.Time
 }
 
 // Wallet represents a wallet that can send both Quai and Qi transactions
 type Wallet struct {
 	privateKey     *ecdsa.PrivateKey
-	client         *ethclient.Client
+	client         *ethclient.Client // use rpc(), swapped by reconnect
+	rawClient      *rpc.Client       // connection of client, use rawRPC()
+	clientMutex    sync.RWMutex
+	rpcURLs        []string // endpoints of the location, in order of preference
+	rpcIndex       int      // endpoint of client, guarded by clientMutex
 	chainID        *ChainIDMapping
 	location       common.Location
 	network        wtypes.Network
@@ -67,8 +79,62 @@ type Wallet struct {
 	config         *config.Config
 	nonceMutex     sync.Mutex
 	maxLocalNonce  uint64
+	pendingNonces  map[uint64]struct{}
+	minedTxs       map[uint64]*minedTx // by nonce, guarded by nonceMutex
 	pendingTxs     map[common.Hash]*PendingTx
 	pendingTxMutex sync.RWMutex
+
+	gasPriceMutex     sync.Mutex
+	startGasPrice     *big.Int
+	cachedGasPrice    *big.Int
+	gasPriceUpdatedAt time.Time
+
+	events  *eventlog.Log
+	results *report.Mux
+	metrics *metrics.Metrics
+
+	// balanceCheckedAt is when the batch last re-checked the balance
+	balanceCheckedAt time.Time
+
+	// abandoned counts the transactions of the batch abandoned by the block-count timeout,
+	// guarded by pendingTxMutex
+	abandoned int
+	// reverted counts the transactions of the batch mined but reverted, guarded by pendingTxMutex
+	reverted int
+	// batchFees sums the fees of the transactions mined during the batch, guarded by pendingTxMutex
+	batchFees decimal.Decimal
+	// batchValue sums the values of the transactions mined successfully during the batch,
+	// guarded by pendingTxMutex
+	batchValue decimal.Decimal
+	// batchGas compares the gas limits and usage of the transactions mined during the batch
+	// with gas_report, guarded by pendingTxMutex
+	batchGas GasStats
+
+	// progress records the progress of the batches in batch_runs, nil unless SetRunID was called
+	progress *batchProgress
+	// pause holds back new broadcasts while paused, nil unless SetPause was called
+	pause *Pause
+	// finalityUnavailable is set once the node turned out not to report finalized blocks, for
+	// confirmation_mode finalized to fall back to the confirmation depth
+	finalityUnavailable atomic.Bool
+	// broadcastFallback is set once the endpoint turned out not to serve broadcast_method eth,
+	// for sends to go through the client instead
+	broadcastFallback atomic.Bool
+}
+
+// SetEventLog sets the event log that records the wallet's transaction lifecycle
+func (w *Wallet) SetEventLog(events *eventlog.Log) {
+	w.events = events
+}
+
+// SetResults sets the outputs that receive a row as each batch entry confirms or fails
+func (w *Wallet) SetResults(results *report.Mux) {
+	w.results = results
+}
+
+// SetMetrics sets the metrics backend instrumenting the wallet's batches
+func (w *Wallet) SetMetrics(m *metrics.Metrics) {
+	w.metrics = m
 }
 
 func (w *Wallet) GetLocation() common.Location {
@@ -77,41 +143,152 @@ func (w *Wallet) GetLocation() common.Location {
 
 func (w *Wallet) GetBalance(ctx context.Context) (*big.Int, error) {
 	address := w.GetAddress()
-	return w.client.BalanceAt(ctx, address.MixedcaseAddress(), nil)
+	return withRetry(ctx, w, "get balance", func(client *ethclient.Client) (*big.Int, error) {
+		return client.BalanceAt(ctx, address.MixedcaseAddress(), nil)
+	})
 }
 
 func (w *Wallet) BroadcastTransaction(ctx context.Context, tx *types.Transaction) error {
-	if w.config.Debug {
-		protoTx, err := tx.ProtoEncode()
+	if w.config.DryRun {
+		raw, err := EncodeRawTransaction(tx)
 		if err != nil {
 			return err
 		}
-		data, err := proto.Marshal(protoTx)
+		log.Printf("🧪 DRY RUN | Tx Hash: %s | Not broadcast | Raw: %s", tx.Hash().Hex(), raw)
+		return w.txDAL.MarkDryRun(ctx, tx.Hash().Hex())
+	}
+	if w.config.Debug {
+		raw, err := EncodeRawTransaction(tx)
 		if err != nil {
 			return err
 		}
-		if w.config.Debug {
-			log.Printf("transaction hash: %s, transaction raw data: %s", tx.Hash().Hex(), hexutil.Encode(data))
-		}
+		log.Printf("transaction hash: %s, transaction raw data: %s", tx.Hash().Hex(), raw)
 	}
 
-	return w.client.SendTransaction(ctx, tx)
+	// Re-sending the same signed transaction is harmless, the node knows it at worst
+	_, err := withRetry(ctx, w, "send tx "+tx.Hash().Hex(), func(client *ethclient.Client) (struct{}, error) {
+		return struct{}{}, w.sendTransaction(ctx, client, tx)
+	})
+	return err
+}
+
+// rpcMethodNotFound is the JSON-RPC error code of a method the endpoint doesn't serve
+const rpcMethodNotFound = -32601
+
+// sendTransaction sends tx with broadcast_method. The client sends with quai_sendRawTransaction;
+// eth_sendRawTransaction takes the same proto encoding and is called on the client's connection.
+// An endpoint that doesn't serve it makes every later send fall back to the client.
+func (w *Wallet) sendTransaction(ctx context.Context, client *ethclient.Client, tx *types.Transaction) error {
+	if w.config.BroadcastMethod != config.BroadcastMethodEth || w.broadcastFallback.Load() {
+		return client.SendTransaction(ctx, tx)
+	}
+	raw, err := EncodeRawTransaction(tx)
+	if err != nil {
+		return err
+	}
+	err = w.rawRPC().CallContext(ctx, nil, "eth_sendRawTransaction", raw)
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == rpcMethodNotFound {
+		if w.broadcastFallback.CompareAndSwap(false, true) {
+			log.Printf("⚠️ BROADCAST METHOD UNAVAILABLE | Endpoint: %s | eth_sendRawTransaction is not served, sending with quai_sendRawTransaction | %v",
+				w.rpcURL(), err)
+		}
+		return client.SendTransaction(ctx, tx)
+	}
+	return err
 }
 
 func (w *Wallet) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
-	return w.client.SuggestGasPrice(ctx)
+	price, err := withRetry(ctx, w, "suggest gas price", func(client *ethclient.Client) (*big.Int, error) {
+		return client.SuggestGasPrice(ctx)
+	})
+	if err == nil {
+		w.metrics.GasPrice(price)
+	}
+	return price, err
 }
 
+// GetNonce reserves the next nonce, first waiting up to nonce_wait for a node that lags behind
+// our own broadcasts to catch up. Use it when sending transactions back to back, as in batch
+// runs. With a concurrency above one it doesn't wait: the nonces of the batch come from the
+// local counter. Callers must hold nonceMutex.
 func (w *Wallet) GetNonce(ctx context.Context) (uint64, error) {
-	return w.client.PendingNonceAt(ctx, w.GetAddress().MixedcaseAddress())
+	if w.config.Concurrency > 1 {
+		return w.reserveNonce(ctx, 0)
+	}
+	return w.reserveNonce(ctx, w.config.NonceWait)
+}
+
+// GetNonceNoWait reserves the next nonce without waiting. Use it for single interactive sends,
+// where no earlier transaction of this process can still be propagating. Callers must hold nonceMutex.
+func (w *Wallet) GetNonceNoWait(ctx context.Context) (uint64, error) {
+	return w.reserveNonce(ctx, 0)
+}
+
+// reserveNonce returns max(pending nonce, max local nonce + 1) and marks it as pending. When
+// the pending nonce lags behind the local one, the node hasn't seen all our broadcasts yet and
+// is given wait to catch up before it's asked again. Callers must hold nonceMutex.
+func (w *Wallet) reserveNonce(ctx context.Context, wait time.Duration) (uint64, error) {
+	nonce, err := w.pendingNonce(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if wait > 0 && len(w.pendingNonces) > 0 && w.maxLocalNonce >= nonce {
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(wait):
+		}
+		if nonce, err = w.pendingNonce(ctx); err != nil {
+			return 0, err
+		}
+	}
+
+	if w.config.Debug {
+		log.Printf("(pending: %d, max local: %d)\n", nonce, w.maxLocalNonce)
+	}
+
+	if len(w.pendingNonces) > 0 && w.maxLocalNonce >= nonce {
+		nonce = w.maxLocalNonce + 1
+	}
+
+	w.maxLocalNonce = nonce
+	w.pendingNonces[nonce] = struct{}{}
+	return nonce, nil
+}
+
+// pendingNonce returns the nonce the node expects next, counting its pending transactions
+func (w *Wallet) pendingNonce(ctx context.Context) (uint64, error) {
+	return withRetry(ctx, w, "get pending nonce", func(client *ethclient.Client) (uint64, error) {
+		return client.PendingNonceAt(ctx, w.GetAddress().MixedcaseAddress())
+	})
+}
+
+// releaseNonce gives back a reserved nonce that was never broadcast. Callers must hold nonceMutex.
+func (w *Wallet) releaseNonce(nonce uint64) {
+	delete(w.pendingNonces, nonce)
+	if nonce == w.maxLocalNonce && nonce > 0 {
+		w.maxLocalNonce--
+	}
+}
+
+// cleanupConfirmedNonces stops tracking the nonces of final transactions. Callers must hold nonceMutex.
+func (w *Wallet) cleanupConfirmedNonces(nonces ...uint64) {
+	for _, nonce := range nonces {
+		delete(w.pendingNonces, nonce)
+		delete(w.minedTxs, nonce)
+	}
 }
 
 func (w *Wallet) GetTransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
-	return w.client.TransactionReceipt(ctx, txHash)
+	return withRetry(ctx, w, "get receipt of "+txHash.Hex(), func(client *ethclient.Client) (*types.Receipt, error) {
+		return client.TransactionReceipt(ctx, txHash)
+	})
 }
 
 func (w *Wallet) Close() {
-	w.client.Close()
+	w.rpc().Close()
 }
 
 func (w *Wallet) GetAddress() common.Address {
@@ -138,19 +315,22 @@ func (w *Wallet) initClient() error {
 	// Get location from wallet's address
 	location := w.calculateLocation()
 
-	// Get RPC URL for the location
-	rpcURL, ok := netConfig.RPCURLs[locationToString(location)]
-	if !ok {
-		return fmt.Errorf("unsupported location %v for network %s", location, w.config.Network)
+	// Get the RPC URLs for the location, the first one that answers is used
+	rpcURLs := netConfig.RPCURLs[locationToString(location)]
+	if len(rpcURLs) == 0 {
+		return unsupportedLocationError(w.address, location, w.config.Network, netConfig.RPCURLs)
 	}
 
-	client, err := ethclient.Dial(rpcURL)
+	client, rawClient, rpcIndex, err := dialEndpoints(rpcURLs, 0)
 	if err != nil {
 		return fmt.Errorf("failed to connect to node: %v", err)
 	}
 
 	*w = Wallet{
 		client:        client,
+		rawClient:     rawClient,
+		rpcURLs:       rpcURLs,
+		rpcIndex:      rpcIndex,
 		chainID:       &ChainIDMapping{Expected: netConfig.ChainID},
 		location:      location,
 		network:       w.config.Network,
@@ -159,12 +339,33 @@ func (w *Wallet) initClient() error {
 		address:       w.address,
 		txDAL:         w.txDAL,
 		maxLocalNonce: 0,
+		pendingNonces: make(map[uint64]struct{}),
+		minedTxs:      make(map[uint64]*minedTx),
 		pendingTxs:    make(map[common.Hash]*PendingTx),
 	}
 
 	return nil
 }
 
+// unsupportedLocationError explains that no rpc_urls entry serves the location an address
+// resolved to, listing the locations configured for the network
+func unsupportedLocationError(address common.Address, location common.Location, network wtypes.Network, rpcURLs map[string][]string) error {
+	configured := make([]string, 0, len(rpcURLs))
+	for loc, urls := range rpcURLs {
+		if len(urls) > 0 {
+			configured = append(configured, loc)
+		}
+	}
+	sort.Strings(configured)
+	if len(configured) == 0 {
+		return fmt.Errorf("unsupported location %s for network %s: address %s resolved to it, but networks.%s.rpc_urls is empty",
+			locationToString(location), network, address.Hex(), network)
+	}
+	return fmt.Errorf("unsupported location %s for network %s: address %s resolved to it, but networks.%s.rpc_urls only has %s; "+
+		"add an rpc_urls entry for %s or use a key of a configured location",
+		locationToString(location), network, address.Hex(), network, strings.Join(configured, ", "), locationToString(location))
+}
+
 // calculateLocation calculates the location from the wallet's address
 func (w *Wallet) calculateLocation() common.Location {
 	return common.LocationFromAddressBytes(w.address.Bytes())
@@ -174,12 +375,19 @@ func (w *Wallet) calculateLocation() common.Location {
 func NewWalletFromKey(key *keystore.Key, cfg *config.Config) (*Wallet, error) {
 	dal.DBInit(cfg)
 
+	// Keys are decoded against the configured location; rebind the address to its own
+	// location so a key for another shard is internal to its wallet
+	addrBytes := key.Address.Bytes()
+	address := common.BytesToAddress(addrBytes, common.LocationFromAddressBytes(addrBytes))
+
 	wallet := &Wallet{
-		privateKey: key.PrivateKey,
-		txDAL:      dal.NewTransactionDAL(dal.InterDB),
-		address:    key.Address,
-		config:     cfg,
-		pendingTxs: make(map[common.Hash]*PendingTx),
+		privateKey:    key.PrivateKey,
+		txDAL:         dal.NewTransactionDAL(dal.InterDB),
+		address:       address,
+		config:        cfg,
+		pendingNonces: make(map[uint64]struct{}),
+		minedTxs:      make(map[uint64]*minedTx),
+		pendingTxs:    make(map[common.Hash]*PendingTx),
 	}
 
 	// Initialize client and other fields
@@ -199,7 +407,7 @@ func NewWalletFromKey(key *keystore.Key, cfg *config.Config) (*Wallet, error) {
 func NewWalletFromPrivateKeyString(privKeyHex string, cfg *config.Config) (*Wallet, error) {
 	dal.DBInit(cfg)
 
-	privateKey, err := crypto.HexToECDSA(privKeyHex)
+	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(privKeyHex, "0x"))
 	if err != nil {
 		return nil, fmt.Errorf("invalid private key: %v", err)
 	}
@@ -228,30 +436,63 @@ func NewWalletFromPrivateKeyString(privKeyHex string, cfg *config.Config) (*Wall
 
 // SendQuai sends a Quai transaction asynchronously
 func (w *Wallet) SendQuai(ctx context.Context, to common.Address, amount *big.Int) (*types.Transaction, error) {
+	return w.sendQuai(ctx, to, amount, nil)
+}
+
+// SendContractCall sends a Quai transaction calling the contract at to with data, such as a
+// token transfer, with value attached. Like SendQuai, it's recorded, its nonce managed, and its
+// receipt monitored; the gas limit is estimated for the call.
+func (w *Wallet) SendContractCall(ctx context.Context, to common.Address, value *big.Int, data []byte) (*types.Transaction, error) {
+	if len(data) == 0 {
+		return nil, errors.New("contract call without data")
+	}
+	return w.sendQuai(ctx, to, value, data)
+}
+
+func (w *Wallet) sendQuai(ctx context.Context, to common.Address, amount *big.Int, data []byte) (*types.Transaction, error) {
 	from := w.GetAddress()
 
-	nonce, err := w.GetNonce(ctx)
+	w.nonceMutex.Lock()
+	nonce, err := w.GetNonceNoWait(ctx)
+	w.nonceMutex.Unlock()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get nonce: %v", err)
 	}
 	fmt.Printf("Nonce: %d\n", nonce)
 
-	gasPrice, err := w.SuggestGasPrice(ctx)
+	broadcasted := false
+	defer func() {
+		if !broadcasted {
+			w.nonceMutex.Lock()
+			w.releaseNonce(nonce)
+			w.nonceMutex.Unlock()
+		}
+	}()
+
+	gasPrice, minerTip, err := w.EstimateFees(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get gas price: %v", err)
 	}
-	fmt.Printf("Gas price: %v\n", gasPrice)
-
-	tx := types.NewTx(&types.QuaiTx{
-		ChainID:    w.chainID.Actual,
-		Nonce:      nonce,
-		GasPrice:   gasPrice,
-		MinerTip:   big.NewInt(MinerTip),
-		Gas:        GasLimit,
-		To:         &to,
-		Value:      amount,
-		Data:       nil,
-		AccessList: types.AccessList{},
+	fmt.Printf("Gas price: %v, miner tip: %v\n", gasPrice, minerTip)
+
+	gas, err := w.EstimateGas(ctx, to, amount, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate gas: %v", err)
+	}
+	if err := w.checkGasLimit(gas); err != nil {
+		return nil, err
+	}
+
+	tx := buildTx(TxParams{
+		Type:     QuaiTxType,
+		ChainID:  w.chainID.Actual,
+		Nonce:    nonce,
+		GasPrice: gasPrice,
+		MinerTip: minerTip,
+		Gas:      gas,
+		To:       &to,
+		Value:    amount,
+		Data:     data,
 	})
 	w.printTxDetails(tx)
 
@@ -283,6 +524,10 @@ func (w *Wallet) SendQuai(ctx context.Context, to common.Address, amount *big.In
 	if err := w.BroadcastTransaction(ctx, signedTx); err != nil {
 		return nil, fmt.Errorf("failed to send transaction: %v", err)
 	}
+	broadcasted = true
+	if w.config.DryRun {
+		return signedTx, nil
+	}
 	fmt.Printf("transaction: %s has been broadcasted\n", signedTx.Hash().Hex())
 
 	// Start receipt monitoring
@@ -294,26 +539,38 @@ func (w *Wallet) SendQuai(ctx context.Context, to common.Address, amount *big.In
 }
 
 // MonitorAndConfirmTransaction monitors the transaction and updates the database when confirmed
+// under confirmation_mode, once its block is confirmations deep or finalized. The receipt is read again while waiting, as a reorg
+// may move the transaction to another block.
 func (w *Wallet) MonitorAndConfirmTransaction(ctx context.Context, tx *types.Transaction) (err error) {
 	receipt, err := w.WaitForReceipt(ctx, tx.Hash())
 	if err != nil {
 		fmt.Printf("Error waiting for receipt: %v\n", err)
 		return err
 	}
+	for {
+		err := w.checkConfirmations(ctx, tx, receipt)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errAwaitingConfirmations) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(ReceiptWaitTime):
+		}
+		if receipt, err = w.WaitForReceipt(ctx, tx.Hash()); err != nil {
+			fmt.Printf("Error waiting for receipt: %v\n", err)
+			return err
+		}
+	}
 
 	w.printReceiptDetails(receipt)
 
-	gasUsedAmount := decimal.NewFromInt(int64(receipt.GasUsed)).Mul(decimal.NewFromBigInt(tx.GasPrice(), 0))
-
 	// Update transaction record with confirmation details
-	err = w.txDAL.UpdateTransactionStatus(
-		ctx,
-		tx.Hash().Hex(),
-		gasUsedAmount,
-		receipt,
-	)
-	if err != nil {
-		fmt.Printf("Error updating transaction status: %v\n", err)
+	w.recordReceipt(ctx, tx, receipt)
+	if err := revertedError(tx, receipt); err != nil {
 		return err
 	}
 
@@ -321,55 +578,100 @@ func (w *Wallet) MonitorAndConfirmTransaction(ctx context.Context, tx *types.Tra
 	return nil
 }
 
+// revertedError returns ErrReverted for a receipt of a reverted transaction, recorded as failed
+func revertedError(tx *types.Transaction, receipt *types.Receipt) error {
+	if receipt.Status == types.ReceiptStatusSuccessful {
+		return nil
+	}
+	return fmt.Errorf("%w: %s in block %s", wtypes.ErrReverted, tx.Hash().Hex(), receipt.BlockNumber)
+}
+
+// CheckTransactionAndConfirm updates the database if the transaction has a receipt whose block
+// is confirmed under confirmation_mode, and returns errAwaitingConfirmations while it is mined but shallower
 func (w *Wallet) CheckTransactionAndConfirm(ctx context.Context, tx *types.Transaction) (err error) {
 	receipt, err := w.GetTransactionReceipt(ctx, tx.Hash())
 	if err != nil {
 		return err
 	}
+	if err := w.checkConfirmations(ctx, tx, receipt); err != nil {
+		return err
+	}
 
 	// Print receipt details for logging
 	w.printReceiptDetails(receipt)
 
-	gasUsedAmount := decimal.NewFromInt(int64(receipt.GasUsed)).Mul(decimal.NewFromBigInt(tx.GasPrice(), 0))
-
 	// Update transaction record with confirmation details
-	err = w.txDAL.UpdateTransactionStatus(
-		ctx,
-		tx.Hash().Hex(),
-		gasUsedAmount,
-		receipt,
-	)
-	if err != nil {
-		fmt.Printf("Error updating transaction status: %v\n", err)
-		return err
-	}
+	w.recordReceipt(ctx, tx, receipt)
 
 	// fmt.Printf("Check transaction %s has been confirmed in database\n", tx.Hash().Hex())
-	return nil
+	return revertedError(tx, receipt)
 }
 
-// SendQi sends a Qi transaction
-func (w *Wallet) SendQi(ctx context.Context, to common.Address, amount uint8) (*types.Transaction, error) {
-	// Convert private key to btcec format for Schnorr signing
-	privKeyBytes := crypto.FromECDSA(w.privateKey)
-	btcecPrivKey, _ := btcec.PrivKeyFromBytes(privKeyBytes)
+// schnorrKey returns the wallet key in btcec form for Qi Schnorr signing. It is the same
+// secp256k1 scalar as the ECDSA key, so both forms share one public key.
+func (w *Wallet) schnorrKey() *btcec.PrivateKey {
+	privKey, _ := btcec.PrivKeyFromBytes(crypto.FromECDSA(w.privateKey))
+	return privKey
+}
 
-	txOut := types.NewTxOut(amount, to.Bytes(), big.NewInt(0))
+// QiAddress returns the address of the wallet's Schnorr public key, which owns the wallet's
+// Qi outpoints. The node derives it like a Quai address, from the Keccak hash of the
+// uncompressed public key, so it only holds Qi if it is in the Qi ledger.
+func (w *Wallet) QiAddress() common.Address {
+	return crypto.PubkeyBytesToAddress(w.schnorrKey().PubKey().SerializeUncompressed(), w.location)
+}
 
-	qiTx := &types.QiTx{
-		ChainID: w.chainID.Actual,
-		TxOut:   types.TxOuts{*txOut},
-		// Note: TxIn needs to be populated with actual UTXO data
-	}
-	tx := types.NewTx(qiTx)
+// signQiTx signs a Qi transaction with the wallet's Schnorr key and verifies the signature
+// the way the node does before returning the signed transaction
+func (w *Wallet) signQiTx(params TxParams) (*types.Transaction, error) {
+	key := w.schnorrKey()
 
-	// Sign the transaction with Schnorr signature
-	sig, err := schnorr.Sign(btcecPrivKey, tx.Hash().Bytes())
+	// The signature covers the signer hash of the transaction, not its hash
+	digest := types.NewSigner(params.ChainID, w.location).Hash(buildTx(params))
+	sig, err := schnorr.Sign(key, digest[:])
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign transaction: %v", err)
 	}
 
-	qiTx.Signature = sig
+	if !sig.Verify(digest[:], key.PubKey()) {
+		return nil, errors.New("qi signature does not verify against the wallet key")
+	}
+	qiAddress := w.QiAddress()
+	for i, in := range params.TxIn {
+		if !crypto.PubkeyBytesToAddress(in.PubKey, w.location).Equal(qiAddress) {
+			return nil, fmt.Errorf("input %d is not owned by the wallet Qi address %s", i, qiAddress.Hex())
+		}
+		pubKey, err := btcec.ParsePubKey(in.PubKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key in input %d: %v", i, err)
+		}
+		if !sig.Verify(digest[:], pubKey) {
+			return nil, fmt.Errorf("qi signature does not verify against the public key of input %d", i)
+		}
+	}
+
+	params.Signature = sig
+	return buildTx(params), nil
+}
+
+// SendQi sends one output of the given denomination to an address, spending the wallet's
+// unlocked outpoints and paying the change to qi_change_addresses
+func (w *Wallet) SendQi(ctx context.Context, to common.Address, amount uint8) (*types.Transaction, error) {
+	if qiAddress := w.QiAddress(); !IsInQiLedgerScope(qiAddress.Hex()) {
+		return nil, fmt.Errorf("%w: wallet address %s is not in the qi ledger", wtypes.ErrLedgerMismatch, qiAddress.Hex())
+	}
+	if amount > types.MaxDenomination {
+		return nil, fmt.Errorf("invalid qi denomination %d, the largest is %d", amount, types.MaxDenomination)
+	}
+
+	params, err := w.buildQiTx(ctx, to, amount)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := w.signQiTx(params)
+	if err != nil {
+		return nil, err
+	}
 
 	err = w.BroadcastTransaction(ctx, tx)
 	if err != nil {
@@ -491,6 +793,189 @@ func (w *Wallet) printReceiptDetails(receipt *types.Receipt) {
 	fmt.Printf("\n")
 }
 
+// recordBroadcast appends the outcome of a broadcast to the event log
+func (w *Wallet) recordBroadcast(entry *wtypes.TransferEntry, tx *types.Transaction, err error) {
+	event := eventlog.Event{Type: eventlog.TxBroadcast, EntryID: entry.ID, TxHash: tx.Hash().Hex()}
+	if tx.Type() != types.QiTxType {
+		// A Qi transaction spends outpoints and has no nonce
+		nonce := tx.Nonce()
+		event.Nonce = &nonce
+	}
+	if ClassifyRPCError(err) == RPCErrorKnown {
+		// A re-broadcast of a transaction the node already has, not a failure
+		event.Data = map[string]any{"already_known": true}
+	} else if err != nil {
+		event.Error = err.Error()
+	}
+	w.events.Append(event)
+}
+
+// broadcastWithRetry broadcasts a signed transaction, retrying transient failures up to
+// MaxRetries times with exponential backoff. The same signed transaction is re-sent, so a
+// retry can never pay twice. If the broadcast still fails, the entry is dead-lettered, and a
+// Quai transaction leaves its nonce unsent.
+func (w *Wallet) broadcastWithRetry(ctx context.Context, entry *wtypes.TransferEntry, tx *types.Transaction) error {
+	txHash := tx.Hash().Hex()
+	backoff := w.config.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := w.BroadcastTransaction(ctx, tx)
+		w.recordBroadcast(entry, tx, err)
+		class := ClassifyRPCError(err)
+		if class == RPCErrorKnown {
+			// The node already has this exact transaction, so it's in the mempool and the
+			// submission succeeded, typically when a run is resumed
+			log.Printf("Entry ID %d: transaction %s already known to the node, treating it as broadcast", entry.ID, txHash)
+			err, class = nil, RPCErrorNone
+		}
+		if class == RPCErrorNone || class == RPCErrorNonceUsed {
+			if class == RPCErrorNone && !w.config.DryRun {
+				w.markBroadcast(tx)
+			}
+			w.recordBroadcastHeight(ctx, tx)
+			return err
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+
+		if dbErr := w.txDAL.RecordBroadcastFailure(ctx, txHash, err.Error()); dbErr != nil {
+			log.Printf("failed to record broadcast failure of entry %d: %v", entry.ID, dbErr)
+		}
+
+		if class == RPCErrorPermanent || attempt >= w.config.MaxRetries {
+			if dbErr := w.txDAL.MarkDeadLetter(ctx, txHash, FailureCode(err), err.Error()); dbErr != nil {
+				return fmt.Errorf("failed to dead-letter entry %d: %v (broadcast error: %w)", entry.ID, dbErr, err)
+			}
+			log.Printf("Entry ID %d: dead-lettered after %d retries on %s error: %v\n", entry.ID, attempt, class, err)
+			if tx.Type() == types.QiTxType {
+				return fmt.Errorf("%w after %d retries: %w", wtypes.ErrDeadLettered, attempt, err)
+			}
+			return fmt.Errorf("%w after %d retries (%w: %d): %w", wtypes.ErrDeadLettered, attempt, wtypes.ErrNonceGap, tx.Nonce(), err)
+		}
+
+		log.Printf("🔁 BROADCAST RETRY | ID: %d | Attempt: %d/%d | Backoff: %s | %v", entry.ID, attempt+1, w.config.MaxRetries, backoff, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// markBroadcast counts a transaction the node accepted and stamps its pending entry, if any,
+// with the time so the confirmation latency can be measured
+func (w *Wallet) markBroadcast(tx *types.Transaction) {
+	w.metrics.Broadcast()
+	w.progress.count(1, 0, 0)
+	w.pendingTxMutex.Lock()
+	defer w.pendingTxMutex.Unlock()
+	if pendingTx, ok := w.pendingTxs[tx.Hash()]; ok && pendingTx.BroadcastAt.IsZero() {
+		pendingTx.BroadcastAt = time.Now()
+	}
+}
+
+// recordConfirmation appends a receipt summary to the event log and the result outputs
+func (w *Wallet) recordConfirmation(tx *types.Transaction, receipt *types.Receipt, blockTime time.Time) {
+	var (
+		entryID     int32
+		broadcastAt time.Time
+	)
+	w.pendingTxMutex.RLock()
+	if pendingTx, ok := w.pendingTxs[tx.Hash()]; ok {
+		entryID = pendingTx.Entry.ID
+		broadcastAt = pendingTx.BroadcastAt
+	}
+	w.pendingTxMutex.RUnlock()
+
+	nonce := tx.Nonce()
+	w.events.Append(eventlog.Event{
+		Type:    eventlog.TxConfirmed,
+		EntryID: entryID,
+		TxHash:  tx.Hash().Hex(),
+		Nonce:   &nonce,
+		Data: map[string]any{
+			"status":       getStatusString(receipt.Status),
+			"block_number": receipt.BlockNumber,
+			"gas_used":     receipt.GasUsed,
+		},
+	})
+
+	var blockNumber uint64
+	if receipt.BlockNumber != nil {
+		blockNumber = receipt.BlockNumber.Uint64()
+	}
+	status := report.StatusConfirmed
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		status = report.StatusReverted
+		w.pendingTxMutex.Lock()
+		w.reverted++
+		w.pendingTxMutex.Unlock()
+		w.metrics.Failed(status)
+		w.progress.count(0, 0, 1)
+	} else {
+		var latency time.Duration
+		if !broadcastAt.IsZero() {
+			latency = time.Since(broadcastAt)
+		}
+		w.metrics.Confirmed(latency)
+		w.progress.count(0, 1, 0)
+		w.pendingTxMutex.Lock()
+		w.batchValue = w.batchValue.Add(decimal.NewFromBigInt(tx.Value(), 0))
+		w.pendingTxMutex.Unlock()
+	}
+	fee := decimal.NewFromInt(int64(receipt.GasUsed)).Mul(decimal.NewFromBigInt(tx.GasPrice(), 0))
+	w.pendingTxMutex.Lock()
+	w.batchFees = w.batchFees.Add(fee)
+	w.pendingTxMutex.Unlock()
+	w.recordGasUsage(entryID, tx, receipt)
+
+	w.writeResult(report.Row{
+		ID:      entryID,
+		TxHash:  tx.Hash().Hex(),
+		Status:  status,
+		GasUsed: receipt.GasUsed,
+		Fee:     fee,
+		Value:   decimal.NewFromBigInt(tx.Value(), 0),
+
+		BlockNumber: blockNumber,
+		BlockTime:   blockTime,
+	})
+}
+
+// blockTime returns the timestamp of the block that included a receipt, or the zero time when
+// the block can't be read; the confirmation is recorded either way
+func (w *Wallet) blockTime(ctx context.Context, receipt *types.Receipt) time.Time {
+	if receipt.BlockNumber == nil {
+		return time.Time{}
+	}
+	return w.blockTimeAt(ctx, receipt.BlockNumber)
+}
+
+// blockTimeAt returns the timestamp of block number, or the zero time when it can't be read
+func (w *Wallet) blockTimeAt(ctx context.Context, number *big.Int) time.Time {
+	header, err := w.rpc().HeaderByNumber(ctx, number)
+	if err != nil {
+		log.Printf("⚠️ BLOCK TIME UNAVAILABLE | Block: %s | Error: %v", number, err)
+		return time.Time{}
+	}
+	return time.Unix(int64(header.Time()), 0).UTC()
+}
+
+// recordFailure writes an entry that will not be confirmed in this run to the result outputs
+func (w *Wallet) recordFailure(entry *wtypes.TransferEntry, status string, err error) {
+	w.metrics.Failed(status)
+	w.progress.count(0, 0, 1)
+	w.writeResult(report.Row{ID: entry.ID, Status: status, Error: err.Error(), Value: entry.Value})
+}
+
+func (w *Wallet) writeResult(row report.Row) {
+	if row.Time.IsZero() {
+		row.Time = time.Now()
+	}
+	w.results.Write(row)
+}
+
 // getStatusString converts receipt status to a human-readable string
 func getStatusString(status uint64) string {
 	switch status {
@@ -505,7 +990,7 @@ func getStatusString(status uint64) string {
 
 // verifyChainID verifies if the chain ID is correct with the expected chain ID
 func (w *Wallet) verifyChainID(ctx context.Context) error {
-	actualChainID, err := w.client.ChainID(ctx)
+	actualChainID, err := w.rpc().ChainID(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get chain ID from client: %v", err)
 	}
@@ -513,11 +998,35 @@ func (w *Wallet) verifyChainID(ctx context.Context) error {
 	w.chainID.Actual = actualChainID
 
 	if w.chainID.Expected.Cmp(actualChainID) != 0 {
-		return fmt.Errorf("chain ID mismatch: expected %v, got %v", w.chainID.Expected, actualChainID)
+		if w.config.TrustNodeChainID {
+			log.Printf("⚠️⚠️⚠️ CHAIN ID OVERRIDE | Network: %s | Configured: %v | Node %s: %v | Signing with the node's chain ID, transactions are valid wherever it is in use",
+				w.network, w.chainID.Expected, w.rpcURL(), actualChainID)
+			return nil
+		}
+		return fmt.Errorf("chain ID mismatch on network %s: chain_id is %v but node %s reports %v; %s",
+			w.network, w.chainID.Expected, w.rpcURL(), actualChainID, w.chainIDMismatchHint(actualChainID))
 	}
 	return nil
 }
 
+// chainIDMismatchHint suggests what is likely misconfigured when the node reports another
+// chain ID than the network's chain_id
+func (w *Wallet) chainIDMismatchHint(actual *big.Int) string {
+	var matches []string
+	for network, netConfig := range w.config.Networks {
+		if netConfig.ChainID != nil && netConfig.ChainID.Cmp(actual) == 0 {
+			matches = append(matches, string(network))
+		}
+	}
+	if len(matches) > 0 {
+		sort.Strings(matches)
+		return fmt.Sprintf("that is the chain_id of network %s, so the node likely belongs to another network: check network and the rpc_urls of %s",
+			strings.Join(matches, ", "), w.network)
+	}
+	return fmt.Sprintf("no configured network has that chain_id, so either the rpc_urls of %s point at the wrong node or its chain_id is stale (--trust-node-chain-id signs with the node's)",
+		w.network)
+}
+
 // calculateAddress calculates the address
 func (w *Wallet) calculateAddress() common.Address {
 	publicKey := w.privateKey.Public()
@@ -525,7 +1034,9 @@ func (w *Wallet) calculateAddress() common.Address {
 	if !ok {
 		return common.Address{}
 	}
-	return crypto.PubkeyToAddress(*publicKeyECDSA, w.location)
+	// The address determines the location, so bind it to its own location
+	addrBytes := crypto.PubkeyToAddress(*publicKeyECDSA, w.location).Bytes()
+	return common.BytesToAddress(addrBytes, common.LocationFromAddressBytes(addrBytes))
 }
 
 // locationToString converts a Location to a string key
@@ -539,10 +1050,31 @@ func (w *Wallet) IsValidAddress(address string) bool {
 	if !re.MatchString(address) {
 		return false
 	}
+	if !w.hasValidChecksum(address) {
+		return false
+	}
 	addressBytes := common.FromHex(address)
 	return common.IsInChainScope(addressBytes, w.location)
 }
 
+// hasValidChecksum reports whether a mixed-case address carries a correct EIP-55 checksum.
+// All-lowercase and all-uppercase addresses are treated as unchecksummed and accepted, as is
+// any address when checksum validation is disabled for the network.
+func (w *Wallet) hasValidChecksum(address string) bool {
+	if !w.config.Networks[w.network].ChecksumAddresses {
+		return true
+	}
+	digits := address[2:]
+	if digits == strings.ToLower(digits) || digits == strings.ToUpper(digits) {
+		return true
+	}
+	mixedcase, err := common.NewMixedcaseAddressFromString(address, w.location)
+	if err != nil {
+		return false
+	}
+	return mixedcase.ValidChecksum()
+}
+
 // IsValidQuaiAddress validate address is valid and in Quai ledger scope
 func (w *Wallet) IsValidQuaiAddress(address string) bool {
 	return w.IsValidAddress(address) && IsInQuaiLedgerScope(address)
@@ -553,19 +1085,32 @@ func (w *Wallet) IsValidQiAddress(address string) bool {
 	return w.IsValidAddress(address) && IsInQiLedgerScope(address)
 }
 
-func (w *Wallet) ProcessEntryAsync(ctx context.Context, entry *wtypes.TransferEntry) error {
-	signedTx, storedEntry, status, err := w.GetTransactionByID(ctx, entry.ID)
+// ValidateDestination checks that address is valid, in current chain scope and in the ledger
+// of the configured protocol, naming the expected and actual ledger on mismatch
+func (w *Wallet) ValidateDestination(address string) error {
+	protocol, err := utils.ValidateProtocol(w.config.Protocol)
 	if err != nil {
-		return fmt.Errorf("failed to get transaction: %w", err)
+		return err
 	}
 
-	// Check if transaction is already confirmed
-	if status == models.Confirmed {
-		return wtypes.ErrAlreadyProcessed
+	if !w.IsValidAddress(address) {
+		if common.IsHexAddress(address) && !w.hasValidChecksum(address) {
+			return fmt.Errorf("%w: %s has an invalid checksum, check it for typos", wtypes.ErrInvalidAddress, address)
+		}
+		return fmt.Errorf("%w: %s is malformed or not in chain scope %s", wtypes.ErrInvalidAddress, address, locationToString(w.location))
 	}
 
-	if storedEntry != nil && !CompareEntries(entry, storedEntry) {
-		return fmt.Errorf("entry mismatch for ID %d: stored entry differs from provided entry", entry.ID)
+	if ledger := LedgerOf(address); ledger != protocol {
+		return fmt.Errorf("%w: %s is a %s ledger address, expected %s ledger for a %s transfer",
+			wtypes.ErrLedgerMismatch, address, ledger, protocol, protocol)
+	}
+	return nil
+}
+
+func (w *Wallet) ProcessEntryAsync(ctx context.Context, entry *wtypes.TransferEntry) error {
+	signedTx, err := w.getStoredTransaction(ctx, entry)
+	if err != nil {
+		return err
 	}
 
 	if signedTx == nil {
@@ -589,34 +1134,39 @@ func (w *Wallet) ProcessEntryAsync(ctx context.Context, entry *wtypes.TransferEn
 	w.printTxDetails(signedTx)
 	txHash := signedTx.Hash().Hex()
 
-	if err = w.BroadcastTransaction(ctx, signedTx); err != nil {
-		if !strings.Contains(err.Error(), "nonce too low") && !strings.Contains(err.Error(), "already known") {
+	err = w.broadcastWithRetry(ctx, entry, signedTx)
+	if err != nil {
+		if ClassifyRPCError(err) != RPCErrorNonceUsed {
 			w.pendingTxMutex.Lock()
 			delete(w.pendingTxs, signedTx.Hash())
 			w.pendingTxMutex.Unlock()
 			return fmt.Errorf("failed to broadcast transaction: %w", err)
 		}
-		log.Printf("something went wrong while broadcasting transaction but it's not serious: %v", err)
+		// Most likely this very transaction was mined already, monitoring finds its receipt
+		log.Printf("Entry ID %d: nonce %d already used, monitoring for the receipt of %s", entry.ID, signedTx.Nonce(), txHash)
+	}
+
+	if w.config.DryRun {
+		// Nothing to monitor. The nonce stays reserved, so the next entry is signed with the
+		// nonce it would get in a real run.
+		w.pendingTxMutex.Lock()
+		delete(w.pendingTxs, signedTx.Hash())
+		w.pendingTxMutex.Unlock()
+		log.Printf("Entry ID %d: Transaction: %s has been signed, not broadcast (dry run)\n", entry.ID, txHash)
+		return nil
 	}
 
-	log.Printf("Entry ID %d: Transaction: %s has been broadcasted\n", entry.ID, txHash)
+	w.metrics.Queued()
+	w.metrics.Pending(w.inFlightCount())
+	logEntry(w.config, EntryBroadcast, entry, txHash, nil, "Entry ID %d: Transaction: %s has been broadcasted\n", entry.ID, txHash)
 	return nil
 }
 
 // ProcessEntry handles a single transfer entry
 func (w *Wallet) ProcessEntry(ctx context.Context, entry *wtypes.TransferEntry) error {
-	signedTx, storedEntry, status, err := w.GetTransactionByID(ctx, entry.ID)
+	signedTx, err := w.getStoredTransaction(ctx, entry)
 	if err != nil {
-		return fmt.Errorf("failed to get transaction: %w", err)
-	}
-
-	// Check if transaction is already confirmed
-	if status == models.Confirmed {
-		return wtypes.ErrAlreadyProcessed
-	}
-
-	if storedEntry != nil && !CompareEntries(entry, storedEntry) {
-		return fmt.Errorf("entry mismatch for ID %d: stored entry differs from provided entry", entry.ID)
+		return err
 	}
 
 	if signedTx == nil {
@@ -631,26 +1181,28 @@ func (w *Wallet) ProcessEntry(ctx context.Context, entry *wtypes.TransferEntry)
 	w.printTxDetails(signedTx)
 	txHash := signedTx.Hash().Hex()
 
-	err = w.BroadcastTransaction(ctx, signedTx)
+	err = w.broadcastWithRetry(ctx, entry, signedTx)
 	if err == nil {
-		log.Printf("Entry ID %d: Transaction: %s has been broadcasted\n", entry.ID, txHash)
+		logEntry(w.config, EntryBroadcast, entry, txHash, nil, "Entry ID %d: Transaction: %s has been broadcasted\n", entry.ID, txHash)
 		return w.MonitorAndConfirmTransaction(ctx, signedTx)
 	}
 
-	switch {
-	case strings.Contains(err.Error(), "nonce too low"):
-		if err = w.CheckTransactionAndConfirm(ctx, signedTx); err != nil {
+	// An already known transaction was treated as broadcast above; a used nonce means it
+	// was most likely mined already
+	if ClassifyRPCError(err) == RPCErrorNonceUsed {
+		err = w.CheckTransactionAndConfirm(ctx, signedTx)
+		if errors.Is(err, errAwaitingConfirmations) {
+			return w.MonitorAndConfirmTransaction(ctx, signedTx)
+		}
+		if errors.Is(err, wtypes.ErrReverted) {
+			return err
+		}
+		if err != nil {
 			return fmt.Errorf("failed to check and confirm transaction: receipt %w and nonce too low", err)
 		}
 		return nil
-
-	case strings.Contains(err.Error(), "already known"):
-		log.Printf("transaction: %s already known, skipping", txHash)
-		return w.MonitorAndConfirmTransaction(ctx, signedTx)
-
-	default:
-		return fmt.Errorf("failed to send transaction: %w", err)
 	}
+	return fmt.Errorf("failed to send transaction: %w", err)
 }
 
 // CreateTransaction creates a new transaction and stores it in the database
@@ -667,43 +1219,33 @@ func (w *Wallet) CreateTransaction(ctx context.Context, entry *wtypes.TransferEn
 	if err != nil {
 		return nil, fmt.Errorf("failed to get nonce: %v", err)
 	}
+	defer func() {
+		if err != nil {
+			w.releaseNonce(nonce)
+		}
+	}()
+	w.events.Append(eventlog.Event{Type: eventlog.NonceAssigned, EntryID: entry.ID, Nonce: &nonce})
 
-	if w.config.Debug {
-		log.Printf("(pending: %d, max local: %d)\n", nonce, w.maxLocalNonce)
-	}
-
-	if w.maxLocalNonce >= nonce {
-		nonce = w.maxLocalNonce + 1
-	}
-
-	// Wait for NonceWaitTime seconds
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case <-time.After(NonceWaitTime):
-	}
-
-	gasPrice, err := w.SuggestGasPrice(ctx)
+	gasPrice, minerTip, err := w.entryFees(ctx, entry)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get gas price: %v", err)
 	}
+	gas, gasEstimate, err := w.entryGas(ctx, entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate gas: %v", err)
+	}
 
-	tx = types.NewTx(&types.QuaiTx{
-		ChainID:    w.chainID.Actual,
-		Nonce:      nonce,
-		GasPrice:   gasPrice,
-		MinerTip:   big.NewInt(MinerTip),
-		Gas:        GasLimit,
-		To:         &to,
-		Value:      entry.Value.BigInt(),
-		Data:       nil,
-		AccessList: types.AccessList{},
-	})
-
-	signedTx, err := types.SignTx(tx, types.NewSigner(w.chainID.Actual, w.location), w.privateKey)
+	signedTx, err := w.signEntryTx(entry, nonce, gas, gasPrice, minerTip)
 	if err != nil {
-		return nil, fmt.Errorf("failed to sign transaction: %v", err)
+		return nil, err
 	}
+	w.events.Append(eventlog.Event{
+		Type:    eventlog.TxSigned,
+		EntryID: entry.ID,
+		TxHash:  signedTx.Hash().Hex(),
+		Nonce:   &nonce,
+		Data:    map[string]any{"to": to.Hex(), "value": entry.Value.String(), "gas_price": gasPrice.String()},
+	})
 
 	txJSON, err := json.Marshal(signedTx)
 	if err != nil {
@@ -724,6 +1266,7 @@ func (w *Wallet) CreateTransaction(ctx context.Context, entry *wtypes.TransferEn
 		Nonce:        nonce,
 		Value:        entry.Value,
 		GasLimit:     decimal.NewFromInt(int64(signedTx.Gas())),
+		GasEstimate:  decimal.NewFromInt(int64(gasEstimate)),
 		GasPrice:     decimal.NewFromBigInt(signedTx.GasPrice(), 0),
 		AggregateIds: entry.AggregateIds,
 		Status:       models.Generated,
@@ -731,62 +1274,313 @@ func (w *Wallet) CreateTransaction(ctx context.Context, entry *wtypes.TransferEn
 		Tx:           string(txJSON),
 		Entry:        string(entryJSON),
 	}
+	if entry.IdempotencyKey != "" {
+		txRecord.IdempotencyKey = &entry.IdempotencyKey
+	}
 
 	if err = w.txDAL.CreateTransaction(ctx, txRecord); err != nil {
 		return nil, fmt.Errorf("failed to create transaction record: %v", err)
 	}
 
-	w.maxLocalNonce = nonce
+	log.Printf("Created transaction record: %d, hash: %s, gas price: %s wei\n", txRecord.ID, txRecord.TxHash, gasPrice)
+	return signedTx, nil
+}
 
-	log.Printf("Created transaction record: %d, hash: %s\n", txRecord.ID, txRecord.TxHash)
+// checkGasLimit rejects a gas limit above max_gas_limit, so an absurd estimate fails loudly
+// instead of paying for it
+func (w *Wallet) checkGasLimit(gas uint64) error {
+	if gas > w.config.MaxGasLimit {
+		return fmt.Errorf("%w: transaction needs %d gas, max_gas_limit is %d; raise max_gas_limit if that is expected",
+			wtypes.ErrGasLimitExceeded, gas, w.config.MaxGasLimit)
+	}
+	return nil
+}
+
+// entryDestination returns the address an entry pays
+func (w *Wallet) entryDestination(entry *wtypes.TransferEntry) common.Address {
+	return common.HexToAddress(entry.ToAddress, w.GetLocation())
+}
+
+// signEntryTx builds and signs the transaction paying an entry. Signing is deterministic:
+// go-quai signs through libsecp256k1 with RFC 6979 nonces, so the same entry, nonce, gas and
+// fees always give the same signed transaction and hash.
+func (w *Wallet) signEntryTx(entry *wtypes.TransferEntry, nonce, gas uint64, gasPrice, minerTip *big.Int) (*types.Transaction, error) {
+	if err := w.checkGasLimit(gas); err != nil {
+		return nil, err
+	}
+
+	to, value, data, err := w.entryCall(entry)
+	if err != nil {
+		return nil, err
+	}
+	tx := buildTx(TxParams{
+		Type:     QuaiTxType,
+		ChainID:  w.chainID.Actual,
+		Nonce:    nonce,
+		GasPrice: gasPrice,
+		MinerTip: minerTip,
+		Gas:      gas,
+		To:       &to,
+		Value:    value,
+		Data:     data,
+	})
+
+	signedTx, err := types.SignTx(tx, types.NewSigner(w.chainID.Actual, w.location), w.privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign transaction: %v", err)
+	}
 	return signedTx, nil
 }
 
-func CheckBalance(ctx context.Context, w *Wallet, transferEntries []*wtypes.TransferEntry) error {
-	balance, err := w.GetBalance(ctx)
+// PreviewTransaction returns the signed transaction a batch would broadcast for an entry,
+// without recording or broadcasting anything. An entry that is already recorded previews
+// its stored transaction. Otherwise a nonce is reserved only while signing and released
+// before returning, so the preview never holds up a later send.
+func (w *Wallet) PreviewTransaction(ctx context.Context, entry *wtypes.TransferEntry) (*types.Transaction, error) {
+	if err := w.validateEntry(entry); err != nil {
+		return nil, err
+	}
+
+	stored, err := w.getStoredTransaction(ctx, entry)
 	if err != nil {
-		return fmt.Errorf("failed to get balance: %w", err)
+		return nil, err
 	}
-	balanceDecimal := decimal.NewFromBigInt(balance, 0)
+	if stored != nil {
+		log.Printf("Entry ID %d: previewing the transaction already recorded in database\n", entry.ID)
+		return stored, nil
+	}
+
+	w.nonceMutex.Lock()
+	defer w.nonceMutex.Unlock()
+
+	nonce, err := w.GetNonceNoWait(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nonce: %v", err)
+	}
+	defer w.releaseNonce(nonce)
 
-	totalAmount := decimal.Zero
-	for _, entry := range transferEntries {
-		totalAmount = totalAmount.Add(entry.Value)
+	gasPrice, minerTip, err := w.entryFees(ctx, entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gas price: %v", err)
+	}
+	gas, _, err := w.entryGas(ctx, entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate gas: %v", err)
 	}
+	return w.signEntryTx(entry, nonce, gas, gasPrice, minerTip)
+}
 
-	gasPrice, err := w.SuggestGasPrice(ctx)
+// PreviewTxHash returns the hash the Quai transaction of params will have once signed, without
+// recording or broadcasting it. The transaction is signed in memory with the next nonce, which
+// is reserved only while signing and released before returning, so the hash is the one of the
+// next transaction sent with the same fields, as long as no other is sent first. ChainID
+// defaults to the wallet's. Signing is deterministic, but the hash covers every field: a
+// different nonce, gas, fee, recipient, value or data gives a different hash.
+func (w *Wallet) PreviewTxHash(ctx context.Context, params TxParams) (common.Hash, error) {
+	if params.Type != QuaiTxType {
+		return common.Hash{}, fmt.Errorf("previewing the hash of a %s transaction is not supported", params.Type)
+	}
+	if params.To == nil || params.Value == nil || params.GasPrice == nil || params.MinerTip == nil || params.Gas == 0 {
+		return common.Hash{}, errors.New("transaction is not fully specified: to, value, gas, gas price and miner tip are required")
+	}
+	if err := w.checkGasLimit(params.Gas); err != nil {
+		return common.Hash{}, err
+	}
+	if params.ChainID == nil {
+		params.ChainID = w.chainID.Actual
+	}
+
+	w.nonceMutex.Lock()
+	defer w.nonceMutex.Unlock()
+
+	nonce, err := w.GetNonceNoWait(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get gas price: %w", err)
+		return common.Hash{}, fmt.Errorf("failed to get nonce: %v", err)
 	}
+	defer w.releaseNonce(nonce)
+	params.Nonce = nonce
 
-	// to make sure we have enough balance, we multiply the gas price by 10
-	gasPriceDecimal := decimal.NewFromBigInt(gasPrice, 0).Mul(decimal.NewFromInt(10))
+	signedTx, err := types.SignTx(buildTx(params), types.NewSigner(params.ChainID, w.location), w.privateKey)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to sign transaction: %v", err)
+	}
+	return signedTx.Hash(), nil
+}
 
-	// Calculate total gas cost ———— standard transfer gas limit * estimate gas price * 10 * number of transfers
-	estimatedGas := gasPriceDecimal.Mul(decimal.NewFromInt(GasLimit * int64(len(transferEntries))))
-	totalRequired := totalAmount.Add(estimatedGas)
+// BroadcastRawTransaction decodes a raw transaction, as printed by EncodeRawTransaction, checks
+// it is signed by the wallet and broadcasts it. Raw broadcasts are not recorded in database.
+func (w *Wallet) BroadcastRawTransaction(ctx context.Context, raw string) (*types.Transaction, error) {
+	if w.config.DryRun {
+		return nil, fmt.Errorf("%w: raw transactions are only ever broadcast", wtypes.ErrDryRun)
+	}
+	tx, err := DecodeRawTransaction(raw, w.location)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := types.Sender(types.NewSigner(w.chainID.Actual, w.location), tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recover transaction sender: %v", err)
+	}
+	if !from.Equal(w.address) {
+		return nil, fmt.Errorf("transaction is signed by %s, not by the wallet %s", from.Hex(), w.address.Hex())
+	}
+
+	if err := w.BroadcastTransaction(ctx, tx); err != nil {
+		if ClassifyRPCError(err) != RPCErrorKnown {
+			return nil, fmt.Errorf("failed to broadcast transaction: %w", err)
+		}
+		log.Printf("transaction %s already known to the node, treating it as broadcast", tx.Hash().Hex())
+	}
+	return tx, nil
+}
+
+func CheckBalance(ctx context.Context, w *Wallet, transferEntries []*wtypes.TransferEntry) error {
+	if err := w.checkBalance(ctx, totalsOf(transferEntries)); err != nil {
+		return err
+	}
+	return w.checkTokenBalances(ctx, transferEntries)
+}
+
+// checkBalance fails when the balance can't cover entries of the given totals with
+// balance_buffer_percent to spare
+func (w *Wallet) checkBalance(ctx context.Context, totals entryTotals) error {
+	balance, err := w.GetBalance(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get balance: %w", err)
+	}
+	balanceDecimal := decimal.NewFromBigInt(balance, 0)
+
+	totalRequired, err := w.requiredBalance(ctx, totals)
+	if err != nil {
+		return err
+	}
+	if w.config.BalanceBufferPercent > 0 {
+		buffer := totalRequired.Mul(decimal.NewFromInt(w.config.BalanceBufferPercent)).Div(decimal.NewFromInt(100)).Floor()
+		totalRequired = totalRequired.Add(buffer)
+	}
 
 	if balanceDecimal.LessThan(totalRequired) {
-		return fmt.Errorf("insufficient balance for transfers: have %s, need %s",
+		return fmt.Errorf("%w for transfers: have %s, need %s", wtypes.ErrInsufficientBalance,
 			utils.ToQuai(balanceDecimal.String()), utils.ToQuai(totalRequired.String()))
 	}
 	log.Printf("balance check passed, have %s, need at least %s", utils.ToQuai(balanceDecimal.String()), utils.ToQuai(totalRequired.String()))
 	return nil
 }
 
+// getStoredTransaction returns the signed transaction already recorded for an entry, or nil if
+// there is none. The record is the only source of truth: an entry that has one is only ever
+// rebroadcast with its signed transaction, checked against the recorded hash, and never signed
+// again with a fresh nonce, so replaying a CSV can't pay an entry twice.
+func (w *Wallet) getStoredTransaction(ctx context.Context, entry *wtypes.TransferEntry) (*types.Transaction, error) {
+	signedTx, status, err := findStoredTransaction(ctx, w.txDAL, entry)
+	if err != nil || signedTx == nil {
+		return nil, err
+	}
+
+	if status == models.DryRun {
+		// Never broadcast, so its nonce may be long taken; sign the entry anew
+		if err := w.txDAL.DeleteDryRun(ctx, signedTx.Hash().Hex()); err != nil {
+			return nil, fmt.Errorf("failed to delete dry run transaction: %w", err)
+		}
+		log.Printf("Entry ID %d: dropped dry run transaction %s, signing it again\n", entry.ID, signedTx.Hash().Hex())
+		return nil, nil
+	}
+	if w.config.DryRun {
+		// A real transaction, maybe in the mempool already; a dry run leaves it alone
+		log.Printf("Entry ID %d: transaction %s is pending, left alone by the dry run\n", entry.ID, signedTx.Hash().Hex())
+		return nil, wtypes.ErrAlreadyProcessed
+	}
+	return signedTx, nil
+}
+
+// recordLookup finds the record of an entry, nil if there is none
+type recordLookup interface {
+	GetTransactionByID(ctx context.Context, id int32) (*models.Transaction, error)
+	GetTransactionByIdempotencyKey(ctx context.Context, key string) (*models.Transaction, error)
+}
+
+// findStoredTransaction returns the signed transaction and status of the record of an entry,
+// or no transaction if there is none. The idempotency key is looked up first so that a payout
+// whose ID was reassigned upstream is still recognized, then the ID. A record that settles the
+// entry, confirmed, reverted or dead-lettered, is returned as its error, and one of a different
+// payout as a mismatch.
+func findStoredTransaction(ctx context.Context, records recordLookup, entry *wtypes.TransferEntry) (*types.Transaction, models.TxStatus, error) {
+	var (
+		signedTx    *types.Transaction
+		storedEntry *wtypes.TransferEntry
+		status      models.TxStatus
+	)
+
+	if entry.IdempotencyKey != "" {
+		txRecord, err := records.GetTransactionByIdempotencyKey(ctx, entry.IdempotencyKey)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to get transaction: %w", err)
+		}
+		if signedTx, storedEntry, status, err = decodeTransactionRecord(txRecord); err != nil {
+			return nil, 0, fmt.Errorf("failed to get transaction: %w", err)
+		}
+		if storedEntry != nil && storedEntry.ID != entry.ID {
+			log.Printf("Entry ID %d: idempotency key %q already recorded under entry ID %d\n", entry.ID, entry.IdempotencyKey, storedEntry.ID)
+		}
+	}
+
+	if storedEntry == nil {
+		txRecord, err := records.GetTransactionByID(ctx, entry.ID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to get transaction: %w", err)
+		}
+		if signedTx, storedEntry, status, err = decodeTransactionRecord(txRecord); err != nil {
+			return nil, 0, fmt.Errorf("failed to get transaction: %w", err)
+		}
+	}
+	if storedEntry == nil {
+		return nil, 0, nil
+	}
+
+	switch status {
+	case models.Confirmed:
+		return nil, status, wtypes.ErrAlreadyProcessed
+	case models.Failed:
+		// Reverted on chain with its nonce spent; paying the entry again is left to the operator
+		return nil, status, fmt.Errorf("%w: %s", wtypes.ErrReverted, signedTx.Hash().Hex())
+	case models.DeadLetter:
+		return nil, status, fmt.Errorf("%w: requeue entry %d with the dead-letter command to retry it", wtypes.ErrDeadLettered, entry.ID)
+	}
+
+	if !CompareEntries(entry, storedEntry) {
+		return nil, status, fmt.Errorf("entry mismatch for ID %d: stored entry differs from provided entry", entry.ID)
+	}
+	return signedTx, status, nil
+}
+
 // GetTransactionByID retrieves transaction details by ID
 func (w *Wallet) GetTransactionByID(ctx context.Context, id int32) (*types.Transaction, *wtypes.TransferEntry, models.TxStatus, error) {
 	txRecord, err := w.txDAL.GetTransactionByID(ctx, id)
 	if err != nil {
 		return nil, nil, 0, fmt.Errorf("failed to get transaction: %v", err)
 	}
+	return decodeTransactionRecord(txRecord)
+}
+
+// GetTransactionByIdempotencyKey retrieves transaction details by idempotency key
+func (w *Wallet) GetTransactionByIdempotencyKey(ctx context.Context, key string) (*types.Transaction, *wtypes.TransferEntry, models.TxStatus, error) {
+	txRecord, err := w.txDAL.GetTransactionByIdempotencyKey(ctx, key)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("failed to get transaction: %v", err)
+	}
+	return decodeTransactionRecord(txRecord)
+}
+
+// decodeTransactionRecord deserializes the signed transaction and entry of a record
+func decodeTransactionRecord(txRecord *models.Transaction) (*types.Transaction, *wtypes.TransferEntry, models.TxStatus, error) {
 	if txRecord == nil {
 		return nil, nil, 0, nil // Return nil if no record found
 	}
 
-	var tx types.Transaction
-	if err := json.Unmarshal([]byte(txRecord.Tx), &tx); err != nil {
-		return nil, nil, 0, fmt.Errorf("failed to deserialize transaction: %v", err)
+	tx, err := DecodeStoredTransaction(txRecord)
+	if err != nil {
+		return nil, nil, 0, err
 	}
 
 	var entry wtypes.TransferEntry
@@ -794,62 +1588,313 @@ func (w *Wallet) GetTransactionByID(ctx context.Context, id int32) (*types.Trans
 		return nil, nil, 0, fmt.Errorf("failed to deserialize entry: %v", err)
 	}
 
-	return &tx, &entry, txRecord.Status, nil
+	return tx, &entry, txRecord.Status, nil
 }
 
-// CompareEntries compares two TransferEntry objects and returns true if they are equal
+// DecodeStoredTransaction deserializes the signed transaction of a record and verifies
+// its hash matches the stored TxHash, so what is rebroadcast is exactly what was recorded
+func DecodeStoredTransaction(txRecord *models.Transaction) (*types.Transaction, error) {
+	var tx types.Transaction
+	if err := json.Unmarshal([]byte(txRecord.Tx), &tx); err != nil {
+		return nil, fmt.Errorf("failed to deserialize transaction: %v", err)
+	}
+	if !strings.EqualFold(tx.Hash().Hex(), txRecord.TxHash) {
+		return nil, fmt.Errorf("%w for ID %d: record has %s, decoded tx has %s",
+			wtypes.ErrStoredTxMismatch, txRecord.ID, txRecord.TxHash, tx.Hash().Hex())
+	}
+	return &tx, nil
+}
+
+// DecodeRawTransaction decodes the proto-encoded hex form of a signed transaction
+func DecodeRawTransaction(raw string, location common.Location) (*types.Transaction, error) {
+	data, err := hexutil.Decode(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid raw transaction hex: %v", err)
+	}
+	var protoTx types.ProtoTransaction
+	if err := proto.Unmarshal(data, &protoTx); err != nil {
+		return nil, fmt.Errorf("failed to decode raw transaction: %v", err)
+	}
+	var tx types.Transaction
+	if err := tx.ProtoDecode(&protoTx, location); err != nil {
+		return nil, fmt.Errorf("failed to decode raw transaction: %v", err)
+	}
+	return &tx, nil
+}
+
+// EncodeRawTransaction returns the proto-encoded hex form of a signed transaction,
+// as it is sent to the node
+func EncodeRawTransaction(tx *types.Transaction) (string, error) {
+	protoTx, err := tx.ProtoEncode()
+	if err != nil {
+		return "", err
+	}
+	data, err := proto.Marshal(protoTx)
+	if err != nil {
+		return "", err
+	}
+	return hexutil.Encode(data), nil
+}
+
+// CompareEntries compares two TransferEntry objects and returns true if they are equal.
+// Entries sharing an idempotency key are the same payout even if their IDs differ.
 func CompareEntries(a, b *wtypes.TransferEntry) bool {
 	if a == nil || b == nil {
 		return a == b // Both should be nil to be equal
 	}
 
-	return a.ID == b.ID &&
+	sameKey := a.IdempotencyKey != "" && a.IdempotencyKey == b.IdempotencyKey
+	return (a.ID == b.ID || sameKey) &&
 		a.MinerAccountID == b.MinerAccountID &&
 		a.ToAddress == b.ToAddress &&
+		bytes.Equal(a.Data, b.Data) &&
+		a.TokenAddress == b.TokenAddress &&
 		a.Value.Equal(b.Value)
 }
 
-// ProcessBatchEntry processes multiple transfer entries asynchronously
-func (w *Wallet) ProcessBatchEntry(ctx context.Context, entries []*wtypes.TransferEntry) {
-	invalidCnt := 0
-	successCnt := 0
-	failedCnt := 0
-	processedCnt := 0
+// BatchResult summarizes the outcome of a batch transfer
+type BatchResult struct {
+	Total        int
+	Success      int
+	Failed       int
+	Reverted     int // mined but reverted, not counted in Success
+	Processed    int
+	Unprocessed  int
+	Invalid      int
+	Unsent       int
+	DeadLettered int
+	UnsentIDs    []int32
+	Duration     time.Duration
+	// TotalFees is what the transactions mined during the batch paid in gas, reverted ones
+	// included, in wei
+	TotalFees decimal.Decimal
+	// TotalValue is what the transactions mined successfully during the batch paid out, in
+	// wei. Reverted ones moved nothing.
+	TotalValue decimal.Decimal
+	// Gas compares the gas limits of the mined transactions with their usage, with gas_report
+	Gas GasStats
+}
+
+// markUnsent records entries that were never broadcast because the batch stopped early
+func (r *BatchResult) markUnsent(entries []*wtypes.TransferEntry, reason error) {
+	ids := make([]int32, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ID
+	}
+	r.markUnsentIDs(ids, reason)
+}
+
+func (r *BatchResult) markUnsentIDs(ids []int32, reason error) {
+	r.UnsentIDs = append(r.UnsentIDs, ids...)
+	r.Unsent += len(ids)
+	log.Printf("🛑 BROADCAST STOPPED | Unsent: %d | IDs: %v | Reason: %v", len(ids), r.UnsentIDs, reason)
+}
 
-	now := time.Now()
+// merge adds the counts of another batch. Batches are merged after running concurrently,
+// so the longest one determines the duration.
+func (r *BatchResult) merge(other *BatchResult) {
+	if other == nil {
+		return
+	}
+	r.Total += other.Total
+	r.Success += other.Success
+	r.Failed += other.Failed
+	r.Reverted += other.Reverted
+	r.Processed += other.Processed
+	r.Unprocessed += other.Unprocessed
+	r.Invalid += other.Invalid
+	r.Unsent += other.Unsent
+	r.DeadLettered += other.DeadLettered
+	r.UnsentIDs = append(r.UnsentIDs, other.UnsentIDs...)
+	r.TotalFees = r.TotalFees.Add(other.TotalFees)
+	r.TotalValue = r.TotalValue.Add(other.TotalValue)
+	r.Gas.merge(&other.Gas)
+	if other.Duration > r.Duration {
+		r.Duration = other.Duration
+	}
+}
+
+// validateBatch checks the entries of a batch and returns the valid ones, counting the others
+// in result as invalid. Under strict validation a single invalid entry fails the whole batch.
+func (w *Wallet) validateBatch(entries []*wtypes.TransferEntry, result *BatchResult) ([]*wtypes.TransferEntry, error) {
+	validEntries := make([]*wtypes.TransferEntry, 0, len(entries))
 	for _, entry := range entries {
-		if !w.IsValidQuaiAddress(entry.ToAddress) {
-			invalidCnt++
-			log.Printf("⚠️ TRANSFER INVALID | Miner: %s | ID: %d | Invalid Quai address", entry.MinerAccount, entry.ID)
+		if err := w.validateEntry(entry); err != nil {
+			result.Invalid++
+			logEntry(w.config, EntryInvalid, entry, "", err, "⚠️ TRANSFER INVALID | Miner: %s | ID: %d | %v", entry.MinerAccount, entry.ID, err)
+			w.recordFailure(entry, report.StatusInvalid, err)
 			continue
 		}
+		validEntries = append(validEntries, entry)
+	}
 
-		err := w.ProcessEntryAsync(ctx, entry)
-		if err != nil {
-			if errors.Is(err, wtypes.ErrAlreadyProcessed) {
-				processedCnt++
-				log.Printf("⏭️ TRANSFER SKIPPED | Miner: %s | ID: %d | Already processed", entry.MinerAccount, entry.ID)
-				continue
+	if result.Invalid > 0 && w.config.StrictValidation {
+		return nil, fmt.Errorf("%w: %d of %d entries are invalid, strict validation aborted the batch before broadcasting",
+			wtypes.ErrInvalidEntries, result.Invalid, result.Total)
+	}
+	return validEntries, nil
+}
+
+// ProcessBatchEntry processes multiple transfer entries asynchronously
+func (w *Wallet) ProcessBatchEntry(ctx context.Context, entries []*wtypes.TransferEntry) (*BatchResult, error) {
+	result := &BatchResult{Total: len(entries)}
+	defer w.finishBatch(result, time.Now())
+	w.startProgress(result.Total)
+
+	validEntries, err := w.validateBatch(entries, result)
+	if err != nil {
+		return result, err
+	}
+
+	if w.config.Priority != "" {
+		if err := SortEntries(validEntries, w.config.Priority); err != nil {
+			return result, err
+		}
+		if validEntries, err = w.cutOffUnaffordable(ctx, validEntries, result); err != nil {
+			return result, err
+		}
+	}
+
+	if err := w.startGasPriceTracking(ctx); err != nil {
+		return result, err
+	}
+	defer w.stopGasPriceTracking()
+	w.resetBatchState()
+
+	// Broadcast phase: no new transaction is sent once this loop exits
+	pool := w.newBatchPool()
+	remaining := totalsOf(validEntries)
+	for i, entry := range validEntries {
+		err := pool.acquire(ctx)
+		if err == nil {
+			if err = w.awaitGasPrice(ctx); err == nil {
+				err = w.awaitBalance(ctx, entry, remaining, i)
 			}
-			failedCnt++
-			log.Printf("❌ TRANSFER FAILED | Miner: %s | ID: %d | Error: %v", entry.MinerAccount, entry.ID, err)
-			continue
+			if err == nil {
+				err = pool.awaitInFlight(ctx)
+			}
+			if err != nil {
+				pool.release()
+			}
+		}
+		if err != nil {
+			result.markUnsent(validEntries[i:], err)
+			break
 		}
+		remaining.remove(entry)
+		pool.send(ctx, entry)
+	}
+	pool.wait(result)
+
+	w.monitorBatch(ctx, result)
+	return result, nil
+}
+
+// finishBatch logs the summary of a batch that started at start and records it in the event log
+func (w *Wallet) finishBatch(result *BatchResult, start time.Time) {
+	result.Duration = time.Since(start)
+	title := fmt.Sprintf("BATCH TRANSFER SUMMARY (%s)", locationToString(w.location))
+	if w.config.DryRun {
+		title = "DRY RUN " + title
+	}
+	logBatchSummary(title, result)
+	w.finishProgress(result)
+	w.events.Append(eventlog.Event{Type: eventlog.BatchSummary, Data: map[string]any{"location": locationToString(w.location), "result": result}})
+}
+
+// resetBatchState clears the per-batch state of the wallet before a batch broadcasts
+func (w *Wallet) resetBatchState() {
+	w.balanceCheckedAt = time.Now()
+	w.pendingTxMutex.Lock()
+	w.abandoned = 0
+	w.reverted = 0
+	w.batchFees = decimal.Zero
+	w.batchValue = decimal.Zero
+	w.batchGas = GasStats{}
+	w.pendingTxMutex.Unlock()
+}
+
+// sendBatchEntry broadcasts one valid entry of a batch and counts its outcome. It returns the
+// error the entry failed with, nil if it didn't, for the pool to decide whether to go on.
+func (w *Wallet) sendBatchEntry(ctx context.Context, entry *wtypes.TransferEntry, result *BatchResult) error {
+	defer w.saveProgress(false)
+	err := w.ProcessEntryAsync(ctx, entry)
+	if err == nil {
+		logEntry(w.config, EntryQueued, entry, "", nil,
+			"📤 TRANSFER QUEUED | Miner: %s | ID: %d | Amount: %s Quai", entry.MinerAccount, entry.ID, utils.ToQuai(entry.Value.String()))
+		return nil
+	}
 
-		log.Printf("📤 TRANSFER QUEUED | Miner: %s | ID: %d | Amount: %s Quai", entry.MinerAccount, entry.ID, utils.ToQuai(entry.Value.String()))
+	if errors.Is(err, wtypes.ErrAlreadyProcessed) {
+		result.Processed++
+		logEntry(w.config, EntrySkipped, entry, "", nil, "⏭️ TRANSFER SKIPPED | Miner: %s | ID: %d | Already processed", entry.MinerAccount, entry.ID)
+		return nil
+	}
+	if errors.Is(err, wtypes.ErrReverted) {
+		// Reverted in an earlier run, still unpaid
+		result.Reverted++
+		logEntry(w.config, EntryFailed, entry, "", err, "↩️ TRANSFER REVERTED | Miner: %s | ID: %d | Error: %v", entry.MinerAccount, entry.ID, err)
+		w.recordFailure(entry, report.StatusReverted, err)
+	} else if errors.Is(err, wtypes.ErrDeadLettered) {
+		result.DeadLettered++
+		logEntry(w.config, EntryDeadLettered, entry, "", err, "🪦 TRANSFER DEAD-LETTERED | Miner: %s | ID: %d | Error: %v", entry.MinerAccount, entry.ID, err)
+		w.recordFailure(entry, report.StatusDeadLettered, err)
+	} else {
+		result.Failed++
+		logEntry(w.config, EntryFailed, entry, "", err, "❌ TRANSFER FAILED | Miner: %s | ID: %d | Error: %v", entry.MinerAccount, entry.ID, err)
+		w.recordFailure(entry, report.StatusFailed, err)
 	}
+	return err
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+// monitorBatch waits for everything the batch broadcast to confirm, then fills in the
+// unprocessed and success counts of its result. Once ctx is canceled, as on an interrupt,
+// the pending transactions are checked one last time and the rest counted as unprocessed.
+func (w *Wallet) monitorBatch(ctx context.Context, result *BatchResult) {
+	ctx, cancel := context.WithTimeout(ctx, MonitorTimeout)
 	defer cancel()
 
 	unprocessedCount, err := w.MonitorAllTransactions(ctx)
 	if err != nil {
 		log.Printf("Error monitoring transactions: %v", err)
 	}
+	result.Unprocessed = unprocessedCount
+	w.pendingTxMutex.RLock()
+	result.DeadLettered += w.abandoned
+	result.Reverted += w.reverted
+	result.TotalFees = w.batchFees
+	result.TotalValue = w.batchValue
+	result.Gas = w.batchGas
+	w.pendingTxMutex.RUnlock()
 	// Update success count based on confirmed transactions
-	successCnt = len(entries) - invalidCnt - failedCnt - processedCnt - unprocessedCount
-	log.Printf("\n📊 BATCH TRANSFER SUMMARY 📊\nCompleted in %s\n😈 Total: %d\n✅  Success: %d\n❌  Failed: %d\n⏭️ Processed: %d\n😓 Unprocessed: %d\n⚠️ Invalid: %d\n",
-		time.Since(now), len(entries), successCnt, failedCnt, processedCnt, unprocessedCount, invalidCnt)
+	result.Success = result.Total - result.Invalid - result.Failed - result.Reverted - result.Processed - result.Unprocessed - result.Unsent - result.DeadLettered
+}
+
+// logBatchSummary prints the final summary of a batch transfer
+func logBatchSummary(title string, result *BatchResult) {
+	log.Printf("\n📊 %s 📊\nCompleted in %s\n😈 Total: %d\n✅  Success: %d\n❌  Failed: %d\n↩️ Reverted: %d\n⏭️ Processed: %d\n😓 Unprocessed: %d\n⚠️ Invalid: %d\n🛑 Unsent: %d\n🪦 Dead-lettered: %d\n⛽ Total fees: %s Quai\n",
+		title, result.Duration, result.Total, result.Success, result.Failed, result.Reverted, result.Processed, result.Unprocessed, result.Invalid, result.Unsent, result.DeadLettered,
+		utils.ToQuai(result.TotalFees.BigInt()))
+	if result.Gas.Count > 0 {
+		log.Printf("⛽ GAS USAGE | Transactions: %d | Average utilization: %.1f%% | Highest: %.1f%% | Smallest headroom: %d | Near the limit: %d",
+			result.Gas.Count, result.Gas.AverageUtilization()*100, result.Gas.MaxUtilization*100, result.Gas.MinHeadroom, result.Gas.NearLimit)
+	}
+}
+
+// EstimateBatchDuration estimates how long batches of the given sizes take when run
+// concurrently, if each entry waits nonceWait before it's signed and confirmations are polled
+// every ReceiptWaitTime once broadcasting ends. Entries only wait for a node lagging behind,
+// so with the configured nonce_wait this is the worst case of the waits, while retries and
+// gas price pauses are not included.
+func EstimateBatchDuration(nonceWait time.Duration, batchSizes ...int) time.Duration {
+	var longest int
+	for _, n := range batchSizes {
+		longest = max(longest, n)
+	}
+	if longest == 0 {
+		return 0
+	}
+	return time.Duration(longest)*nonceWait + ReceiptWaitTime
 }
 
 // getCopyPendingTxs returns a slice of pending transactions in a thread-safe way
@@ -886,11 +1931,13 @@ func (w *Wallet) MonitorAllTransactions(ctx context.Context) (int, error) {
 					pendingTx.Entry.ID, txHash.Hex())
 			}
 			w.pendingTxMutex.RUnlock()
+			w.metrics.Pending(unprocessedCount)
 			log.Printf("Transaction monitoring stopped due to context cancellation: %v", ctx.Err())
 			return unprocessedCount, ctx.Err()
 
 		case <-ticker.C:
 			w.checkPendingTransactions()
+			w.saveProgress(true)
 			sortedTxs := w.getCopyPendingTxs()
 
 			sort.Slice(sortedTxs, func(i, j int) bool {
@@ -913,17 +1960,34 @@ func (w *Wallet) MonitorAllTransactions(ctx context.Context) (int, error) {
 func (w *Wallet) checkPendingTransactions() {
 	pendingTxs := w.getCopyPendingTxs()
 
+	unconfirmed := make([]*PendingTx, 0, len(pendingTxs))
 	for _, pendingTx := range pendingTxs {
 		err := w.CheckTransactionAndConfirm(context.Background(), pendingTx.Tx)
-		if err == nil {
-			log.Printf("\n✅ TRANSFER SUCCESSFUL ✅\nMiner Account: %s\nEntry ID: %d\nTransferred: %s Quai\n",
+		if errors.Is(err, wtypes.ErrReverted) {
+			// Recorded as failed and written to the result outputs as reverted
+			logEntry(w.config, EntryFailed, pendingTx.Entry, pendingTx.Tx.Hash().Hex(), err,
+				"\n↩️ TRANSFER REVERTED ↩️\nMiner Account: %s\nEntry ID: %d\nNot transferred: %s Quai\n",
+				pendingTx.Entry.MinerAccount, pendingTx.Entry.ID, utils.ToQuai(pendingTx.Entry.Value.String()))
+		} else if err != nil {
+			// Mined ones only wait for their confirmations, the timeout is for unmined ones
+			if !errors.Is(err, errAwaitingConfirmations) {
+				unconfirmed = append(unconfirmed, pendingTx)
+			}
+			continue
+		} else {
+			logEntry(w.config, EntryConfirmed, pendingTx.Entry, pendingTx.Tx.Hash().Hex(), nil,
+				"\n✅ TRANSFER SUCCESSFUL ✅\nMiner Account: %s\nEntry ID: %d\nTransferred: %s Quai\n",
 				pendingTx.Entry.MinerAccount, pendingTx.Entry.ID, utils.ToQuai(pendingTx.Entry.Value.String()))
-
-			func() {
-				w.pendingTxMutex.Lock()
-				defer w.pendingTxMutex.Unlock()
-				delete(w.pendingTxs, pendingTx.Tx.Hash())
-			}()
 		}
+
+		func() {
+			w.pendingTxMutex.Lock()
+			defer w.pendingTxMutex.Unlock()
+			delete(w.pendingTxs, pendingTx.Tx.Hash())
+		}()
 	}
+
+	w.checkBlockTimeouts(context.Background(), unconfirmed)
+	w.reconcileMinedNonces(context.Background())
+	w.metrics.Pending(w.inFlightCount())
 }
@@ -28,6 +28,7 @@ type WalletFunc interface {
 	IsValidAddress(address string) bool
 	IsValidQuaiAddress(address string) bool
 	IsValidQiAddress(address string) bool
+	ValidateDestination(address string) error
 
 	// Transaction utilities
 	SuggestGasPrice(ctx context.Context) (*big.Int, error)
@@ -36,11 +37,21 @@ type WalletFunc interface {
 // IsInQuaiLedgerScope checks if an address is in the Quai ledger scope
 func IsInQuaiLedgerScope(address string) bool {
 	// The first bit of the second byte is not set if the address is in the Quai ledger
-	return address[1] <= 127
+	addressBytes := common.FromHex(address)
+	return len(addressBytes) > 1 && addressBytes[1] <= 127
 }
 
 // IsInQiLedgerScope checks if an address is in the Qi ledger scope
 func IsInQiLedgerScope(address string) bool {
 	// The first bit of the second byte is set if the address is in the Qi ledger
-	return address[1] > 127
+	addressBytes := common.FromHex(address)
+	return len(addressBytes) > 1 && addressBytes[1] > 127
+}
+
+// LedgerOf returns the protocol ("quai" or "qi") whose ledger the address belongs to
+func LedgerOf(address string) string {
+	if IsInQiLedgerScope(address) {
+		return "qi"
+	}
+	return "quai"
 }
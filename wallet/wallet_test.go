@@ -0,0 +1,398 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"quai-transfer/config"
+	"quai-transfer/dal/models"
+	wtypes "quai-transfer/types"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/crypto"
+	"github.com/shopspring/decimal"
+)
+
+// Addresses of zone 0-0, in the Quai and Qi ledgers, and of zone 1-0
+const (
+	testQuaiAddress      = "0x0063983e573E5AB68efF7057C7ccE0902256e029"
+	testQiAddress        = "0x0080000000000000000000000000000000000001"
+	testOtherZoneAddress = "0x1076b67a0cfc64cfe3798670427215d759494167"
+)
+
+// newTestWallet returns a wallet of zone 0-0 with no node, key or database, enough for what
+// is checked offline
+func newTestWallet(cfg *config.Config) *Wallet {
+	if cfg.Protocol == "" {
+		cfg.Protocol = "quai"
+	}
+	return &Wallet{config: cfg, location: common.Location{0, 0}}
+}
+
+func testEntry(id int32, to string) *wtypes.TransferEntry {
+	return &wtypes.TransferEntry{ID: id, ToAddress: to, Value: decimal.NewFromInt(1)}
+}
+
+func TestValidateBatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		strict    bool
+		entries   []*wtypes.TransferEntry
+		wantValid []int32
+		wantErr   error
+	}{
+		{
+			name:      "all valid",
+			entries:   []*wtypes.TransferEntry{testEntry(1, testQuaiAddress), testEntry(2, testQuaiAddress)},
+			wantValid: []int32{1, 2},
+		},
+		{
+			name:      "all valid, strict",
+			strict:    true,
+			entries:   []*wtypes.TransferEntry{testEntry(1, testQuaiAddress), testEntry(2, testQuaiAddress)},
+			wantValid: []int32{1, 2},
+		},
+		{
+			name:      "invalid entries skipped",
+			entries:   []*wtypes.TransferEntry{testEntry(1, testQuaiAddress), testEntry(2, testOtherZoneAddress), testEntry(3, testQiAddress), testEntry(4, "0x1234")},
+			wantValid: []int32{1},
+		},
+		{
+			name:    "invalid entries abort, strict",
+			strict:  true,
+			entries: []*wtypes.TransferEntry{testEntry(1, testQuaiAddress), testEntry(2, testOtherZoneAddress)},
+			wantErr: wtypes.ErrInvalidEntries,
+		},
+		{
+			name:    "invalid token address abort, strict",
+			strict:  true,
+			entries: []*wtypes.TransferEntry{{ID: 1, ToAddress: testQuaiAddress, TokenAddress: testOtherZoneAddress, Value: decimal.NewFromInt(1)}},
+			wantErr: wtypes.ErrInvalidEntries,
+		},
+		{
+			name:      "every entry invalid",
+			entries:   []*wtypes.TransferEntry{testEntry(1, testQiAddress)},
+			wantValid: []int32{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newTestWallet(&config.Config{StrictValidation: tt.strict})
+			result := &BatchResult{Total: len(tt.entries)}
+			valid, err := w.validateBatch(tt.entries, result)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if valid != nil {
+					t.Errorf("returned %d entries along with the error", len(valid))
+				}
+				if result.Invalid == 0 {
+					t.Error("no entry counted invalid")
+				}
+				return
+			}
+
+			if len(valid) != len(tt.wantValid) {
+				t.Fatalf("%d valid entries, want %d", len(valid), len(tt.wantValid))
+			}
+			for i, entry := range valid {
+				if entry.ID != tt.wantValid[i] {
+					t.Errorf("valid entry %d has ID %d, want %d", i, entry.ID, tt.wantValid[i])
+				}
+			}
+			if want := len(tt.entries) - len(tt.wantValid); result.Invalid != want {
+				t.Errorf("%d entries counted invalid, want %d", result.Invalid, want)
+			}
+		})
+	}
+}
+
+// testRecords is a recordLookup over records kept in memory
+type testRecords []*models.Transaction
+
+func (r testRecords) GetTransactionByID(ctx context.Context, id int32) (*models.Transaction, error) {
+	for _, record := range r {
+		if record.ID == id {
+			return record, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r testRecords) GetTransactionByIdempotencyKey(ctx context.Context, key string) (*models.Transaction, error) {
+	for _, record := range r {
+		if record.IdempotencyKey != nil && *record.IdempotencyKey == key {
+			return record, nil
+		}
+	}
+	return nil, nil
+}
+
+// testRecord signs the transaction of an entry with a throwaway key and records it with status
+func testRecord(t *testing.T, entry *wtypes.TransferEntry, status models.TxStatus) *models.Transaction {
+	t.Helper()
+	key, err := crypto.HexToECDSA("13221fe46bde6a5de07d45248101760b6e32ccd6d36e97ae6950ba95298e4da6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	location := common.Location{0, 0}
+	chainID := big.NewInt(9000)
+	to := common.HexToAddress(entry.ToAddress, location)
+	signed, err := types.SignTx(buildTx(TxParams{
+		Type:     QuaiTxType,
+		ChainID:  chainID,
+		Nonce:    uint64(entry.ID),
+		GasPrice: big.NewInt(1),
+		MinerTip: big.NewInt(1),
+		Gas:      21000,
+		To:       &to,
+		Value:    entry.Value.BigInt(),
+	}), types.NewSigner(chainID, location), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txJSON, err := json.Marshal(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entryJSON, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := &models.Transaction{ID: entry.ID, TxHash: signed.Hash().Hex(), Status: status, Tx: string(txJSON), Entry: string(entryJSON)}
+	if entry.IdempotencyKey != "" {
+		record.IdempotencyKey = &entry.IdempotencyKey
+	}
+	return record
+}
+
+func TestFindStoredTransaction(t *testing.T) {
+	keyed := func(id int32, key, to string) *wtypes.TransferEntry {
+		entry := testEntry(id, to)
+		entry.IdempotencyKey = key
+		return entry
+	}
+	tests := []struct {
+		name    string
+		stored  *wtypes.TransferEntry // recorded by an earlier run, nil for none
+		status  models.TxStatus
+		entry   *wtypes.TransferEntry // of the re-run
+		wantTx  bool                  // the recorded transaction is returned for a rebroadcast
+		wantErr error
+	}{
+		{
+			name:  "no record",
+			entry: keyed(1, "payout-1", testQuaiAddress),
+		},
+		{
+			name:   "same ID, pending",
+			stored: testEntry(1, testQuaiAddress),
+			status: models.Generated,
+			entry:  testEntry(1, testQuaiAddress),
+			wantTx: true,
+		},
+		{
+			name:   "ID changed, same key, pending",
+			stored: keyed(1, "payout-1", testQuaiAddress),
+			status: models.Generated,
+			entry:  keyed(7, "payout-1", testQuaiAddress),
+			wantTx: true,
+		},
+		{
+			name:    "ID changed, same key, confirmed",
+			stored:  keyed(1, "payout-1", testQuaiAddress),
+			status:  models.Confirmed,
+			entry:   keyed(7, "payout-1", testQuaiAddress),
+			wantErr: wtypes.ErrAlreadyProcessed,
+		},
+		{
+			name:    "ID changed, same key, reverted",
+			stored:  keyed(1, "payout-1", testQuaiAddress),
+			status:  models.Failed,
+			entry:   keyed(7, "payout-1", testQuaiAddress),
+			wantErr: wtypes.ErrReverted,
+		},
+		{
+			name:    "ID changed, same key, dead-lettered",
+			stored:  keyed(1, "payout-1", testQuaiAddress),
+			status:  models.DeadLetter,
+			entry:   keyed(7, "payout-1", testQuaiAddress),
+			wantErr: wtypes.ErrDeadLettered,
+		},
+		{
+			name:   "ID changed, other key",
+			stored: keyed(1, "payout-1", testQuaiAddress),
+			status: models.Confirmed,
+			entry:  keyed(7, "payout-7", testQuaiAddress),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var records testRecords
+			if tt.stored != nil {
+				records = append(records, testRecord(t, tt.stored, tt.status))
+			}
+			tx, _, err := findStoredTransaction(context.Background(), records, tt.entry)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantTx {
+				if tx == nil || tx.Hash().Hex() != records[0].TxHash {
+					t.Fatalf("transaction %v, want the recorded %s", tx, records[0].TxHash)
+				}
+			} else if tx != nil {
+				t.Fatalf("transaction %s returned, want none", tx.Hash().Hex())
+			}
+		})
+	}
+
+	t.Run("ID changed, same key, other payout", func(t *testing.T) {
+		records := testRecords{testRecord(t, keyed(1, "payout-1", testQuaiAddress), models.Generated)}
+		entry := keyed(7, "payout-1", testQuaiAddress)
+		entry.Value = decimal.NewFromInt(2)
+		if tx, _, err := findStoredTransaction(context.Background(), records, entry); err == nil {
+			t.Fatalf("transaction %v returned for a different payout under the same key", tx)
+		}
+	})
+}
+
+func TestRebroadcastAlreadyKnown(t *testing.T) {
+	node := newFakeNode()
+	node.handle("quai_sendRawTransaction", func([]json.RawMessage) (any, error) {
+		return nil, errors.New("already known")
+	})
+	entry := testEntry(1, testQuaiAddress)
+	record := testRecord(t, entry, models.Generated)
+	db := &fakeDB{records: []*models.Transaction{record}}
+	w := newFakeWallet(t, &config.Config{MaxRetries: 3}, node, db)
+	ctx := context.Background()
+
+	// A run resumed twice while the transaction is still in the mempool
+	for run := 1; run <= 2; run++ {
+		result := &BatchResult{Total: 1}
+		if err := w.sendBatchEntry(ctx, entry, result); err != nil {
+			t.Fatalf("run %d: entry failed: %v", run, err)
+		}
+		if !reflect.DeepEqual(*result, BatchResult{Total: 1}) {
+			t.Errorf("run %d: entry counted in %+v, want it left to confirm", run, result)
+		}
+		if sends := node.count("quai_sendRawTransaction"); sends != run {
+			t.Errorf("run %d: %d broadcasts, want %d, already known isn't retried", run, sends, run)
+		}
+	}
+
+	if n := w.inFlightCount(); n != 1 {
+		t.Fatalf("%d transactions in flight, want 1", n)
+	}
+	if _, ok := w.pendingTxs[common.HexToHash(record.TxHash)]; !ok {
+		t.Errorf("in-flight transaction isn't the recorded %s", record.TxHash)
+	}
+	if inserts := db.executed("INSERT"); len(inserts) > 0 {
+		t.Errorf("entry signed again: %v", inserts)
+	}
+	if deadLetters := db.executed("failure_code"); len(deadLetters) > 0 {
+		t.Errorf("entry dead-lettered: %v", deadLetters)
+	}
+
+	// Once confirmed, a later run skips it without broadcasting
+	record.Status = models.Confirmed
+	result := &BatchResult{Total: 1}
+	w.sendBatchEntry(ctx, entry, result)
+	if !reflect.DeepEqual(*result, BatchResult{Total: 1, Processed: 1}) {
+		t.Errorf("confirmed entry counted in %+v, want processed once", result)
+	}
+	if sends := node.count("quai_sendRawTransaction"); sends != 2 {
+		t.Errorf("confirmed entry broadcast again, %d broadcasts", sends)
+	}
+}
+
+func TestBroadcastRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		errs      []string // errors of the successive broadcasts, then success
+		wantSends int
+		wantErr   error
+	}{
+		{name: "transient error retried", errs: []string{"connection reset by peer", "503 service unavailable"}, wantSends: 3},
+		{name: "retries exhausted", errs: []string{"timeout", "timeout", "timeout", "timeout"}, wantSends: 3, wantErr: wtypes.ErrDeadLettered},
+		{name: "permanent error not retried", errs: []string{"insufficient funds for gas * price + value"}, wantSends: 1, wantErr: wtypes.ErrDeadLettered},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var raws []string
+			node := newFakeNode()
+			node.handle("quai_sendRawTransaction", func(params []json.RawMessage) (any, error) {
+				raws = append(raws, string(params[0]))
+				if len(raws) <= len(tt.errs) {
+					return nil, errors.New(tt.errs[len(raws)-1])
+				}
+				return nil, nil
+			})
+			entry := testEntry(1, testQuaiAddress)
+			db := &fakeDB{records: []*models.Transaction{testRecord(t, entry, models.Generated)}}
+			w := newFakeWallet(t, &config.Config{MaxRetries: 2, RetryBackoff: time.Millisecond}, node, db)
+
+			err := w.sendBatchEntry(context.Background(), entry, &BatchResult{Total: 1})
+			if tt.wantErr == nil && err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr != nil && (!errors.Is(err, tt.wantErr) || !errors.Is(err, wtypes.ErrNonceGap)) {
+				t.Fatalf("error %v, want %v leaving a nonce gap", err, tt.wantErr)
+			}
+			if len(raws) != tt.wantSends {
+				t.Fatalf("%d broadcasts, want %d", len(raws), tt.wantSends)
+			}
+			for i, raw := range raws {
+				if raw != raws[0] {
+					t.Errorf("broadcast %d sent another transaction than the first", i+1)
+				}
+			}
+			deadLetters := db.executed("failure_code")
+			if tt.wantErr == nil && len(deadLetters) > 0 {
+				t.Errorf("entry dead-lettered: %v", deadLetters)
+			}
+			if tt.wantErr != nil && len(deadLetters) == 0 {
+				t.Error("entry not dead-lettered")
+			}
+		})
+	}
+}
+
+func TestDeadLetterStopsBatch(t *testing.T) {
+	node := newFakeNode()
+	node.handle("quai_sendRawTransaction", func([]json.RawMessage) (any, error) {
+		return nil, errors.New("insufficient funds for gas * price + value")
+	})
+	db := &fakeDB{}
+	batch := []*wtypes.TransferEntry{testEntry(1, testQuaiAddress), testEntry(2, testQuaiAddress)}
+	for _, entry := range batch {
+		db.records = append(db.records, testRecord(t, entry, models.Generated))
+	}
+	w := newFakeWallet(t, &config.Config{Concurrency: 1}, node, db)
+	ctx := context.Background()
+
+	pool := w.newBatchPool()
+	if err := pool.acquire(ctx); err != nil {
+		t.Fatal(err)
+	}
+	pool.send(ctx, batch[0])
+	// With a single worker, the next entry waits for the first to be dead-lettered
+	if err := pool.acquire(ctx); !errors.Is(err, wtypes.ErrNonceGap) {
+		t.Fatalf("next entry acquired with error %v, want %v", err, wtypes.ErrNonceGap)
+	}
+	result := &BatchResult{Total: len(batch)}
+	pool.wait(result)
+	if result.DeadLettered != 1 {
+		t.Errorf("%d entries dead-lettered, want 1", result.DeadLettered)
+	}
+	if sends := node.count("quai_sendRawTransaction"); sends != 1 {
+		t.Errorf("%d broadcasts, want only the dead-lettered entry", sends)
+	}
+}
@@ -0,0 +1,153 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/dominant-strategies/go-quai/common"
+	"github.com/dominant-strategies/go-quai/core/types"
+	"github.com/dominant-strategies/go-quai/rpc"
+)
+
+// IncomingTx is a transaction paying a watched address, as found in a block
+type IncomingTx struct {
+	Hash        common.Hash
+	From        common.Address
+	To          common.Address
+	Value       *big.Int
+	BlockNumber uint64
+}
+
+// WatchIncoming reports transactions sent to the wallet address, starting at fromBlock, or at
+// the latest block when fromBlock is nil. New heads are followed through a subscription; when
+// it drops, the wallet resubscribes and first catches up on the blocks it missed. Nodes that
+// don't support subscriptions are polled every ReceiptWaitTime instead. The channel is closed
+// when ctx is done.
+func (w *Wallet) WatchIncoming(ctx context.Context, fromBlock *big.Int) (<-chan IncomingTx, error) {
+	return w.WatchAddresses(ctx, fromBlock, []common.Address{w.address})
+}
+
+// WatchAddresses is WatchIncoming for a set of addresses in the wallet's location, scanning each
+// block once for all of them
+func (w *Wallet) WatchAddresses(ctx context.Context, fromBlock *big.Int, addresses []common.Address) (<-chan IncomingTx, error) {
+	watched := make(map[common.AddressBytes]bool, len(addresses))
+	for _, address := range addresses {
+		watched[address.Bytes20()] = true
+	}
+
+	var next uint64
+	if fromBlock != nil {
+		next = fromBlock.Uint64()
+	} else {
+		latest, err := w.rpc().BlockNumber(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get block number: %w", err)
+		}
+		next = latest
+	}
+
+	out := make(chan IncomingTx)
+	go func() {
+		defer close(out)
+
+		polling := false
+		for ctx.Err() == nil {
+			latest, err := w.rpc().BlockNumber(ctx)
+			if err == nil {
+				err = w.scanIncoming(ctx, &next, latest, watched, out)
+			}
+			if err != nil {
+				log.Printf("failed to scan blocks from %d: %v", next, err)
+			}
+
+			heads := make(chan *types.WorkObject, 16)
+			sub, err := w.rpc().SubscribeNewHead(ctx, heads)
+			if err != nil {
+				if !polling {
+					polling = true
+					if errors.Is(err, rpc.ErrNotificationsUnsupported) {
+						log.Printf("node does not support subscriptions, polling for new blocks every %s", ReceiptWaitTime)
+					} else {
+						log.Printf("failed to subscribe to new heads, polling every %s: %v", ReceiptWaitTime, err)
+					}
+				}
+				sleepCtx(ctx, ReceiptWaitTime)
+				continue
+			}
+			polling = false
+
+			err = w.followHeads(ctx, &next, heads, sub.Err(), watched, out)
+			sub.Unsubscribe()
+			if err != nil {
+				log.Printf("new head subscription dropped, reconnecting: %v", err)
+				sleepCtx(ctx, ReceiptWaitTime)
+			}
+		}
+	}()
+	return out, nil
+}
+
+// followHeads scans every new head until the subscription fails or ctx is done
+func (w *Wallet) followHeads(ctx context.Context, next *uint64, heads <-chan *types.WorkObject, subErr <-chan error, watched map[common.AddressBytes]bool, out chan<- IncomingTx) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-subErr:
+			if err == nil {
+				err = errors.New("subscription closed")
+			}
+			return err
+		case head := <-heads:
+			if err := w.scanIncoming(ctx, next, head.NumberU64(common.ZONE_CTX), watched, out); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// scanIncoming emits the transactions paying a watched address from block *next up to latest,
+// advancing *next past every block fully scanned
+func (w *Wallet) scanIncoming(ctx context.Context, next *uint64, latest uint64, watched map[common.AddressBytes]bool, out chan<- IncomingTx) error {
+	signer := types.NewSigner(w.chainID.Expected, w.location)
+	for ; *next <= latest; *next++ {
+		block, err := w.rpc().BlockByNumber(ctx, new(big.Int).SetUint64(*next))
+		if err != nil {
+			return fmt.Errorf("failed to get block %d: %w", *next, err)
+		}
+
+		for _, tx := range block.Transactions() {
+			if tx.Type() != types.QuaiTxType && tx.Type() != types.ExternalTxType {
+				continue
+			}
+			to := tx.To()
+			if to == nil || !watched[to.Bytes20()] {
+				continue
+			}
+
+			from, err := types.Sender(signer, tx)
+			if err != nil {
+				log.Printf("failed to recover sender of incoming transaction %s: %v", tx.Hash().Hex(), err)
+			}
+
+			select {
+			case out <- IncomingTx{Hash: tx.Hash(), From: from, To: *to, Value: tx.Value(), BlockNumber: *next}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+	return nil
+}
+
+// sleepCtx waits for d or until ctx is done
+func sleepCtx(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}